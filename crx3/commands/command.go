@@ -27,6 +27,21 @@ func New(version string) *cobra.Command {
 	cmd.AddCommand(newMCPCmd(version))
 	cmd.AddCommand(newSearchCmd())
 	cmd.AddCommand(newScanCmd())
+	cmd.AddCommand(newPolicyCmd())
+	cmd.AddCommand(newExternalCmd())
+	cmd.AddCommand(newProfileCmd())
+	cmd.AddCommand(newDedupeCmd())
+	cmd.AddCommand(newIndexCmd())
+	cmd.AddCommand(newQueryCmd())
+	cmd.AddCommand(newIntegrityCmd())
+	cmd.AddCommand(newDiffCmd())
+	cmd.AddCommand(newInspectCmd())
+	cmd.AddCommand(newLsCmd())
+	cmd.AddCommand(newCatCmd())
+	cmd.AddCommand(newExtractCmd())
+	cmd.AddCommand(newPatchCmd())
+	cmd.AddCommand(newManifestCmd())
+	cmd.AddCommand(newKeysCmd())
 
 	return cmd
 }
@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mediabuyerbot/go-crx3"
+	"github.com/spf13/cobra"
+)
+
+type dedupeOpts struct {
+	MaxDepth int
+	Keep     int
+	Prune    bool
+	DryRun   bool
+	Output   *outputOpts
+}
+
+// dedupeResult is one copy of a duplicated extension and the action taken on it.
+type dedupeResult struct {
+	ID          string   `json:"id,omitempty"`
+	Versions    []string `json:"versions"`
+	Version     string   `json:"version,omitempty"`
+	ContentHash string   `json:"contentHash,omitempty"`
+	Type        string   `json:"type"`
+	Size        int64    `json:"size"`
+	Path        string   `json:"path"`
+	Action      string   `json:"action"`
+}
+
+const (
+	dedupeKeep    = "keep"
+	dedupeRemove  = "remove"
+	dedupeRemoved = "removed"
+)
+
+var dedupeColumns = []column[dedupeResult]{
+	{Name: "id", Value: func(r dedupeResult) string { return r.ID }},
+	{Name: "version", Value: func(r dedupeResult) string { return r.Version }},
+	{Name: "versions", Value: func(r dedupeResult) string { return strings.Join(r.Versions, ",") }},
+	{Name: "hash", Value: func(r dedupeResult) string { return shortHash(r.ContentHash) }},
+	{Name: "type", Value: func(r dedupeResult) string { return r.Type }},
+	{Name: "action", Value: func(r dedupeResult) string { return r.Action }},
+	{Name: "path", Value: func(r dedupeResult) string { return r.Path }},
+}
+
+func newDedupeCmd() *cobra.Command {
+	var opts dedupeOpts
+	cmd := &cobra.Command{
+		Use:   "dedupe [path]",
+		Short: "Find duplicate extensions and old versions in a directory",
+		Long: `Dedupe scans a directory for extensions and groups them by extension ID and content hash.
+Every group lists the versions present; copies are ordered newest first by version
+and modification time. The content hash covers the extension files only, so a CRX file,
+its ZIP archive and its unpacked directory are recognized as identical.
+With --prune all copies except the newest --keep per extension ID are deleted.`,
+		Example: `$ crx3 dedupe ~/extensions
+$ crx3 dedupe ~/extensions --prune --keep 2 --dry-run
+$ crx3 dedupe ~/extensions --prune --output json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Output.Validate(); err != nil {
+				return err
+			}
+			if opts.Keep < 1 {
+				return fmt.Errorf("--keep must be at least 1")
+			}
+			path := "."
+			if len(args) > 0 {
+				path = args[0]
+			}
+			path, err := toPath(path)
+			if err != nil {
+				return err
+			}
+
+			scanOpts := []crx3.ScanOption{crx3.WithContinueOnError(), crx3.WithSortedResults()}
+			if opts.MaxDepth >= 0 {
+				scanOpts = append(scanOpts, crx3.WithMaxDepth(opts.MaxDepth))
+			}
+			groups, err := crx3.FindDuplicates(path, scanOpts...)
+			if err != nil {
+				return err
+			}
+			if len(groups) == 0 {
+				fmt.Fprintln(os.Stderr, "No duplicates found.")
+			}
+
+			var results []dedupeResult
+			for _, group := range groups {
+				keep := len(group.Copies)
+				if opts.Prune {
+					keep = opts.Keep
+				}
+				kept, removed := group.Prune(keep)
+				for _, c := range kept {
+					results = append(results, newDedupeResult(group, c, dedupeKeep))
+				}
+				for _, c := range removed {
+					action := dedupeRemove
+					if !opts.DryRun {
+						if err := crx3.RemoveExtension(c.ExtensionInfo); err != nil {
+							return fmt.Errorf("failed to remove %s: %w", c.Path, err)
+						}
+						action = dedupeRemoved
+					}
+					results = append(results, newDedupeResult(group, c, action))
+				}
+			}
+			return writeRecords(os.Stdout, opts.Output, results, dedupeColumns)
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.MaxDepth, "depth", 5, "Maximum directory depth to scan (0 = only root, -1 = unlimited)")
+	cmd.Flags().IntVar(&opts.Keep, "keep", 1, "Number of newest copies to keep per extension when pruning")
+	cmd.Flags().BoolVar(&opts.Prune, "prune", false, "Delete all copies except the newest --keep per extension")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Only show what --prune would delete")
+	opts.Output = addOutputFlags(cmd, outputTable)
+
+	return cmd
+}
+
+func newDedupeResult(group *crx3.DuplicateGroup, c *crx3.ExtensionCopy, action string) dedupeResult {
+	return dedupeResult{
+		ID:          group.ID,
+		Versions:    group.Versions,
+		Version:     c.Version,
+		ContentHash: c.ContentHash,
+		Type:        c.Type,
+		Size:        c.Size,
+		Path:        c.Path,
+		Action:      action,
+	}
+}
+
+// shortHash shortens a content hash for table output.
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/mediabuyerbot/go-crx3"
+	"github.com/spf13/cobra"
+)
+
+// outputMarkdown is accepted as an alias of the text output, the Markdown report.
+const outputMarkdown = "markdown"
+
+// diffFileCount returns a column with the number of files of status.
+func diffFileCount(name, status string) column[*crx3.ExtensionDiff] {
+	return column[*crx3.ExtensionDiff]{Name: name, Value: func(d *crx3.ExtensionDiff) string {
+		n := 0
+		for _, f := range d.Files {
+			if f.Status == status {
+				n++
+			}
+		}
+		return strconv.Itoa(n)
+	}}
+}
+
+var diffColumns = []column[*crx3.ExtensionDiff]{
+	{Name: "old", Value: func(d *crx3.ExtensionDiff) string { return d.Old }},
+	{Name: "new", Value: func(d *crx3.ExtensionDiff) string { return d.New }},
+	{Name: "signature_changed", Value: func(d *crx3.ExtensionDiff) string { return strconv.FormatBool(d.Signature.Changed) }},
+	{Name: "manifest_changed", Value: func(d *crx3.ExtensionDiff) string { return strconv.FormatBool(!d.Manifest.Empty()) }},
+	diffFileCount("added", crx3.FileAdded),
+	diffFileCount("removed", crx3.FileRemoved),
+	diffFileCount("modified", crx3.FileModified),
+}
+
+type diffOpts struct {
+	Output      *outputOpts
+	Context     int
+	MaxTextSize int
+	NoText      bool
+	ExitCode    bool
+}
+
+func newDiffCmd() *cobra.Command {
+	var opts diffOpts
+	cmd := &cobra.Command{
+		Use:   "diff [old] [new]",
+		Short: "Show what changed between two versions of an extension",
+		Long: `Diff compares two versions of an extension. Each of them may be a CRX file, a ZIP archive
+or an unpacked directory. The report lists:
+- signature changes: a different extension ID, manifest key or CRX signing key is flagged first
+- manifest changes: version, CSP and other fields, added and removed permissions and hosts
+- files added, removed and modified, with size deltas
+- unified diffs of modified JS, JSON, HTML and CSS files
+The text output is a Markdown report ("--output markdown" is an alias); json, yaml and
+ndjson print the whole diff, table and csv a summary.`,
+		Example: `$ crx3 diff old.crx new.crx
+$ crx3 diff old.crx ./new-extension --output json
+$ crx3 diff old.crx new.crx --output table
+$ crx3 diff old.zip new.zip --no-text --exit-code`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Output.Format == outputMarkdown {
+				opts.Output.Format = outputText
+			}
+			if err := opts.Output.Validate(); err != nil {
+				return err
+			}
+			oldPath, err := toPath(args[0])
+			if err != nil {
+				return err
+			}
+			newPath, err := toPath(args[1])
+			if err != nil {
+				return err
+			}
+
+			diffOpts := []crx3.DiffOption{crx3.WithDiffContext(opts.Context)}
+			if opts.MaxTextSize > 0 {
+				diffOpts = append(diffOpts, crx3.WithMaxTextDiffSize(opts.MaxTextSize))
+			}
+			if opts.NoText {
+				diffOpts = append(diffOpts, crx3.WithoutTextDiffs())
+			}
+			diff, err := crx3.Diff(oldPath, newPath, diffOpts...)
+			if err != nil {
+				return err
+			}
+
+			if opts.Output.Format == outputText && len(opts.Output.Template) == 0 {
+				err = diff.WriteMarkdown(os.Stdout)
+			} else {
+				err = writeRecord(os.Stdout, opts.Output, diff, diffColumns)
+			}
+			if err != nil {
+				return err
+			}
+			if diff.Signature.Changed {
+				fmt.Fprintln(os.Stderr, "WARNING: the signing key or extension ID changed.")
+			}
+			if opts.ExitCode && !diff.Empty() {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("extensions differ")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&opts.Context, "context", "U", 3, "Number of context lines in text diffs")
+	cmd.Flags().IntVar(&opts.MaxTextSize, "max-text-size", 0, "Skip text diffs of files larger than this many bytes (default 1 MiB)")
+	cmd.Flags().BoolVar(&opts.NoText, "no-text", false, "Only list modified files without text diffs")
+	cmd.Flags().BoolVar(&opts.ExitCode, "exit-code", false, "Exit with an error if the extensions differ")
+	opts.Output = addOutputFlags(cmd, outputText)
+
+	return cmd
+}
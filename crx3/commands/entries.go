@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mediabuyerbot/go-crx3"
+	"github.com/spf13/cobra"
+)
+
+var entryColumns = []column[crx3.Entry]{
+	{Name: "size", Value: func(e crx3.Entry) string { return strconv.FormatUint(e.Size, 10) }},
+	{Name: "compressed", Value: func(e crx3.Entry) string { return strconv.FormatUint(e.CompressedSize, 10) }},
+	{Name: "modified", Value: func(e crx3.Entry) string { return e.Modified.Format(time.DateTime) }},
+	{Name: "crc32", Value: func(e crx3.Entry) string { return fmt.Sprintf("%08x", e.CRC32) }},
+	{Name: "name", Value: func(e crx3.Entry) string { return e.Name }},
+}
+
+func newLsCmd() *cobra.Command {
+	var (
+		output *outputOpts
+		dirs   bool
+	)
+	cmd := &cobra.Command{
+		Use:   "ls [extension.crx]",
+		Short: "List the files of a CRX or ZIP file without unpacking it",
+		Long: `Ls lists the entries of the ZIP archive embedded in a CRX file, or of a ZIP file,
+with their size, compressed size, modification time and CRC-32 checksum.`,
+		Example: `$ crx3 ls extension.crx
+$ crx3 ls extension.crx --template '{{.Name}}'`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := output.Validate(); err != nil {
+				return err
+			}
+			filename, err := toPath(args[0])
+			if err != nil {
+				return err
+			}
+			entries, err := crx3.ListEntries(filename)
+			if err != nil {
+				return err
+			}
+			files := entries[:0]
+			for _, e := range entries {
+				if dirs || !e.IsDir {
+					files = append(files, e)
+				}
+			}
+			return writeRecords(os.Stdout, output, files, entryColumns)
+		},
+	}
+	cmd.Flags().BoolVar(&dirs, "dirs", false, "Also list directory entries")
+	output = addOutputFlags(cmd, outputTable)
+
+	return cmd
+}
+
+func newCatCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cat [extension.crx] [path...]",
+		Short: "Print files stored in a CRX or ZIP file",
+		Long: `Cat reads files directly from the ZIP archive embedded in a CRX file, or from a ZIP file,
+and writes them to standard output.`,
+		Example: `$ crx3 cat extension.crx manifest.json
+$ crx3 cat extension.crx js/background.js js/content.js`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filename, err := toPath(args[0])
+			if err != nil {
+				return err
+			}
+			for _, name := range args[1:] {
+				if err := crx3.CopyEntry(os.Stdout, filename, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func newExtractCmd() *cobra.Command {
+	var dir string
+	cmd := &cobra.Command{
+		Use:   "extract [extension.crx] [pattern...]",
+		Short: "Extract matching files from a CRX or ZIP file",
+		Long: `Extract writes the files of a CRX or ZIP file that match one of the glob patterns to a directory,
+keeping their paths. Patterns use the Go filepath.Match syntax and are matched against
+the path inside the archive, the base name and the parent directories,
+so 'js' extracts the whole js directory. Quote patterns to keep the shell from expanding them.`,
+		Example: `$ crx3 extract extension.crx 'js/*.js'
+$ crx3 extract extension.crx manifest.json '*.png' --dir ./out`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filename, err := toPath(args[0])
+			if err != nil {
+				return err
+			}
+			target, err := toPath(dir)
+			if err != nil {
+				return err
+			}
+			extracted, err := crx3.ExtractEntries(filename, target, args[1:]...)
+			for _, name := range extracted {
+				fmt.Println(name)
+			}
+			if err != nil {
+				return err
+			}
+			if len(extracted) == 0 {
+				return fmt.Errorf("no files match %v", args[1:])
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&dir, "dir", "d", ".", "Directory to extract files to")
+
+	return cmd
+}
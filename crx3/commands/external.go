@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	crx3 "github.com/mediabuyerbot/go-crx3"
+	"github.com/spf13/cobra"
+)
+
+type externalOpts struct {
+	Outdir    string
+	CopyTo    string
+	UpdateURL string
+}
+
+func newExternalCmd() *cobra.Command {
+	var opts externalOpts
+	cmd := &cobra.Command{
+		Use:   "external [extension.crx]",
+		Short: "Write the external extension preferences file used to preinstall an extension on Linux",
+		Long: `Write <id>.json with external_crx and external_version, or external_update_url,
+for preinstalling an extension through ` + crx3.ExternalExtensionsDir + `.
+The extension ID is taken from the CRX header and the version from the manifest.`,
+		Example: `$ crx3 external ./ext.crx -o /usr/share/google-chrome/extensions --copy-to /opt/extensions
+$ crx3 external ./ext.crx --update-url https://clients2.google.com/service/update2/crx`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("extension is required")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			infile, err := toPath(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid infile path: %w", err)
+			}
+			outdir, err := toPath(opts.Outdir)
+			if err != nil {
+				return fmt.Errorf("invalid output directory path: %w", err)
+			}
+			if len(outdir) == 0 {
+				outdir = "."
+			}
+			var externalOpts []crx3.ExternalOption
+			if len(opts.CopyTo) > 0 {
+				copyTo, err := toPath(opts.CopyTo)
+				if err != nil {
+					return fmt.Errorf("invalid copy-to path: %w", err)
+				}
+				externalOpts = append(externalOpts, crx3.ExternalCopyTo(copyTo))
+			}
+			if len(opts.UpdateURL) > 0 {
+				externalOpts = append(externalOpts, crx3.ExternalUpdateURL(opts.UpdateURL))
+			}
+			filename, err := crx3.WriteExternalExtension(infile, outdir, externalOpts...)
+			if err != nil {
+				return err
+			}
+			fmt.Println(filename)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Outdir, "outdir", "o", "", "directory to write <id>.json to (default is current directory)")
+	cmd.Flags().StringVarP(&opts.CopyTo, "copy-to", "c", "", "copy the crx file into this directory and reference the copy")
+	cmd.Flags().StringVarP(&opts.UpdateURL, "update-url", "u", "", "write external_update_url instead of external_crx")
+
+	return cmd
+}
@@ -3,18 +3,47 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/mediabuyerbot/go-crx3"
 	"github.com/spf13/cobra"
 )
 
+type idResult struct {
+	ID         string `json:"id"`
+	ManifestID string `json:"manifestId,omitempty"`
+	HeaderID   string `json:"headerId,omitempty"`
+	Consistent bool   `json:"consistent"`
+	Path       string `json:"path"`
+}
+
+var idColumns = []column[idResult]{
+	{Name: "id", Value: func(r idResult) string { return r.ID }},
+	{Name: "manifest_id", Value: func(r idResult) string { return r.ManifestID }},
+	{Name: "header_id", Value: func(r idResult) string { return r.HeaderID }},
+	{Name: "consistent", Value: func(r idResult) string { return fmt.Sprint(r.Consistent) }},
+	{Name: "path", Value: func(r idResult) string { return r.Path }},
+}
+
 func newIDCmd() *cobra.Command {
+	var (
+		strict       bool
+		unpackedPath bool
+		output       *outputOpts
+	)
 	cmd := &cobra.Command{
 		Use:   "id [infile]",
 		Short: "Generate id from header extension or manifest file",
 		Long: `
 The identifier is generated from the hash of the public key, which is located in the extension header or declared in the key field of the manifest.
 If the key is specified in the manifest, the public key is taken from there; otherwise, the search continues in the header.
+For CRX files both IDs are compared: Chrome refuses to install a CRX file whose manifest key and signing key
+give different IDs. Such a mismatch is reported on stderr, and with --strict the command fails.
+The text output prints the ID on the first line; for a CRX file with a manifest key it is
+followed by "manifest:" and "header:" lines with both IDs. The table, csv and json outputs show both IDs.
+With --unpacked-path a directory without a key gets the ID Chrome assigns when it is loaded
+unpacked in developer mode, derived from the absolute path of the directory.
 `,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) < 1 {
@@ -23,18 +52,65 @@ If the key is specified in the manifest, the public key is taken from there; oth
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			if err := output.Validate(); err != nil {
+				return err
+			}
 			infile, err := toPath(args[0])
 			if err != nil {
 				return err
 			}
 			ext := crx3.Extension(infile)
-			id, err := ext.ID()
+			if unpackedPath && ext.IsDir() {
+				id, err := ext.ID(crx3.IDWithPathFallback())
+				if err != nil {
+					return err
+				}
+				return writeRecord(os.Stdout, output, idResult{ID: id, Consistent: true, Path: infile}, idColumns)
+			}
+			check, err := crx3.CheckID(infile)
 			if err != nil {
 				return err
 			}
-			fmt.Println(id)
+			result := idResult{
+				ID:         check.ID(),
+				ManifestID: check.ManifestID,
+				HeaderID:   check.HeaderID,
+				Consistent: check.Consistent(),
+				Path:       infile,
+			}
+			if output.Format == outputText && len(output.Template) == 0 {
+				err = writeIDText(os.Stdout, result)
+			} else {
+				err = writeRecord(os.Stdout, output, result, idColumns)
+			}
+			if err != nil {
+				return err
+			}
+			if !check.Consistent() {
+				if strict {
+					return check.Err()
+				}
+				fmt.Fprintf(os.Stderr, "WARNING: manifest key gives ID %s, but the CRX is signed by a key with ID %s.\n",
+					check.ManifestID, check.HeaderID)
+			}
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&strict, "strict", false, "fail if the manifest key and the signing key give different IDs")
+	cmd.Flags().BoolVar(&unpackedPath, "unpacked-path", false, "derive the ID of a directory without a key from its path, as Chrome does in developer mode")
+	output = addOutputFlags(cmd, outputText)
 	return cmd
 }
+
+// writeIDText prints the ID and, if the manifest key and the CRX header both
+// give one, the two IDs on lines of their own.
+func writeIDText(w io.Writer, r idResult) error {
+	if _, err := fmt.Fprintln(w, r.ID); err != nil {
+		return err
+	}
+	if len(r.ManifestID) == 0 || len(r.HeaderID) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "manifest: %s\nheader: %s\n", r.ManifestID, r.HeaderID)
+	return err
+}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mediabuyerbot/go-crx3"
+	"github.com/spf13/cobra"
+)
+
+type indexOpts struct {
+	IndexFile      string
+	MaxDepth       int
+	Exclude        []string
+	Hidden         bool
+	FollowSymlinks bool
+	Rebuild        bool
+}
+
+func newIndexCmd() *cobra.Command {
+	var opts indexOpts
+	cmd := &cobra.Command{
+		Use:   "index [path]",
+		Short: "Build or update the extension catalog of a directory",
+		Long: `Index scans a directory for extensions and stores their IDs, versions, paths, content hashes,
+manifest summary and permissions in a single catalog file (` + crx3.IndexFilename + ` in the scanned directory).
+Subsequent runs only re-read extensions whose size or modification time changed; for unpacked
+directories the total size and the newest modification time of all files inside are compared.
+Use 'crx3 query' to search the catalog without touching the extensions again.`,
+		Example: `$ crx3 index ~/extensions
+$ crx3 index ~/extensions --rebuild`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := toPath(firstArg(args, "."))
+			if err != nil {
+				return err
+			}
+			filename := opts.IndexFile
+			if len(filename) == 0 {
+				filename = crx3.IndexPath(root)
+			}
+
+			var prev *crx3.Index
+			if !opts.Rebuild {
+				prev, err = crx3.LoadIndex(filename)
+				if err != nil && !errors.Is(err, crx3.ErrIndexNotFound) {
+					fmt.Fprintf(os.Stderr, "rebuilding index: %v\n", err)
+				}
+			}
+
+			var scanOpts []crx3.ScanOption
+			if opts.MaxDepth >= 0 {
+				scanOpts = append(scanOpts, crx3.WithMaxDepth(opts.MaxDepth))
+			}
+			if len(opts.Exclude) > 0 {
+				scanOpts = append(scanOpts, crx3.WithExcludeGlob(opts.Exclude...))
+			}
+			if opts.Hidden {
+				scanOpts = append(scanOpts, crx3.WithHidden())
+			}
+			if opts.FollowSymlinks {
+				scanOpts = append(scanOpts, crx3.WithFollowSymlinks())
+			}
+			idx, stats, err := crx3.UpdateIndex(root, prev, scanOpts...)
+			if err != nil {
+				return err
+			}
+			if err := crx3.SaveIndex(idx, filename); err != nil {
+				return fmt.Errorf("failed to write index: %w", err)
+			}
+			fmt.Printf("%s: %d extensions (%d added, %d updated, %d unchanged, %d removed)\n",
+				filename, len(idx.Entries), stats.Added, stats.Updated, stats.Unchanged, stats.Removed)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.IndexFile, "index", "", "index file (default <path>/"+crx3.IndexFilename+")")
+	cmd.Flags().IntVar(&opts.MaxDepth, "depth", 5, "Maximum directory depth to scan (0 = only root, -1 = unlimited)")
+	cmd.Flags().StringSliceVar(&opts.Exclude, "exclude", nil, "Skip paths matching these glob patterns")
+	cmd.Flags().BoolVar(&opts.Hidden, "hidden", false, "Scan hidden files and directories")
+	cmd.Flags().BoolVar(&opts.FollowSymlinks, "follow-symlinks", false, "Follow symbolic links to files and directories")
+	cmd.Flags().BoolVar(&opts.Rebuild, "rebuild", false, "Ignore the existing index and read every extension again")
+
+	return cmd
+}
+
+func newQueryCmd() *cobra.Command {
+	var (
+		indexFile string
+		maxLimit  int
+		filters   scanFilterFlags
+		output    *outputOpts
+	)
+	cmd := &cobra.Command{
+		Use:   "query [path]",
+		Short: "Search the extension catalog built by 'crx3 index'",
+		Long: `Query filters the catalog written by 'crx3 index' with the same filters as 'crx3 scan'.
+Values of one filter are joined with OR, different filters with AND.`,
+		Example: `# all Manifest V2 extensions requesting the debugger permission
+$ crx3 query ~/extensions --permission debugger --manifest-version 2
+$ crx3 query ~/extensions --type crx --output csv`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := output.Validate(); err != nil {
+				return err
+			}
+			root, err := toPath(firstArg(args, "."))
+			if err != nil {
+				return err
+			}
+			filename := indexFile
+			if len(filename) == 0 {
+				filename = crx3.IndexPath(root)
+			}
+			idx, err := crx3.LoadIndex(filename)
+			if errors.Is(err, crx3.ErrIndexNotFound) {
+				return fmt.Errorf("%w, run 'crx3 index %s' first", err, root)
+			}
+			if err != nil {
+				return err
+			}
+
+			opts, err := filters.options()
+			if err != nil {
+				return err
+			}
+			if maxLimit > 0 {
+				opts = append(opts, crx3.WithMaxResults(maxLimit))
+			}
+			results := make([]*crx3.ExtensionInfo, 0)
+			for e := range idx.Query(opts...) {
+				results = append(results, &e.ExtensionInfo)
+			}
+			if len(results) == 0 {
+				fmt.Fprintln(os.Stderr, "No extensions found.")
+			}
+			return writeRecords(os.Stdout, output, results, scanColumns)
+		},
+	}
+
+	cmd.Flags().StringVar(&indexFile, "index", "", "index file (default <path>/"+crx3.IndexFilename+")")
+	cmd.Flags().IntVar(&maxLimit, "limit", 0, "Maximum number of extensions to return (0 = unlimited)")
+	filters.register(cmd)
+	output = addOutputFlags(cmd, outputTable)
+
+	return cmd
+}
+
+// firstArg returns args[0] or def if there are no arguments.
+func firstArg(args []string, def string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return def
+}
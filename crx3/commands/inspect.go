@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mediabuyerbot/go-crx3"
+	"github.com/spf13/cobra"
+)
+
+var inspectColumns = []column[*crx3.CRXInfo]{
+	{Name: "crx_id", Value: func(i *crx3.CRXInfo) string { return i.CrxID }},
+	{Name: "version", Value: func(i *crx3.CRXInfo) string {
+		if i.Manifest == nil {
+			return ""
+		}
+		return i.Manifest.Version
+	}},
+	{Name: "proofs", Value: func(i *crx3.CRXInfo) string { return strconv.Itoa(len(i.Proofs)) }},
+	{Name: "entries", Value: func(i *crx3.CRXInfo) string { return strconv.Itoa(i.Entries) }},
+	{Name: "problems", Value: func(i *crx3.CRXInfo) string { return strings.Join(i.Problems, "; ") }},
+	{Name: "path", Value: func(i *crx3.CRXInfo) string { return i.Path }},
+}
+
+func newInspectCmd() *cobra.Command {
+	var (
+		output *outputOpts
+		asJSON bool
+	)
+	cmd := &cobra.Command{
+		Use:   "inspect [extension.crx]",
+		Short: "Dump the structure of a CRX file",
+		Long: `Inspect prints the structure of a CRX3 file for debugging broken packages:
+the magic number, format version and header size, every key proof with its algorithm,
+key size, key fingerprint, derived ID and whether it matches the crx_id and its signature
+is valid, the signed crx_id, the offset and size of the embedded ZIP archive, the number
+of entries, a manifest summary and protobuf fields unknown to the CRX3 format.
+The command fails if any problem was found.`,
+		Example: `$ crx3 inspect extension.crx
+$ crx3 inspect extension.crx --json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if asJSON {
+				output.Format = outputJSON
+			}
+			if err := output.Validate(); err != nil {
+				return err
+			}
+			path, err := toPath(args[0])
+			if err != nil {
+				return err
+			}
+			info, err := crx3.Inspect(path)
+			if err != nil {
+				return err
+			}
+			if output.Format == outputText && len(output.Template) == 0 {
+				err = writeInspectReport(os.Stdout, info)
+			} else {
+				err = writeRecord(os.Stdout, output, info, inspectColumns)
+			}
+			if err != nil {
+				return err
+			}
+			if !info.OK() {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d problem(s) found in %s", len(info.Problems), path)
+			}
+			return nil
+		},
+	}
+	output = addOutputFlags(cmd, outputText)
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the structure as JSON (same as --output json)")
+
+	return cmd
+}
+
+// writeInspectReport prints the structure of a CRX file in a human-readable form.
+func writeInspectReport(w io.Writer, info *crx3.CRXInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	row := func(name string, value any) {
+		fmt.Fprintf(tw, "%s:\t%v\n", name, value)
+	}
+	row("Path", info.Path)
+	row("File size", info.FileSize)
+	row("Magic", strconv.Quote(info.Magic))
+	row("Version", info.Version)
+	row("Header size", info.HeaderSize)
+	row("CRX ID", fmt.Sprintf("%s (%s)", orDash(info.CrxID), orDash(info.CrxIDHex)))
+	row("ZIP offset", info.ZipOffset)
+	row("ZIP size", info.ZipSize)
+	row("Entries", info.Entries)
+	if m := info.Manifest; m != nil {
+		row("Name", m.Name)
+		row("Extension version", m.Version)
+		row("Manifest version", m.ManifestVersion)
+		row("Manifest key", m.HasKey)
+		row("Permissions", orDash(strings.Join(m.Permissions, ", ")))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nProofs (%d):\n", len(info.Proofs))
+	for i, p := range info.Proofs {
+		fmt.Fprintf(w, "  [%d] %s %s-%d\n", i, p.Algorithm, orDash(p.KeyType), p.KeySize)
+		fmt.Fprintf(w, "      fingerprint: %s\n", p.Fingerprint)
+		fmt.Fprintf(w, "      id:          %s (matches crx_id: %t)\n", p.ID, p.MatchesCrxID)
+		fmt.Fprintf(w, "      signature:   %d bytes (valid: %t)\n", p.SignatureSize, p.SignatureValid)
+		if len(p.Error) > 0 {
+			fmt.Fprintf(w, "      error:       %s\n", p.Error)
+		}
+	}
+	if len(info.UnknownFields) > 0 {
+		fmt.Fprintf(w, "\nUnknown fields (%d):\n", len(info.UnknownFields))
+		for _, f := range info.UnknownFields {
+			fmt.Fprintf(w, "  %s: field %d (%s, %d bytes)\n", f.Message, f.Number, f.WireType, f.Size)
+		}
+	}
+	if len(info.Problems) > 0 {
+		fmt.Fprintf(w, "\nProblems (%d):\n", len(info.Problems))
+		for _, p := range info.Problems {
+			fmt.Fprintf(w, "  - %s\n", p)
+		}
+	}
+	return nil
+}
+
+func orDash(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return s
+}
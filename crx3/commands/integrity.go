@@ -0,0 +1,163 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mediabuyerbot/go-crx3"
+	"github.com/spf13/cobra"
+)
+
+// integrityResult is one file that differs from the reference.
+type integrityResult struct {
+	Status string `json:"status"`
+	Path   string `json:"path"`
+}
+
+var integrityColumns = []column[integrityResult]{
+	{Name: "status", Value: func(r integrityResult) string { return r.Status }},
+	{Name: "path", Value: func(r integrityResult) string { return r.Path }},
+}
+
+func newIntegrityCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "integrity",
+		Short: "Verify unpacked extensions against their recorded digests",
+	}
+
+	cmd.AddCommand(newIntegrityCheckCmd())
+	cmd.AddCommand(newIntegrityVerifyContentsCmd())
+	cmd.AddCommand(newIntegrityHashesCmd())
+
+	return cmd
+}
+
+func newIntegrityCheckCmd() *cobra.Command {
+	var output *outputOpts
+	cmd := &cobra.Command{
+		Use:   "check [dir] [extension.crx]",
+		Short: "Report files added, removed or modified in an unpacked extension",
+		Long: `Check compares the files of an unpacked extension with the SHA-256 digests recorded
+in ` + crx3.SumFilename + ` when it was unpacked, or with the original CRX or ZIP file if one is given.
+The command fails if any file was added, removed or modified.`,
+		Example: `$ crx3 integrity check ./extension
+$ crx3 integrity check ./extension extension.crx --output json`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := output.Validate(); err != nil {
+				return err
+			}
+			dir, err := toPath(args[0])
+			if err != nil {
+				return err
+			}
+			var reference string
+			if len(args) > 1 {
+				if reference, err = toPath(args[1]); err != nil {
+					return err
+				}
+			}
+			report, err := crx3.CheckIntegrity(dir, reference)
+			if err != nil {
+				return err
+			}
+
+			return writeIntegrityReport(cmd, output, report)
+		},
+	}
+	output = addOutputFlags(cmd, outputTable)
+
+	return cmd
+}
+
+func newIntegrityVerifyContentsCmd() *cobra.Command {
+	var output *outputOpts
+	cmd := &cobra.Command{
+		Use:   "verify-contents [extension]",
+		Short: "Verify extension files against the Web Store's verified_contents.json",
+		Long: `Verify-contents computes the SHA-256 tree hash of every file of an unpacked directory,
+ZIP or CRX file and compares it with the root hashes in ` + crx3.VerifiedContentsFilename + `,
+which the Chrome Web Store adds to the extensions it serves. File paths are compared
+case-insensitively, as Chrome does. The signatures of verified_contents.json are listed
+but not verified. The command fails if any file was added, removed or modified.`,
+		Example: `$ crx3 integrity verify-contents ./extension
+$ crx3 integrity verify-contents extension.crx --output json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := output.Validate(); err != nil {
+				return err
+			}
+			path, err := toPath(args[0])
+			if err != nil {
+				return err
+			}
+			contents, err := crx3.ReadVerifiedContents(path)
+			if err != nil {
+				return err
+			}
+			report, err := contents.Verify(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Item %s version %s, signed by %s.\n",
+				contents.ItemID, contents.ItemVersion, strings.Join(contents.Signatures, ", "))
+			return writeIntegrityReport(cmd, output, report)
+		},
+	}
+	output = addOutputFlags(cmd, outputTable)
+
+	return cmd
+}
+
+func newIntegrityHashesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hashes [dir]",
+		Short: "Write " + crx3.ComputedHashesFilename + " for an unpacked extension",
+		Long: `Hashes computes the SHA-256 hash of every 4096-byte block of every file of an
+unpacked extension and writes them to ` + crx3.ComputedHashesFilename + `,
+in the format Chrome uses when it installs an extension.`,
+		Example: `$ crx3 integrity hashes ./extension`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := toPath(args[0])
+			if err != nil {
+				return err
+			}
+			if err := crx3.WriteComputedHashes(dir); err != nil {
+				return err
+			}
+			fmt.Printf("Wrote %s\n", filepath.Join(dir, filepath.FromSlash(crx3.ComputedHashesFilename)))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// writeIntegrityReport prints one record per differing file and fails if there are any.
+func writeIntegrityReport(cmd *cobra.Command, output *outputOpts, report *crx3.IntegrityReport) error {
+	results := make([]integrityResult, 0)
+	for _, path := range report.Added {
+		results = append(results, integrityResult{Status: "added", Path: path})
+	}
+	for _, path := range report.Removed {
+		results = append(results, integrityResult{Status: "removed", Path: path})
+	}
+	for _, path := range report.Modified {
+		results = append(results, integrityResult{Status: "modified", Path: path})
+	}
+	if report.OK() {
+		fmt.Fprintln(os.Stderr, "OK: all files match.")
+	}
+	if err := writeRecords(os.Stdout, output, results, integrityColumns); err != nil {
+		return err
+	}
+	if !report.OK() {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("integrity check failed: %d added, %d removed, %d modified",
+			len(report.Added), len(report.Removed), len(report.Modified))
+	}
+	return nil
+}
@@ -1,8 +1,14 @@
 package commands
 
 import (
+	"context"
+	"crypto"
 	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
 	"strings"
+	"time"
 
 	crx3 "github.com/mediabuyerbot/go-crx3"
 	"github.com/spf13/cobra"
@@ -12,10 +18,26 @@ const pemExt = ".pem"
 
 type keygenOpts struct {
 	PrivateKeySize int
+	Encrypt        bool
+	KDF            string
+	KeyType        string
+	VanityPrefix   string
+	Workers        int
+	StoreKey       bool
+	KeyStore       string
+	Seed           string
 }
 
+// insecureKeyNotice precedes the PEM block of keys derived from a seed.
+const insecureKeyNotice = `# INSECURE TEST KEY derived from a seed with "crx3 keygen --seed".
+# Anyone who knows the seed can recreate it. Never sign published extensions with it.
+`
+
 func newKeygenCmd() *cobra.Command {
-	var opts keygenOpts
+	var (
+		opts       keygenOpts
+		passphrase *passphraseOpts
+	)
 	cmd := &cobra.Command{
 		Use:   "keygen [file]",
 		Short: "Create a new private key",
@@ -24,30 +46,165 @@ If no file is specified, the private key is printed to stdout.
 Otherwise, the private key is saved to the specified file. 
 If the file does not have a .pem extension, it is added automatically.
 Size of the private key can be set with the --size or -s flag. Sizes of 2048, 3072, or 4096 bits are allowed.
+The key is encrypted (PKCS#8, PBES2 with AES-256) if a passphrase is given with --encrypt (prompt),
+--passphrase-env or --passphrase-file. --kdf selects scrypt (default) or pbkdf2 to derive the encryption key.
+--type ecdsa creates a P-256 ECDSA key instead of an RSA key.
+
+--vanity-prefix searches keys on all CPUs until the extension ID starts with the prefix.
+IDs only use the letters a-p; digits 0-9 are translated to a-j, so "42" searches "ec".
+Prefixes with other characters, e.g. "corp", can never be found and are rejected.
+Every letter multiplies the expected number of keys by 16, so prefer --type ecdsa,
+which is much faster to generate.
+The winning key is saved to the file, or to <id>.pem if no file is given, and its ID is printed.
+Press Ctrl+C to stop the search.
+With --store-key the key is saved in the key store instead (see "crx3 keys").
+
+--seed derives the key deterministically from the seed, so test fixtures keep the same
+extension ID across runs. The same seed, --type and --size always give the same key.
+Such keys are INSECURE: anyone who knows the seed can recreate them. They are marked
+as test keys in the PEM output and must never sign published extensions.
 		`,
+		Example: `$ crx3 keygen ./key.pem
+$ crx3 keygen --type ecdsa --vanity-prefix hello
+$ crx3 keygen --type ecdsa --vanity-prefix 42 --store-key
+$ crx3 keygen --seed ci-fixture ./testdata/fixture.pem`,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			if opts.KDF != crx3.KDFScrypt && opts.KDF != crx3.KDFPBKDF2 {
+				return fmt.Errorf("invalid --kdf %q: use %s or %s", opts.KDF, crx3.KDFScrypt, crx3.KDFPBKDF2)
+			}
+			keyOpts, err := keygenKeyOptions(opts, passphrase)
+			if err != nil {
+				return err
+			}
 			crx3.SetDefaultKeySize(sanitizeKeySize(opts.PrivateKeySize))
-			pk, err := crx3.NewPrivateKey()
+			var (
+				pk crypto.Signer
+				id string
+			)
+			switch {
+			case len(opts.VanityPrefix) > 0:
+				vanity, err := generateVanityKey(cmd.Context(), opts)
+				if err != nil {
+					return err
+				}
+				pk, id = vanity.Key, vanity.ID
+			case len(opts.Seed) > 0:
+				if pk, err = crx3.InsecureTestKey([]byte(opts.Seed), opts.KeyType); err != nil {
+					return err
+				}
+				fmt.Fprintln(os.Stderr, "WARNING: the key is derived from a seed and INSECURE, use it for tests only")
+			default:
+				if pk, err = crx3.NewKey(opts.KeyType); err != nil {
+					return err
+				}
+			}
+			if opts.StoreKey {
+				ks, err := openKeyStore(opts.KeyStore)
+				if err != nil {
+					return err
+				}
+				key, err := ks.Add(pk, keyOpts...)
+				if err != nil {
+					return err
+				}
+				fmt.Println(key.ID, key.Path)
+				return nil
+			}
+			key, err := crx3.MarshalPrivateKeyPEM(pk, keyOpts...)
 			if err != nil {
 				return err
 			}
-			if len(args) == 0 {
-				key := crx3.PrivateKeyToPEM(pk)
+			if len(opts.Seed) > 0 {
+				key = append([]byte(insecureKeyNotice), key...)
+			}
+			if len(args) == 0 && len(id) == 0 {
 				fmt.Print(string(key))
 				return nil
 			}
-			filename, err := toPath(args[0])
-			if err != nil {
-				return fmt.Errorf("invalid infile path: %w", err)
+			filename := id
+			if len(args) > 0 {
+				if filename, err = toPath(args[0]); err != nil {
+					return fmt.Errorf("invalid infile path: %w", err)
+				}
 			}
 			if !strings.HasSuffix(filename, pemExt) {
 				filename = filename + pemExt
 			}
-			return crx3.SavePrivateKey(filename, pk)
+			if err := crx3.WriteKeyFile(filename, key); err != nil {
+				return err
+			}
+			if len(id) > 0 {
+				fmt.Println(id, filename)
+			}
+			return nil
 		},
 	}
 
 	cmd.Flags().IntVarP(&opts.PrivateKeySize, "size", "s", 2048, "private key size")
+	cmd.Flags().BoolVarP(&opts.Encrypt, "encrypt", "e", false, "encrypt the key with a passphrase entered on the terminal")
+	cmd.Flags().StringVar(&opts.KDF, "kdf", crx3.KDFScrypt, "key derivation for encrypted keys: scrypt or pbkdf2")
+	cmd.Flags().StringVarP(&opts.KeyType, "type", "t", "rsa", "key type: rsa or ecdsa")
+	cmd.Flags().StringVar(&opts.VanityPrefix, "vanity-prefix", "", "search a key whose extension ID starts with this prefix")
+	cmd.Flags().IntVar(&opts.Workers, "workers", runtime.NumCPU(), "number of parallel workers of the vanity search")
+	cmd.Flags().BoolVar(&opts.StoreKey, "store-key", false, "save the key in the key store instead of a file")
+	cmd.Flags().StringVar(&opts.KeyStore, "keystore", "", "key store directory (default $"+crx3.KeyStoreEnv+" or ~/.config/crx3/keys)")
+	cmd.Flags().StringVar(&opts.Seed, "seed", "", "derive the key deterministically from this seed (INSECURE, for test fixtures only)")
+	passphrase = addPassphraseFlags(cmd)
+	cmd.MarkFlagsMutuallyExclusive("encrypt", "passphrase-env", "passphrase-file")
+	cmd.MarkFlagsMutuallyExclusive("seed", "vanity-prefix")
+	cmd.MarkFlagsMutuallyExclusive("seed", "store-key")
 
 	return cmd
 }
+
+// keygenKeyOptions returns the options encrypting a new key, or none if no passphrase source is given.
+func keygenKeyOptions(opts keygenOpts, passphraseSource *passphraseOpts) ([]crx3.KeyOption, error) {
+	passphrase, err := passphraseSource.read()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Encrypt {
+		if passphrase, err = promptPassphrase("Enter passphrase for the new key: ", true); err != nil {
+			return nil, err
+		}
+	}
+	if passphrase == nil {
+		return nil, nil
+	}
+	return []crx3.KeyOption{crx3.WithPassphrase(passphrase), crx3.WithKDF(opts.KDF)}, nil
+}
+
+// generateVanityKey searches a key whose ID starts with opts.VanityPrefix,
+// reporting the progress on stderr until a key is found or the search is interrupted.
+func generateVanityKey(ctx context.Context, opts keygenOpts) (*crx3.VanityKey, error) {
+	prefix, err := crx3.VanityPrefix(opts.VanityPrefix)
+	if err != nil {
+		return nil, err
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "Searching %s keys for an ID starting with %q: %.0f keys expected, %d workers\n",
+		strings.ToUpper(opts.KeyType), prefix, crx3.ExpectedVanityAttempts(prefix), opts.Workers)
+	interval, lineEnd := 10*time.Second, "\n"
+	if isTerminal(os.Stderr) {
+		interval, lineEnd = time.Second, ""
+	}
+	progress := func(s crx3.VanityStats) {
+		fmt.Fprintf(os.Stderr, "\r%d keys, %.0f keys/s, %s elapsed, ~%s expected, %.0f%% chance so far  %s",
+			s.Attempts, s.Rate(), s.Elapsed.Round(time.Second), s.Remaining().Round(time.Second), s.Probability()*100, lineEnd)
+	}
+	key, err := crx3.GenerateVanityKey(ctx, prefix,
+		crx3.VanityKeyType(opts.KeyType),
+		crx3.VanityWorkers(opts.Workers),
+		crx3.VanityProgress(progress, interval),
+	)
+	if len(lineEnd) == 0 {
+		fmt.Fprintln(os.Stderr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("vanity search stopped: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Found %s after %d keys in %s\n", key.ID, key.Stats.Attempts, key.Stats.Elapsed.Round(time.Millisecond))
+	return key, nil
+}
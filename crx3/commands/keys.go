@@ -0,0 +1,234 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/mediabuyerbot/go-crx3"
+	"github.com/spf13/cobra"
+)
+
+var storedKeyColumns = []column[crx3.StoredKey]{
+	{Name: "id", Value: func(k crx3.StoredKey) string { return k.ID }},
+	{Name: "type", Value: func(k crx3.StoredKey) string { return k.KeyType }},
+	{Name: "size", Value: func(k crx3.StoredKey) string {
+		if k.KeySize == 0 {
+			return ""
+		}
+		return strconv.Itoa(k.KeySize)
+	}},
+	{Name: "encrypted", Value: func(k crx3.StoredKey) string { return fmt.Sprint(k.Encrypted) }},
+	{Name: "path", Value: func(k crx3.StoredKey) string { return k.Path }},
+}
+
+var keyMatchColumns = []column[crx3.KeyMatch]{
+	{Name: "id", Value: func(m crx3.KeyMatch) string { return m.ID }},
+	{Name: "source", Value: func(m crx3.KeyMatch) string { return m.Source }},
+	{Name: "path", Value: func(m crx3.KeyMatch) string { return m.Key.Path }},
+}
+
+// openKeyStore opens the key store in dir, or the default key store if dir is empty.
+func openKeyStore(dir string) (*crx3.KeyStore, error) {
+	dir, err := toPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	return crx3.OpenKeyStore(dir)
+}
+
+func newKeysCmd() *cobra.Command {
+	var dir string
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "Manage the private keys of the local key store",
+		Long: `Keys manages a local store of private keys indexed by the extension ID they give.
+The store is $` + crx3.KeyStoreEnv + ` or crx3/keys in the user configuration directory,
+e.g. ~/.config/crx3/keys. Keys are stored as imported; encrypted keys stay encrypted.
+Sign with a stored key with "crx3 pack --id <id>".`,
+	}
+	cmd.PersistentFlags().StringVar(&dir, "keystore", "", "key store directory (default $"+crx3.KeyStoreEnv+" or ~/.config/crx3/keys)")
+
+	cmd.AddCommand(newKeysListCmd(&dir))
+	cmd.AddCommand(newKeysImportCmd(&dir))
+	cmd.AddCommand(newKeysExportCmd(&dir))
+	cmd.AddCommand(newKeysRemoveCmd(&dir))
+	cmd.AddCommand(newKeysFindCmd(&dir))
+
+	return cmd
+}
+
+func newKeysListCmd(dir *string) *cobra.Command {
+	var output *outputOpts
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List the stored keys",
+		Example: `$ crx3 keys list`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := output.Validate(); err != nil {
+				return err
+			}
+			ks, err := openKeyStore(*dir)
+			if err != nil {
+				return err
+			}
+			keys, err := ks.List()
+			if err != nil {
+				return err
+			}
+			return writeRecords(os.Stdout, output, keys, storedKeyColumns)
+		},
+	}
+	output = addOutputFlags(cmd, outputTable)
+
+	return cmd
+}
+
+func newKeysImportCmd(dir *string) *cobra.Command {
+	var (
+		passphrase *passphraseOpts
+		output     *outputOpts
+	)
+	cmd := &cobra.Command{
+		Use:   "import [pem...]",
+		Short: "Copy private keys into the key store",
+		Long: `Import copies PEM private keys into the key store under the extension ID they give.
+The ID of an encrypted key is computed with the passphrase, which is prompted for
+unless given non-interactively; the stored copy stays encrypted.`,
+		Example: `$ crx3 keys import ./key.pem
+$ crx3 keys import ./encrypted.pem --passphrase-env CRX3_PASS`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := output.Validate(); err != nil {
+				return err
+			}
+			ks, err := openKeyStore(*dir)
+			if err != nil {
+				return err
+			}
+			pass, err := passphrase.read()
+			if err != nil {
+				return err
+			}
+			keys := make([]crx3.StoredKey, 0, len(args))
+			for _, arg := range args {
+				filename, err := toPath(arg)
+				if err != nil {
+					return err
+				}
+				key, err := ks.Import(filename, crx3.WithPassphrase(pass))
+				if errors.Is(err, crx3.ErrPassphraseRequired) && pass == nil && stdinIsTerminal() {
+					var prompted []byte
+					if prompted, err = promptPassphrase(fmt.Sprintf("Enter passphrase for %s: ", filename), false); err != nil {
+						return err
+					}
+					key, err = ks.Import(filename, crx3.WithPassphrase(prompted))
+				}
+				if err != nil {
+					return err
+				}
+				keys = append(keys, *key)
+			}
+			return writeRecords(os.Stdout, output, keys, storedKeyColumns)
+		},
+	}
+	passphrase = addPassphraseFlags(cmd)
+	output = addOutputFlags(cmd, outputText)
+
+	return cmd
+}
+
+func newKeysExportCmd(dir *string) *cobra.Command {
+	var outfile string
+	cmd := &cobra.Command{
+		Use:   "export [id]",
+		Short: "Write a stored key as PEM to stdout or a file",
+		Example: `$ crx3 keys export nigbihjmcbekdlkgdceknpanajdpncle
+$ crx3 keys export nigbihjmcbekdlkgdceknpanajdpncle -o ./key.pem`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ks, err := openKeyStore(*dir)
+			if err != nil {
+				return err
+			}
+			data, err := ks.Export(args[0])
+			if err != nil {
+				return err
+			}
+			if len(outfile) == 0 {
+				_, err = os.Stdout.Write(data)
+				return err
+			}
+			if outfile, err = toPath(outfile); err != nil {
+				return err
+			}
+			return crx3.WriteKeyFile(outfile, data)
+		},
+	}
+	cmd.Flags().StringVarP(&outfile, "outfile", "o", "", "write the key to this file instead of stdout")
+
+	return cmd
+}
+
+func newKeysRemoveCmd(dir *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rm [id...]",
+		Short:   "Delete stored keys",
+		Example: `$ crx3 keys rm nigbihjmcbekdlkgdceknpanajdpncle`,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ks, err := openKeyStore(*dir)
+			if err != nil {
+				return err
+			}
+			for _, id := range args {
+				if err := ks.Remove(id); err != nil {
+					return err
+				}
+				fmt.Println(id)
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func newKeysFindCmd(dir *string) *cobra.Command {
+	var output *outputOpts
+	cmd := &cobra.Command{
+		Use:   "find [crx|zip|dir]",
+		Short: "Find the stored key that signed a CRX or matches a manifest key",
+		Long: `Find looks up the IDs of the CRX3 header proofs and of the manifest "key" field
+in the key store and prints the stored keys that match. It fails if none does.`,
+		Example: `$ crx3 keys find ./extension.crx
+$ crx3 keys find ./extension`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := output.Validate(); err != nil {
+				return err
+			}
+			filename, err := toPath(args[0])
+			if err != nil {
+				return err
+			}
+			ks, err := openKeyStore(*dir)
+			if err != nil {
+				return err
+			}
+			matches, err := ks.Find(filename)
+			if err != nil {
+				return err
+			}
+			if len(matches) == 0 {
+				return fmt.Errorf("%w for %s", crx3.ErrKeyNotFound, filename)
+			}
+			return writeRecords(os.Stdout, output, matches, keyMatchColumns)
+		},
+	}
+	output = addOutputFlags(cmd, outputTable)
+
+	return cmd
+}
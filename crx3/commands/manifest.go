@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mediabuyerbot/go-crx3"
+	"github.com/spf13/cobra"
+)
+
+type manifestKeyResult struct {
+	ID       string `json:"id,omitempty"`
+	Path     string `json:"path"`
+	Modified bool   `json:"modified"`
+}
+
+var manifestKeyColumns = []column[manifestKeyResult]{
+	{Name: "id", Value: func(r manifestKeyResult) string { return r.ID }},
+	{Name: "path", Value: func(r manifestKeyResult) string { return r.Path }},
+	{Name: "modified", Value: func(r manifestKeyResult) string { return fmt.Sprint(r.Modified) }},
+}
+
+func newManifestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "manifest",
+		Short: "Edit the manifest.json of an unpacked extension",
+	}
+
+	cmd.AddCommand(newManifestSetKeyCmd())
+	cmd.AddCommand(newManifestRemoveKeyCmd())
+
+	return cmd
+}
+
+func newManifestSetKeyCmd() *cobra.Command {
+	var (
+		pemPath    string
+		passphrase *passphraseOpts
+		output     *outputOpts
+	)
+	cmd := &cobra.Command{
+		Use:   "set-key [dir]",
+		Short: "Write the public key of a private key into the manifest key field",
+		Long: `Set-key writes the base64 DER public key of the private key into the "key" field of
+manifest.json, so the unpacked extension loaded in developer mode keeps the ID of the CRX
+signed with the same key. Only the key field is inserted or replaced; the rest of the file
+keeps its formatting. The printed ID is verified against the written manifest.`,
+		Example: `$ crx3 manifest set-key --pem key.pem ./extension`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := output.Validate(); err != nil {
+				return err
+			}
+			dir, err := toPath(args[0])
+			if err != nil {
+				return err
+			}
+			if pemPath, err = toPath(pemPath); err != nil {
+				return err
+			}
+			pk, err := loadKey(pemPath, passphrase)
+			if err != nil {
+				return fmt.Errorf("failed to load private key %s: %w", pemPath, err)
+			}
+			id, err := crx3.SetManifestKey(dir, pk)
+			if err != nil {
+				return err
+			}
+			return writeRecord(os.Stdout, output, manifestKeyResult{ID: id, Path: dir, Modified: true}, manifestKeyColumns)
+		},
+	}
+	cmd.Flags().StringVarP(&pemPath, "pem", "p", "", "private key whose public key is written to the manifest")
+	_ = cmd.MarkFlagRequired("pem")
+	passphrase = addPassphraseFlags(cmd)
+	output = addOutputFlags(cmd, outputText)
+
+	return cmd
+}
+
+func newManifestRemoveKeyCmd() *cobra.Command {
+	var output *outputOpts
+	cmd := &cobra.Command{
+		Use:   "remove-key [dir]",
+		Short: "Remove the key field from manifest.json, e.g. before a Web Store upload",
+		Long: `Remove-key deletes the "key" field from manifest.json. The Chrome Web Store rejects
+uploads whose manifest has a key. The rest of the file keeps its formatting.`,
+		Example: `$ crx3 manifest remove-key ./extension`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := output.Validate(); err != nil {
+				return err
+			}
+			dir, err := toPath(args[0])
+			if err != nil {
+				return err
+			}
+			removed, err := crx3.RemoveManifestKey(dir)
+			if err != nil {
+				return err
+			}
+			return writeRecord(os.Stdout, output, manifestKeyResult{Path: dir, Modified: removed}, manifestKeyColumns[1:])
+		},
+	}
+	output = addOutputFlags(cmd, outputText)
+
+	return cmd
+}
@@ -0,0 +1,216 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats supported by the --output flag.
+const (
+	outputText   = "text"
+	outputTable  = "table"
+	outputJSON   = "json"
+	outputNDJSON = "ndjson"
+	outputCSV    = "csv"
+	outputYAML   = "yaml"
+)
+
+// column describes one field of a record in table and CSV output.
+type column[T any] struct {
+	Name  string
+	Value func(T) string
+}
+
+// outputOpts holds the --output and --template flags shared by commands that print records.
+type outputOpts struct {
+	Format   string
+	Template string
+
+	formats []string
+}
+
+// addOutputFlags registers --output/-o and --template on cmd. The text format
+// is only offered when the command has a plain text form of its own.
+func addOutputFlags(cmd *cobra.Command, defaultFormat string) *outputOpts {
+	opts := &outputOpts{
+		formats: []string{outputTable, outputJSON, outputNDJSON, outputCSV, outputYAML},
+	}
+	if defaultFormat == outputText {
+		opts.formats = append([]string{outputText}, opts.formats...)
+	}
+	cmd.Flags().StringVarP(&opts.Format, "output", "o", defaultFormat,
+		"output format: "+strings.Join(opts.formats, ", "))
+	cmd.Flags().StringVar(&opts.Template, "template", "",
+		"Go text/template applied to every record, e.g. '{{.ID}} {{.Path}}' (overrides --output)")
+	return opts
+}
+
+// Validate checks the flags before the command does any work.
+func (o *outputOpts) Validate() error {
+	if len(o.Template) > 0 {
+		_, err := o.parseTemplate()
+		return err
+	}
+	if !slices.Contains(o.formats, o.Format) {
+		return fmt.Errorf("unknown output format %q, expected one of: %s",
+			o.Format, strings.Join(o.formats, ", "))
+	}
+	return nil
+}
+
+func (o *outputOpts) parseTemplate() (*template.Template, error) {
+	tmpl, err := template.New("output").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+		"join":  strings.Join,
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+	}).Parse(o.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// writeRecords prints records in the selected format. JSON and YAML print a
+// single document holding the list; the other formats print one line per record.
+func writeRecords[T any](w io.Writer, o *outputOpts, records []T, columns []column[T]) error {
+	if records == nil {
+		records = []T{}
+	}
+	return render(w, o, records, records, columns)
+}
+
+// writeRecord prints a single record. Unlike writeRecords, JSON and YAML
+// print the record itself rather than a list.
+func writeRecord[T any](w io.Writer, o *outputOpts, record T, columns []column[T]) error {
+	return render(w, o, record, []T{record}, columns)
+}
+
+func render[T any](w io.Writer, o *outputOpts, doc any, records []T, columns []column[T]) error {
+	if len(o.Template) > 0 {
+		tmpl, err := o.parseTemplate()
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, r); err != nil {
+				return fmt.Errorf("failed to execute template: %w", err)
+			}
+			if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+				buf.WriteByte('\n')
+			}
+			if _, err := w.Write(buf.Bytes()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch o.Format {
+	case outputText:
+		for _, r := range records {
+			if _, err := fmt.Fprintln(w, columns[0].Value(r)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		header := make([]string, len(columns))
+		for i, c := range columns {
+			header[i] = strings.ToUpper(c.Name)
+		}
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, r := range records {
+			fmt.Fprintln(tw, strings.Join(columnValues(r, columns, "-"), "\t"))
+		}
+		return tw.Flush()
+	case outputCSV:
+		cw := csv.NewWriter(w)
+		header := make([]string, len(columns))
+		for i, c := range columns {
+			header[i] = c.Name
+		}
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		for _, r := range records {
+			if err := cw.Write(columnValues(r, columns, "")); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case outputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(doc); err != nil {
+			return fmt.Errorf("failed to encode results: %w", err)
+		}
+		return nil
+	case outputNDJSON:
+		encoder := json.NewEncoder(w)
+		for _, r := range records {
+			if err := encoder.Encode(r); err != nil {
+				return fmt.Errorf("failed to encode results: %w", err)
+			}
+		}
+		return nil
+	case outputYAML:
+		return writeYAML(w, doc)
+	}
+	return fmt.Errorf("unknown output format %q", o.Format)
+}
+
+func columnValues[T any](record T, columns []column[T], empty string) []string {
+	values := make([]string, len(columns))
+	for i, c := range columns {
+		values[i] = c.Value(record)
+		if len(values[i]) == 0 {
+			values[i] = empty
+		}
+	}
+	return values
+}
+
+// writeYAML encodes v through its JSON form, so YAML keys, field order and
+// omitted fields are the same as in the JSON output.
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode results: %w", err)
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return fmt.Errorf("failed to encode results: %w", err)
+	}
+	blockStyle(&node)
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return fmt.Errorf("failed to encode results: %w", err)
+	}
+	return encoder.Close()
+}
+
+// blockStyle resets the flow and quoting styles the YAML decoder keeps for JSON input.
+func blockStyle(node *yaml.Node) {
+	node.Style &^= yaml.FlowStyle | yaml.DoubleQuotedStyle
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -1,7 +1,7 @@
 package commands
 
 import (
-	"crypto/rsa"
+	"crypto"
 	"errors"
 
 	crx3 "github.com/mediabuyerbot/go-crx3"
@@ -12,6 +12,10 @@ type packOpts struct {
 	PrivateKey     string
 	Outfile        string
 	PrivateKeySize int
+	StrictID       bool
+	KeyID          string
+	KeyStore       string
+	StoreKey       bool
 }
 
 func (o packOpts) hasPem() bool {
@@ -19,7 +23,10 @@ func (o packOpts) hasPem() bool {
 }
 
 func newPackCmd() *cobra.Command {
-	var opts packOpts
+	var (
+		opts       packOpts
+		passphrase *passphraseOpts
+	)
 	cmd := &cobra.Command{
 		Use:   "pack [extension]",
 		Short: "Pack zip file or unzipped directory into a crx extension",
@@ -35,24 +42,54 @@ func newPackCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			var pk *rsa.PrivateKey
+			var key crypto.Signer
 			if opts.hasPem() {
-				pk, err = crx3.LoadPrivateKey(opts.PrivateKey)
+				key, err = loadKey(opts.PrivateKey, passphrase)
 				if err != nil {
 					return err
 				}
 			}
+			var packOpts []crx3.PackOption
+			if len(opts.KeyID) > 0 || opts.StoreKey {
+				ks, err := openKeyStore(opts.KeyStore)
+				if err != nil {
+					return err
+				}
+				if len(opts.KeyID) > 0 {
+					stored, err := ks.Get(opts.KeyID)
+					if err != nil {
+						return err
+					}
+					if key, err = loadKey(stored.Path, passphrase); err != nil {
+						return err
+					}
+				} else {
+					packOpts = append(packOpts, crx3.PackKeyStore(ks))
+				}
+			}
 			out, err := toPath(opts.Outfile)
 			if err != nil {
 				return err
 			}
-			return crx3.Pack(unpacked, out, pk)
+			if opts.StrictID {
+				packOpts = append(packOpts, crx3.PackStrictID())
+			}
+			if key != nil {
+				packOpts = append(packOpts, crx3.PackSigner(key))
+			}
+			return crx3.Pack(unpacked, out, nil, packOpts...)
 		},
 	}
 
 	cmd.Flags().StringVarP(&opts.PrivateKey, "pem", "p", "", "load private key")
 	cmd.Flags().StringVarP(&opts.Outfile, "outfile", "o", "", "save to file")
 	cmd.Flags().IntVarP(&opts.PrivateKeySize, "size", "s", 2048, "private key size")
+	cmd.Flags().StringVar(&opts.KeyID, "id", "", "sign with the key of this extension ID from the key store")
+	cmd.Flags().StringVar(&opts.KeyStore, "keystore", "", "key store directory (default $"+crx3.KeyStoreEnv+" or ~/.config/crx3/keys)")
+	cmd.Flags().BoolVar(&opts.StoreKey, "store-key", false, "save a generated private key in the key store instead of next to the output")
+	passphrase = addPassphraseFlags(cmd)
+	cmd.Flags().BoolVar(&opts.StrictID, "strict-id", false, "fail if the manifest key gives another ID than the signing key")
+	cmd.MarkFlagsMutuallyExclusive("pem", "id", "store-key")
 
 	return cmd
 }
@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mediabuyerbot/go-crx3"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// passphraseOpts are the non-interactive sources of a private key passphrase.
+type passphraseOpts struct {
+	Env  string
+	File string
+}
+
+func addPassphraseFlags(cmd *cobra.Command) *passphraseOpts {
+	opts := new(passphraseOpts)
+	cmd.Flags().StringVar(&opts.Env, "passphrase-env", "", "read the key passphrase from this environment variable")
+	cmd.Flags().StringVar(&opts.File, "passphrase-file", "", "read the key passphrase from the first line of this file")
+	cmd.MarkFlagsMutuallyExclusive("passphrase-env", "passphrase-file")
+	return opts
+}
+
+// read returns the passphrase from the environment variable or the file, or nil if neither is set.
+func (o *passphraseOpts) read() ([]byte, error) {
+	switch {
+	case len(o.Env) > 0:
+		value := os.Getenv(o.Env)
+		if len(value) == 0 {
+			return nil, fmt.Errorf("environment variable %s is empty or not set", o.Env)
+		}
+		return []byte(value), nil
+	case len(o.File) > 0:
+		filename, err := toPath(o.File)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase file: %w", err)
+		}
+		line, _, _ := bytes.Cut(data, []byte("\n"))
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if len(line) == 0 {
+			return nil, fmt.Errorf("passphrase file %s is empty", o.File)
+		}
+		return line, nil
+	}
+	return nil, nil
+}
+
+func stdinIsTerminal() bool {
+	return isTerminal(os.Stdin)
+}
+
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// promptPassphrase reads a passphrase from the terminal without echoing it.
+// With confirm the passphrase has to be entered twice.
+func promptPassphrase(prompt string, confirm bool) ([]byte, error) {
+	if !stdinIsTerminal() {
+		return nil, errors.New("cannot prompt for a passphrase: stdin is not a terminal")
+	}
+	read := func(prompt string) ([]byte, error) {
+		fmt.Fprint(os.Stderr, prompt)
+		defer fmt.Fprintln(os.Stderr)
+		return term.ReadPassword(int(os.Stdin.Fd()))
+	}
+	passphrase, err := read(prompt)
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+	if confirm {
+		again, err := read("Confirm passphrase: ")
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(passphrase, again) {
+			return nil, errors.New("passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
+
+// loadKey loads a private key. An encrypted key is decrypted with the
+// passphrase from opts or, if there is none, a passphrase prompted for on the terminal.
+func loadKey(filename string, opts *passphraseOpts) (crypto.Signer, error) {
+	var passphrase []byte
+	if opts != nil {
+		var err error
+		if passphrase, err = opts.read(); err != nil {
+			return nil, err
+		}
+	}
+	key, err := crx3.LoadKey(filename, crx3.WithPassphrase(passphrase))
+	if errors.Is(err, crx3.ErrPassphraseRequired) && passphrase == nil && stdinIsTerminal() {
+		if passphrase, err = promptPassphrase(fmt.Sprintf("Enter passphrase for %s: ", filename), false); err != nil {
+			return nil, err
+		}
+		key, err = crx3.LoadKey(filename, crx3.WithPassphrase(passphrase))
+	}
+	return key, err
+}
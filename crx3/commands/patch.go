@@ -0,0 +1,234 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mediabuyerbot/go-crx3"
+	"github.com/spf13/cobra"
+)
+
+type patchOpts struct {
+	PrivateKey string
+	Outfile    string
+	OpsFile    string
+	Ops        []crx3.PatchOp
+}
+
+// patchOpFlag is a repeatable flag that appends an operation to a list
+// shared by all operation flags, so operations run in command line order.
+type patchOpFlag struct {
+	ops   *[]crx3.PatchOp
+	typ   string
+	parse func(string) (crx3.PatchOp, error)
+}
+
+func (f *patchOpFlag) String() string { return "" }
+func (f *patchOpFlag) Type() string   { return f.typ }
+
+func (f *patchOpFlag) Set(s string) error {
+	op, err := f.parse(s)
+	if err != nil {
+		return err
+	}
+	*f.ops = append(*f.ops, op)
+	return nil
+}
+
+// patchFileOp is one operation of a --ops file.
+type patchFileOp struct {
+	Op      string          `json:"op"`
+	Path    string          `json:"path"`
+	Value   json.RawMessage `json:"value"`
+	File    string          `json:"file"`
+	Content *string         `json:"content"`
+	Pattern string          `json:"pattern"`
+}
+
+func newPatchCmd() *cobra.Command {
+	var (
+		opts       patchOpts
+		passphrase *passphraseOpts
+	)
+	cmd := &cobra.Command{
+		Use:   "patch [extension.crx]",
+		Short: "Edit files inside a CRX file and re-sign it in one step",
+		Long: `Patch applies operations to the files of a CRX or ZIP file, rebuilds the archive
+and signs it with the given key. Operations run in the order they are given:
+  --set path=value         set a manifest value; value is parsed as JSON, or used as a string if it is not valid JSON
+  --set-string path=value  set a manifest value to a string
+  --delete path            delete a manifest value
+  --put name=file          add or replace the file name with the content of a local file
+  --remove pattern         remove the files matching a glob pattern
+  --diff file              apply a unified diff (diff -u, git diff)
+Manifest paths are dot-separated, e.g. update_url or content_scripts.0.matches;
+the array index "-" appends. Operations may also be read from a JSON file with --ops:
+  [{"op": "set", "path": "update_url", "value": "https://example.com/update.xml"},
+   {"op": "delete", "path": "key"},
+   {"op": "put", "path": "config.json", "file": "./config.json"},
+   {"op": "put", "path": "flags.txt", "content": "beta"},
+   {"op": "remove", "pattern": "*.map"},
+   {"op": "diff", "file": "fix.patch"}]
+The operations of the --ops file run before the ones given as flags.`,
+		Example: `$ crx3 patch vendor.crx --pem key.pem --set update_url=https://example.com/update.xml
+$ crx3 patch vendor.crx --pem key.pem --put config.json=./config.json --remove '*.map' -o patched.crx
+$ crx3 patch vendor.crx --pem key.pem --diff fix.patch --delete key`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			src, err := toPath(args[0])
+			if err != nil {
+				return err
+			}
+			var ops []crx3.PatchOp
+			if len(opts.OpsFile) > 0 {
+				if ops, err = readPatchOps(opts.OpsFile); err != nil {
+					return err
+				}
+			}
+			ops = append(ops, opts.Ops...)
+			if len(ops) == 0 {
+				return fmt.Errorf("no operations given")
+			}
+			if len(opts.PrivateKey) == 0 {
+				return fmt.Errorf("--pem is required to sign the patched extension")
+			}
+			keyPath, err := toPath(opts.PrivateKey)
+			if err != nil {
+				return err
+			}
+			pk, err := loadKey(keyPath, passphrase)
+			if err != nil {
+				return err
+			}
+			dst := opts.Outfile
+			if len(dst) == 0 {
+				dst = strings.TrimSuffix(src, filepath.Ext(src)) + ".patched.crx"
+			}
+			if dst, err = toPath(dst); err != nil {
+				return err
+			}
+			if err := crx3.Patch(src, dst, pk, ops...); err != nil {
+				return err
+			}
+			fmt.Printf("Patched extension written to %s\n", dst)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.PrivateKey, "pem", "p", "", "private key to sign the patched extension")
+	cmd.Flags().StringVarP(&opts.Outfile, "outfile", "o", "", "save to file (default <name>.patched.crx)")
+	cmd.Flags().StringVar(&opts.OpsFile, "ops", "", "JSON file with a list of operations")
+	cmd.Flags().Var(&patchOpFlag{ops: &opts.Ops, typ: "path=value", parse: parseSetOp(false)}, "set", "set a manifest value, parsed as JSON if valid")
+	cmd.Flags().Var(&patchOpFlag{ops: &opts.Ops, typ: "path=value", parse: parseSetOp(true)}, "set-string", "set a manifest value to a string")
+	cmd.Flags().Var(&patchOpFlag{ops: &opts.Ops, typ: "path", parse: func(s string) (crx3.PatchOp, error) {
+		return crx3.DeleteManifestValue(s), nil
+	}}, "delete", "delete a manifest value")
+	cmd.Flags().Var(&patchOpFlag{ops: &opts.Ops, typ: "name=file", parse: parsePutOp}, "put", "add or replace a file with the content of a local file")
+	cmd.Flags().Var(&patchOpFlag{ops: &opts.Ops, typ: "pattern", parse: func(s string) (crx3.PatchOp, error) {
+		return crx3.RemoveFiles(s), nil
+	}}, "remove", "remove the files matching a glob pattern")
+	cmd.Flags().Var(&patchOpFlag{ops: &opts.Ops, typ: "file", parse: parseDiffOp}, "diff", "apply a unified diff file")
+	passphrase = addPassphraseFlags(cmd)
+
+	return cmd
+}
+
+func parseSetOp(asString bool) func(string) (crx3.PatchOp, error) {
+	return func(s string) (crx3.PatchOp, error) {
+		path, value, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected path=value, got %q", s)
+		}
+		if !asString && json.Valid([]byte(value)) {
+			return crx3.SetManifestValue(path, json.RawMessage(value)), nil
+		}
+		return crx3.SetManifestValue(path, value), nil
+	}
+}
+
+func parsePutOp(s string) (crx3.PatchOp, error) {
+	name, file, ok := strings.Cut(s, "=")
+	if !ok {
+		return nil, fmt.Errorf("expected name=file, got %q", s)
+	}
+	return putFileOp(name, file)
+}
+
+func putFileOp(name, file string) (crx3.PatchOp, error) {
+	path, err := toPath(file)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return crx3.PutFile(name, data), nil
+}
+
+func parseDiffOp(file string) (crx3.PatchOp, error) {
+	path, err := toPath(file)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return crx3.ApplyDiff(data), nil
+}
+
+// readPatchOps reads a --ops file. Relative file names are resolved against its directory.
+func readPatchOps(filename string) ([]crx3.PatchOp, error) {
+	filename, err := toPath(filename)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var entries []patchFileOp
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	resolve := func(file string) string {
+		if filepath.IsAbs(file) || strings.HasPrefix(file, "~") {
+			return file
+		}
+		return filepath.Join(filepath.Dir(filename), file)
+	}
+	ops := make([]crx3.PatchOp, 0, len(entries))
+	for i, e := range entries {
+		var op crx3.PatchOp
+		switch e.Op {
+		case "set":
+			if e.Value == nil {
+				return nil, fmt.Errorf("%s: operation %d: value is required", filename, i+1)
+			}
+			op = crx3.SetManifestValue(e.Path, e.Value)
+		case "delete":
+			op = crx3.DeleteManifestValue(e.Path)
+		case "put":
+			if e.Content != nil {
+				op = crx3.PutFile(e.Path, []byte(*e.Content))
+			} else {
+				op, err = putFileOp(e.Path, resolve(e.File))
+			}
+		case "remove":
+			op = crx3.RemoveFiles(e.Pattern)
+		case "diff":
+			op, err = parseDiffOp(resolve(e.File))
+		default:
+			err = fmt.Errorf("unknown operation %q", e.Op)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: operation %d: %w", filename, i+1, err)
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
@@ -0,0 +1,202 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	crx3 "github.com/mediabuyerbot/go-crx3"
+	"github.com/spf13/cobra"
+)
+
+type policyOpts struct {
+	Outfile             string
+	Merge               string
+	Scan                string
+	Mode                string
+	UpdateURL           string
+	AllowedPermissions  []string
+	BlockedPermissions  []string
+	RuntimeAllowedHosts []string
+	RuntimeBlockedHosts []string
+}
+
+func (o policyOpts) settings() crx3.ExtensionSettings {
+	return crx3.ExtensionSettings{
+		InstallationMode:    o.Mode,
+		UpdateURL:           o.UpdateURL,
+		AllowedPermissions:  o.AllowedPermissions,
+		BlockedPermissions:  o.BlockedPermissions,
+		RuntimeAllowedHosts: o.RuntimeAllowedHosts,
+		RuntimeBlockedHosts: o.RuntimeBlockedHosts,
+	}
+}
+
+func newPolicyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Generate, merge and validate Chrome enterprise extension policies",
+		Long: `Manage ExtensionInstallForcelist and ExtensionSettings policies for Chrome.
+On Linux the resulting JSON file is placed into /etc/opt/chrome/policies/managed/.`,
+	}
+
+	cmd.AddCommand(newPolicyGenerateCmd())
+	cmd.AddCommand(newPolicyMergeCmd())
+	cmd.AddCommand(newPolicyValidateCmd())
+
+	return cmd
+}
+
+func newPolicyGenerateCmd() *cobra.Command {
+	var opts policyOpts
+	cmd := &cobra.Command{
+		Use:   "generate [crx|zip|dir|id ...]",
+		Short: "Generate a policy for a set of extensions",
+		Long: `Generate ExtensionInstallForcelist and ExtensionSettings entries for the given extensions.
+Each argument can be an extension ID, a CRX file, a ZIP archive or an unpacked directory.
+Use --scan to add every extension found in a directory, and --merge to update an existing policy file.`,
+		Example: `$ crx3 policy generate ./ext.crx --block-permission debugger -o /etc/opt/chrome/policies/managed/extensions.json
+$ crx3 policy generate --scan ./extensions --merge ./extensions.json -o ./extensions.json`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && len(opts.Scan) == 0 {
+				return errors.New("extension or --scan directory is required")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sources := make([]string, 0, len(args))
+			for _, arg := range args {
+				path, err := toPath(arg)
+				if err != nil {
+					return err
+				}
+				sources = append(sources, path)
+			}
+			if len(opts.Scan) > 0 {
+				root, err := toPath(opts.Scan)
+				if err != nil {
+					return err
+				}
+				for info, err := range crx3.Scan(root) {
+					if err != nil {
+						return fmt.Errorf("scan error: %w", err)
+					}
+					sources = append(sources, info.Path)
+				}
+			}
+
+			policy := new(crx3.Policy)
+			if len(opts.Merge) > 0 {
+				filename, err := toPath(opts.Merge)
+				if err != nil {
+					return err
+				}
+				if policy, err = crx3.LoadPolicy(filename); err != nil {
+					return err
+				}
+			}
+			for _, src := range sources {
+				id, err := crx3.ResolveExtensionID(src)
+				if err != nil {
+					return fmt.Errorf("failed to get extension id for %s: %w", src, err)
+				}
+				if err := policy.AddExtension(id, opts.settings()); err != nil {
+					return err
+				}
+			}
+			if err := policy.Validate(); err != nil {
+				return err
+			}
+			return writePolicy(opts.Outfile, policy)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Outfile, "outfile", "o", "", "save to file (default is stdout)")
+	cmd.Flags().StringVarP(&opts.Merge, "merge", "m", "", "existing policy file to merge the generated entries into")
+	cmd.Flags().StringVar(&opts.Scan, "scan", "", "add all extensions found in the directory")
+	cmd.Flags().StringVar(&opts.Mode, "mode", crx3.InstallModeForceInstalled, "installation mode: allowed, blocked, force_installed, normal_installed or removed")
+	cmd.Flags().StringVar(&opts.UpdateURL, "update-url", "", "update url for force or normal installed extensions (default is the Chrome Web Store)")
+	cmd.Flags().StringSliceVar(&opts.AllowedPermissions, "allow-permission", nil, "allowed permissions, comma-separated")
+	cmd.Flags().StringSliceVar(&opts.BlockedPermissions, "block-permission", nil, "blocked permissions, comma-separated")
+	cmd.Flags().StringSliceVar(&opts.RuntimeAllowedHosts, "runtime-allowed-host", nil, "hosts extensions are allowed to interact with, comma-separated")
+	cmd.Flags().StringSliceVar(&opts.RuntimeBlockedHosts, "runtime-blocked-host", nil, "hosts extensions are blocked from interacting with, comma-separated")
+
+	return cmd
+}
+
+func newPolicyMergeCmd() *cobra.Command {
+	var outfile string
+	cmd := &cobra.Command{
+		Use:   "merge [policy.json ...]",
+		Short: "Merge policy files, later files take precedence",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			policy := new(crx3.Policy)
+			for _, arg := range args {
+				filename, err := toPath(arg)
+				if err != nil {
+					return err
+				}
+				other, err := crx3.LoadPolicy(filename)
+				if err != nil {
+					return err
+				}
+				policy.Merge(other)
+			}
+			if err := policy.Validate(); err != nil {
+				return err
+			}
+			return writePolicy(outfile, policy)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outfile, "outfile", "o", "", "save to file (default is stdout)")
+
+	return cmd
+}
+
+func newPolicyValidateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate [policy.json ...]",
+		Short: "Validate extension policies in policy files",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var failed bool
+			for _, arg := range args {
+				filename, err := toPath(arg)
+				if err != nil {
+					return err
+				}
+				policy, err := crx3.LoadPolicy(filename)
+				if err == nil {
+					err = policy.Validate()
+				}
+				if err != nil {
+					failed = true
+					fmt.Fprintf(os.Stderr, "%s:\n%v\n", arg, err)
+					continue
+				}
+				fmt.Printf("%s: ok\n", arg)
+			}
+			if failed {
+				return errors.New("policy validation failed")
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func writePolicy(outfile string, policy *crx3.Policy) error {
+	if len(outfile) > 0 {
+		filename, err := toPath(outfile)
+		if err != nil {
+			return err
+		}
+		return crx3.WritePolicyFile(filename, policy)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(policy)
+}
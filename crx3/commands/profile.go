@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	crx3 "github.com/mediabuyerbot/go-crx3"
+	"github.com/spf13/cobra"
+)
+
+type profileListOpts struct {
+	Browsers     []string
+	UserDataDirs []string
+	JSON         bool
+	Output       *outputOpts
+}
+
+func newProfileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Inspect extensions installed in local Chromium-family browser profiles",
+	}
+
+	cmd.AddCommand(newProfileListCmd())
+
+	return cmd
+}
+
+func newProfileListCmd() *cobra.Command {
+	var opts profileListOpts
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List extensions installed in browser profiles",
+		Long: `List extensions installed in Chrome, Chromium, Brave, Edge, Vivaldi and Opera profiles on Linux.
+Profiles are discovered in <user-data-dir>/<profile>/Extensions/<id>/<version>_N and joined
+with the extension state (enabled, install source, granted permissions, install time)
+from the profile Preferences and Secure Preferences files.
+Use --user-data-dir to scan custom user data directories instead of the default ones.`,
+		Example: `$ crx3 profile list
+$ crx3 profile list --browser chrome,brave --output json
+$ crx3 profile list --user-data-dir /tmp/chrome-test-profile`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.JSON {
+				opts.Output.Format = outputJSON
+			}
+			if err := opts.Output.Validate(); err != nil {
+				return err
+			}
+			browsers, err := opts.browsers()
+			if err != nil {
+				return err
+			}
+			var results []*crx3.InstalledExtension
+			for ext, err := range crx3.ScanBrowserProfiles(browsers, crx3.WithProfileState()) {
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					continue
+				}
+				results = append(results, ext)
+			}
+			if len(results) == 0 {
+				fmt.Fprintln(os.Stderr, "No extensions found.")
+			}
+			return writeRecords(os.Stdout, opts.Output, results, profileColumns)
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&opts.Browsers, "browser", "b", nil, "only scan these browsers, comma-separated (chrome, chrome-beta, chrome-unstable, chromium, brave, edge, vivaldi, opera)")
+	cmd.Flags().StringSliceVarP(&opts.UserDataDirs, "user-data-dir", "u", nil, "custom user data directories to scan")
+	cmd.Flags().BoolVar(&opts.JSON, "json", false, "print results as JSON")
+	_ = cmd.Flags().MarkDeprecated("json", "use --output json instead")
+	opts.Output = addOutputFlags(cmd, outputTable)
+
+	return cmd
+}
+
+func (o profileListOpts) browsers() ([]crx3.Browser, error) {
+	if len(o.UserDataDirs) > 0 {
+		browsers := make([]crx3.Browser, 0, len(o.UserDataDirs))
+		for _, dir := range o.UserDataDirs {
+			path, err := toPath(dir)
+			if err != nil {
+				return nil, err
+			}
+			browsers = append(browsers, crx3.Browser{Name: filepath.Base(path), UserDataDir: path})
+		}
+		return browsers, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	browsers := crx3.LinuxBrowsers(home)
+	if len(o.Browsers) > 0 {
+		browsers = slices.DeleteFunc(browsers, func(b crx3.Browser) bool {
+			return !slices.Contains(o.Browsers, b.Name)
+		})
+	}
+	return browsers, nil
+}
+
+var profileColumns = []column[*crx3.InstalledExtension]{
+	{Name: "browser", Value: func(e *crx3.InstalledExtension) string { return e.Browser }},
+	{Name: "profile", Value: func(e *crx3.InstalledExtension) string { return e.Profile }},
+	{Name: "id", Value: func(e *crx3.InstalledExtension) string { return e.ID }},
+	{Name: "version", Value: func(e *crx3.InstalledExtension) string { return e.Version }},
+	{Name: "enabled", Value: func(e *crx3.InstalledExtension) string {
+		if e.State == nil {
+			return ""
+		}
+		return strconv.FormatBool(e.State.Enabled)
+	}},
+	{Name: "source", Value: func(e *crx3.InstalledExtension) string {
+		if e.State == nil {
+			return ""
+		}
+		return e.State.InstallSource
+	}},
+	{Name: "name", Value: func(e *crx3.InstalledExtension) string { return e.Name }},
+}
@@ -1,24 +1,41 @@
 package commands
 
 import (
-	"archive/zip"
-	"crypto/rsa"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
-	"encoding/pem"
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
+	"github.com/mediabuyerbot/go-crx3"
 	"github.com/spf13/cobra"
 )
 
+// Formats of the public key printed by the text output of the pubkey command.
+const (
+	pubkeyFormatBase64      = "base64"
+	pubkeyFormatPEM         = "pem"
+	pubkeyFormatJWK         = "jwk"
+	pubkeyFormatFingerprint = "fingerprint"
+	pubkeyFormatID          = "id"
+)
+
+var pubkeyFormats = []string{pubkeyFormatBase64, pubkeyFormatPEM, pubkeyFormatJWK, pubkeyFormatFingerprint, pubkeyFormatID}
+
+// Sources of public keys that are not CRX3 header proofs.
+const (
+	pubkeySourcePrivateKey = "private_key"
+	pubkeySourceManifest   = "manifest"
+)
+
 type pubkeyOpts struct {
 	PrivateKeyPath string // from private key
 	ManifestPath   string // from manifest.json
-	ExtensionPath  string // from extension/manifest.json
+	ExtensionPath  string // from CRX header proofs or extension/manifest.json
+	Format         string
 }
 
 func (opts pubkeyOpts) Validate() bool {
@@ -27,18 +44,74 @@ func (opts pubkeyOpts) Validate() bool {
 		len(opts.ExtensionPath) != 0
 }
 
+type pubkeyResult struct {
+	PublicKey string `json:"publicKey"`
+	ID        string `json:"id,omitempty"`
+	// Source is the proof algorithm for keys of CRX3 header proofs, otherwise private_key or manifest.
+	Source       string          `json:"source"`
+	KeyType      string          `json:"keyType,omitempty"`
+	MatchesCrxID bool            `json:"matchesCrxId,omitempty"`
+	Fingerprint  string          `json:"fingerprint,omitempty"`
+	PEM          string          `json:"pem,omitempty"`
+	JWK          json.RawMessage `json:"jwk,omitempty"`
+}
+
+var pubkeyColumns = map[string]column[pubkeyResult]{
+	pubkeyFormatBase64:      {Name: "public_key", Value: func(r pubkeyResult) string { return r.PublicKey }},
+	pubkeyFormatPEM:         {Name: "pem", Value: func(r pubkeyResult) string { return strings.TrimSuffix(r.PEM, "\n") }},
+	pubkeyFormatJWK:         {Name: "jwk", Value: func(r pubkeyResult) string { return string(r.JWK) }},
+	pubkeyFormatFingerprint: {Name: "fingerprint", Value: func(r pubkeyResult) string { return r.Fingerprint }},
+	pubkeyFormatID:          {Name: "id", Value: func(r pubkeyResult) string { return r.ID }},
+}
+
+// pubkeyColumnsFor returns the columns of the pubkey records: the one of format
+// first, as the text output only prints the first column, then the others.
+func pubkeyColumnsFor(format string) []column[pubkeyResult] {
+	columns := []column[pubkeyResult]{pubkeyColumns[format]}
+	if format != pubkeyFormatID {
+		columns = append(columns, pubkeyColumns[pubkeyFormatID])
+	}
+	columns = append(columns,
+		column[pubkeyResult]{Name: "source", Value: func(r pubkeyResult) string { return r.Source }},
+		column[pubkeyResult]{Name: "type", Value: func(r pubkeyResult) string { return r.KeyType }},
+	)
+	if format != pubkeyFormatFingerprint {
+		columns = append(columns, pubkeyColumns[pubkeyFormatFingerprint])
+	}
+	return columns
+}
+
 func newPubkeyCmd() *cobra.Command {
-	var opts pubkeyOpts
+	var (
+		opts       pubkeyOpts
+		passphrase *passphraseOpts
+		output     *outputOpts
+	)
 	cmd := &cobra.Command{
 		Use:   "pubkey",
 		Short: "Extract the public key from a private key, manifest.json, or CRX/ZIP file. (default: from extension)",
 		Long: `The 'pubkey' command extracts a public key from different sources:
-- From a private key file (PEM format)
+- From a private key file (PEM format: PKCS#8, encrypted PKCS#8, PKCS#1 or SEC1)
 - From the 'key' field in manifest.json of a Chrome extension
-- From a CRX or ZIP extension file
+- From the header proofs of a CRX file, one record per signing key
+- From the manifest key of a ZIP file or an unpacked directory
 
-The extracted public key is output in DER format and Base64-encoded.`,
+--format selects what the text output prints for every key: the Base64-encoded DER
+public key (base64, the format of the manifest key field), a PEM block (pem), a JSON
+Web Key (jwk), the OpenSSH SHA256 fingerprint (fingerprint) or the derived extension ID (id).
+The json, yaml and ndjson outputs include all formats.`,
+		Example: `$ crx3 pubkey extension.crx
+$ crx3 pubkey extension.crx --format pem
+$ crx3 pubkey extension.crx --output table
+$ crx3 pubkey -p key.pem --format jwk
+$ crx3 pubkey -p encrypted.pem --passphrase-file ./secret.txt`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := output.Validate(); err != nil {
+				return err
+			}
+			if !slices.Contains(pubkeyFormats, opts.Format) {
+				return fmt.Errorf("invalid --format %q: use one of %s", opts.Format, strings.Join(pubkeyFormats, ", "))
+			}
 			if !opts.Validate() {
 				if len(args) == 0 {
 					return errors.New("you need to specify a source to obtain the public key")
@@ -46,105 +119,134 @@ The extracted public key is output in DER format and Base64-encoded.`,
 				opts.ExtensionPath = args[0]
 			}
 
-			var pubkey string
-			var err error
+			var (
+				results []pubkeyResult
+				err     error
+			)
 			switch {
 			case len(opts.PrivateKeyPath) > 0:
-				pubkey, err = extractPublicKeyFromPrivateKey(opts.PrivateKeyPath)
+				results, err = extractPublicKeyFromPrivateKey(opts.PrivateKeyPath, passphrase)
 			case len(opts.ExtensionPath) > 0:
-				pubkey, err = extractKeyFromExtension(opts.ExtensionPath)
+				results, err = extractKeyFromExtension(opts.ExtensionPath)
 			case len(opts.ManifestPath) > 0:
-				pubkey, err = extractKeyFromManifest(opts.ManifestPath)
+				results, err = extractKeyFromManifest(opts.ManifestPath)
 			}
 			if err != nil {
 				return err
 			}
-
-			fmt.Print(pubkey)
-
-			return nil
+			return writeRecords(os.Stdout, output, results, pubkeyColumnsFor(opts.Format))
 		},
 	}
 
 	cmd.Flags().StringVarP(&opts.PrivateKeyPath, "private", "p", "", "extract public key from a private key file (PEM format)")
 	cmd.Flags().StringVarP(&opts.ManifestPath, "manifest", "m", "", "extract public key from the 'key' field in manifest.json")
-	cmd.Flags().StringVarP(&opts.ExtensionPath, "extension", "e", "", "extract public key from a CRX or ZIP extension file")
+	cmd.Flags().StringVarP(&opts.ExtensionPath, "extension", "e", "", "extract public keys from the header of a CRX file or the manifest of a ZIP file or directory")
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", pubkeyFormatBase64, "key format of the text output: "+strings.Join(pubkeyFormats, ", "))
+	passphrase = addPassphraseFlags(cmd)
+	output = addOutputFlags(cmd, outputText)
 
 	return cmd
 }
 
-func extractPublicKeyFromPrivateKey(privKeyPath string) (string, error) {
-	data, err := os.ReadFile(privKeyPath)
+// newPubkeyResult describes key in all formats.
+func newPubkeyResult(key *crx3.PublicKey, source string) (pubkeyResult, error) {
+	fingerprint, err := key.SSHFingerprint()
 	if err != nil {
-		return "", fmt.Errorf("failed to read private key file: %w", err)
+		return pubkeyResult{}, err
 	}
-	block, _ := pem.Decode(data)
-	if block == nil {
-		return "", fmt.Errorf("failed to parse PEM")
+	jwk, err := key.JWK()
+	if err != nil {
+		return pubkeyResult{}, err
+	}
+	return pubkeyResult{
+		PublicKey:    key.Base64(),
+		ID:           key.ID,
+		Source:       source,
+		KeyType:      key.Type(),
+		MatchesCrxID: key.MatchesCrxID,
+		Fingerprint:  fingerprint,
+		PEM:          string(key.PEM()),
+		JWK:          jwk,
+	}, nil
+}
+
+func extractPublicKeyFromPrivateKey(privKeyPath string, passphrase *passphraseOpts) ([]pubkeyResult, error) {
+	privKey, err := loadKey(privKeyPath, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load private key: %w", err)
 	}
-	var privKey *rsa.PrivateKey
-	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
-		privKey = key
-	} else {
-		parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	pubDER, err := x509.MarshalPKIXPublicKey(privKey.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	key, err := crx3.ParsePublicKey(pubDER)
+	if err != nil {
+		return nil, err
+	}
+	result, err := newPubkeyResult(key, pubkeySourcePrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return []pubkeyResult{result}, nil
+}
+
+func extractKeyFromExtension(path string) ([]pubkeyResult, error) {
+	if !crx3.Extension(path).IsCRX3() {
+		manifest, err := crx3.ReadManifest(path)
 		if err != nil {
-			return "", fmt.Errorf("failed to parse private key (unsupported format): %w", err)
-		}
-		var ok bool
-		privKey, ok = parsedKey.(*rsa.PrivateKey)
-		if !ok {
-			return "", fmt.Errorf("parsed PKCS#8 but it's not an RSA private key")
+			return nil, err
 		}
+		return manifestPubkeyResults(manifest.Key)
 	}
-	pubDER, err := x509.MarshalPKIXPublicKey(&privKey.PublicKey)
+	keys, err := crx3.PublicKeys(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal public key: %w", err)
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no key proofs in the header of %s", path)
+	}
+	results := make([]pubkeyResult, 0, len(keys))
+	for _, key := range keys {
+		result, err := newPubkeyResult(&key, key.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
 	}
-	return base64.StdEncoding.EncodeToString(pubDER), nil
+	return results, nil
 }
 
-func extractKeyFromExtension(crxPath string) (string, error) {
-	r, err := zip.OpenReader(crxPath)
+func extractKeyFromManifest(manifestPath string) ([]pubkeyResult, error) {
+	data, err := os.ReadFile(manifestPath)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer r.Close()
-	var manifestData map[string]interface{}
-	for _, f := range r.File {
-		if f.Name == "manifest.json" {
-			rc, err := f.Open()
-			if err != nil {
-				return "", err
-			}
-			defer rc.Close()
-			err = json.NewDecoder(rc).Decode(&manifestData)
-			if err != nil {
-				return "", err
-			}
-			key, ok := manifestData["key"].(string)
-			if !ok {
-				return "", fmt.Errorf("key not found in manifest.json")
-			}
-			return strings.TrimSpace(key), nil
-		}
+	manifest, err := crx3.ParseManifest(data)
+	if err != nil {
+		return nil, err
 	}
-	return "", fmt.Errorf("manifest.json not found in CRX")
+	return manifestPubkeyResults(manifest.Key)
 }
 
-func extractKeyFromManifest(manifestPath string) (string, error) {
-	file, err := os.Open(manifestPath)
+// manifestPubkeyResults describes the base64 DER key of a manifest. A malformed
+// key is still printed, just without an ID and the other formats.
+func manifestPubkeyResults(key string) ([]pubkeyResult, error) {
+	key = strings.TrimSpace(key)
+	if len(key) == 0 {
+		return nil, fmt.Errorf("key not found in manifest.json")
+	}
+	der, err := base64.StdEncoding.DecodeString(key)
 	if err != nil {
-		return "", err
+		return []pubkeyResult{{PublicKey: key, Source: pubkeySourceManifest}}, nil
 	}
-	defer file.Close()
-	var manifestData map[string]interface{}
-	err = json.NewDecoder(file).Decode(&manifestData)
+	parsed, err := crx3.ParsePublicKey(der)
 	if err != nil {
-		return "", err
+		return []pubkeyResult{{PublicKey: key, Source: pubkeySourceManifest}}, nil
 	}
-	key, ok := manifestData["key"].(string)
-	if !ok {
-		return "", fmt.Errorf("key not found in manifest.json")
+	result, err := newPubkeyResult(parsed, pubkeySourceManifest)
+	if err != nil {
+		return nil, err
 	}
-	return strings.TrimSpace(key), nil
+	result.PublicKey = key
+	return []pubkeyResult{result}, nil
 }
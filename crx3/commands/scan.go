@@ -1,9 +1,11 @@
 package commands
 
 import (
-	"encoding/json"
+	"cmp"
 	"fmt"
 	"os"
+	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/mediabuyerbot/go-crx3"
@@ -12,18 +14,28 @@ import (
 
 func newScanCmd() *cobra.Command {
 	var (
-		nameFilters string
-		rootPath    string
-		maxDepth    int
-		maxLimit    int
+		rootPath  string
+		maxDepth  int
+		maxLimit  int
+		metadata  bool
+		workers   int
+		keepGoing bool
+		sorted    bool
+
+		filters        scanFilterFlags
+		followSymlinks bool
 	)
 
+	var output *outputOpts
 	cmd := &cobra.Command{
 		Use:   "scan [path]",
 		Short: "Scan a directory for Chrome extensions",
 		Long:  `Scan recursively scans a directory for Chrome extensions in CRX, ZIP, or unpacked directory formats`,
 		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := output.Validate(); err != nil {
+				return err
+			}
 			path := rootPath
 			if len(args) > 0 {
 				path = args[0]
@@ -39,14 +51,9 @@ func newScanCmd() *cobra.Command {
 				path = strings.Replace(path, "~", home, 1)
 			}
 
-			var opts []crx3.ScanOption
-			if nameFilters != "" {
-				for _, filter := range strings.Split(nameFilters, ",") {
-					filter = strings.TrimSpace(filter)
-					if filter != "" {
-						opts = append(opts, crx3.WithNameFilter(filter))
-					}
-				}
+			opts, err := filters.options()
+			if err != nil {
+				return err
 			}
 			if maxDepth >= 0 {
 				opts = append(opts, crx3.WithMaxDepth(maxDepth))
@@ -54,10 +61,29 @@ func newScanCmd() *cobra.Command {
 			if maxLimit > 0 {
 				opts = append(opts, crx3.WithMaxResults(maxLimit))
 			}
+			if metadata {
+				opts = append(opts, crx3.WithMetadata())
+			}
+			if workers > 1 {
+				opts = append(opts, crx3.WithConcurrency(workers))
+			}
+			if keepGoing {
+				opts = append(opts, crx3.WithContinueOnError())
+			}
+			if sorted {
+				opts = append(opts, crx3.WithSortedResults())
+			}
+			if followSymlinks {
+				opts = append(opts, crx3.WithFollowSymlinks())
+			}
 
 			var results []*crx3.ExtensionInfo
 			for info, err := range crx3.Scan(path, opts...) {
 				if err != nil {
+					if keepGoing {
+						fmt.Fprintln(os.Stderr, err)
+						continue
+					}
 					return fmt.Errorf("scan error: %w", err)
 				}
 				if info != nil {
@@ -67,22 +93,109 @@ func newScanCmd() *cobra.Command {
 
 			if len(results) == 0 {
 				fmt.Fprintln(os.Stderr, "No extensions found.")
-				return nil
-			}
-
-			encoder := json.NewEncoder(os.Stdout)
-			encoder.SetIndent("", "  ")
-			if err := encoder.Encode(results); err != nil {
-				return fmt.Errorf("failed to encode results: %w", err)
 			}
-
-			return nil
+			return writeRecords(os.Stdout, output, results, scanColumns)
 		},
 	}
 
-	cmd.Flags().StringVar(&nameFilters, "filter", "", "Filter extensions by (partial) names, comma-separated")
 	cmd.Flags().IntVar(&maxDepth, "depth", 5, "Maximum directory depth to scan (0 = only root, -1 = unlimited)")
 	cmd.Flags().IntVar(&maxLimit, "limit", 15, "Maximum number of extensions to find (0 = unlimited)")
+	cmd.Flags().BoolVar(&metadata, "metadata", true, "Read extension ID, manifest and real directory size for each result")
+	cmd.Flags().IntVar(&workers, "workers", runtime.NumCPU(), "Number of parallel workers inspecting archives (1 = serial)")
+	cmd.Flags().BoolVar(&keepGoing, "continue-on-error", false, "Report unreadable paths to stderr and keep scanning")
+	cmd.Flags().BoolVar(&sorted, "sorted", true, "Print results in directory walk order")
+	cmd.Flags().BoolVar(&followSymlinks, "follow-symlinks", false, "Follow symbolic links to files and directories")
+	filters.register(cmd)
+	output = addOutputFlags(cmd, outputJSON)
 
 	return cmd
 }
+
+// scanFilterFlags are the result filters shared by scan and query.
+type scanFilterFlags struct {
+	names            string
+	types            []string
+	ids              []string
+	minSize          int64
+	maxSize          int64
+	modifiedAfter    string
+	modifiedBefore   string
+	manifestVersions []int
+	permissions      []string
+	include          []string
+	exclude          []string
+	hidden           bool
+}
+
+func (f *scanFilterFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.names, "filter", "", "Filter extensions by (partial) names, comma-separated")
+	cmd.Flags().StringSliceVar(&f.types, "type", nil, "Only report extensions of these types: crx, zip, dir")
+	cmd.Flags().StringSliceVar(&f.ids, "id", nil, "Only report extensions with these IDs")
+	cmd.Flags().Int64Var(&f.minSize, "min-size", 0, "Minimum extension size in bytes")
+	cmd.Flags().Int64Var(&f.maxSize, "max-size", 0, "Maximum extension size in bytes (0 = unlimited)")
+	cmd.Flags().StringVar(&f.modifiedAfter, "modified-after", "", "Only report extensions modified at or after this date (RFC3339, or YYYY-MM-DD in local time)")
+	cmd.Flags().StringVar(&f.modifiedBefore, "modified-before", "", "Only report extensions modified before this date (RFC3339, or YYYY-MM-DD in local time)")
+	cmd.Flags().IntSliceVar(&f.manifestVersions, "manifest-version", nil, "Only report extensions with these manifest versions")
+	cmd.Flags().StringSliceVar(&f.permissions, "permission", nil, "Only report extensions requesting any of these permissions")
+	cmd.Flags().StringSliceVar(&f.include, "include", nil, "Only report paths matching these glob patterns")
+	cmd.Flags().StringSliceVar(&f.exclude, "exclude", nil, "Skip paths matching these glob patterns")
+	cmd.Flags().BoolVar(&f.hidden, "hidden", false, "Scan hidden files and directories")
+}
+
+func (f *scanFilterFlags) options() ([]crx3.ScanOption, error) {
+	var opts []crx3.ScanOption
+	for _, name := range strings.Split(f.names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			opts = append(opts, crx3.WithNameFilter(name))
+		}
+	}
+	if len(f.types) > 0 {
+		opts = append(opts, crx3.WithTypeFilter(f.types...))
+	}
+	if len(f.ids) > 0 {
+		opts = append(opts, crx3.WithIDFilter(f.ids...))
+	}
+	if f.minSize > 0 || f.maxSize > 0 {
+		opts = append(opts, crx3.WithSizeRange(f.minSize, f.maxSize))
+	}
+	if f.modifiedAfter != "" {
+		t, err := crx3.ParseModifiedTime(f.modifiedAfter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --modified-after: %w", err)
+		}
+		opts = append(opts, crx3.WithModifiedAfter(t))
+	}
+	if f.modifiedBefore != "" {
+		t, err := crx3.ParseModifiedTime(f.modifiedBefore)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --modified-before: %w", err)
+		}
+		opts = append(opts, crx3.WithModifiedBefore(t))
+	}
+	if len(f.manifestVersions) > 0 {
+		opts = append(opts, crx3.WithManifestVersionFilter(f.manifestVersions...))
+	}
+	if len(f.permissions) > 0 {
+		opts = append(opts, crx3.WithPermissionFilter(f.permissions...))
+	}
+	if len(f.include) > 0 {
+		opts = append(opts, crx3.WithIncludeGlob(f.include...))
+	}
+	if len(f.exclude) > 0 {
+		opts = append(opts, crx3.WithExcludeGlob(f.exclude...))
+	}
+	if f.hidden {
+		opts = append(opts, crx3.WithHidden())
+	}
+	return opts, nil
+}
+
+var scanColumns = []column[*crx3.ExtensionInfo]{
+	{Name: "name", Value: func(e *crx3.ExtensionInfo) string { return cmp.Or(e.ManifestName, e.Name) }},
+	{Name: "id", Value: func(e *crx3.ExtensionInfo) string { return e.ID }},
+	{Name: "version", Value: func(e *crx3.ExtensionInfo) string { return e.Version }},
+	{Name: "type", Value: func(e *crx3.ExtensionInfo) string { return e.Type }},
+	{Name: "size", Value: func(e *crx3.ExtensionInfo) string { return strconv.FormatInt(e.Size, 10) }},
+	{Name: "modified", Value: func(e *crx3.ExtensionInfo) string { return e.Modified }},
+	{Name: "path", Value: func(e *crx3.ExtensionInfo) string { return e.Path }},
+}
@@ -1,7 +1,6 @@
 package commands
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 
@@ -10,12 +9,16 @@ import (
 )
 
 func newSearchCmd() *cobra.Command {
+	var output *outputOpts
 	cmd := &cobra.Command{
 		Use:   "search [name]",
 		Short: "Search for Chrome extensions by name",
 		Long:  `Search for Chrome extensions using DuckDuckGo and extract relevant results.`,
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := output.Validate(); err != nil {
+				return err
+			}
 			ctx := cmd.Context()
 			name := args[0]
 
@@ -26,18 +29,17 @@ func newSearchCmd() *cobra.Command {
 
 			if len(results) == 0 {
 				fmt.Fprintln(os.Stderr, "No extensions found.")
-				return nil
-			}
-
-			encoder := json.NewEncoder(os.Stdout)
-			encoder.SetIndent("", "  ")
-			if err := encoder.Encode(results); err != nil {
-				return fmt.Errorf("failed to encode results: %w", err)
 			}
-
-			return nil
+			return writeRecords(os.Stdout, output, results, searchColumns)
 		},
 	}
+	output = addOutputFlags(cmd, outputJSON)
 
 	return cmd
 }
+
+var searchColumns = []column[crx3.SearchResult]{
+	{Name: "id", Value: func(r crx3.SearchResult) string { return r.ExtensionID }},
+	{Name: "name", Value: func(r crx3.SearchResult) string { return r.Name }},
+	{Name: "url", Value: func(r crx3.SearchResult) string { return r.URL }},
+}
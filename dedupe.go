@@ -0,0 +1,143 @@
+package crx3
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+)
+
+// ExtensionCopy is one copy of an extension found in a workspace.
+type ExtensionCopy struct {
+	*ExtensionInfo
+	// ContentHash identifies the extension files regardless of the packaging,
+	// so a CRX file, its ZIP archive and its unpacked directory share a hash.
+	ContentHash string `json:"contentHash,omitempty"`
+}
+
+// DuplicateGroup holds all copies of one extension found in a workspace.
+// Copies without an ID join the group of an extension with the same content
+// hash; the remaining ones are grouped by content hash alone.
+type DuplicateGroup struct {
+	ID string `json:"id,omitempty"`
+	// Versions lists the distinct versions present, newest first.
+	Versions []string `json:"versions"`
+	// Copies are ordered newest first: by version, then by modification time.
+	Copies []*ExtensionCopy `json:"copies"`
+}
+
+// FindDuplicates scans rootPath and returns the groups of extensions that
+// are present more than once, either with the same ID or the same content.
+// Groups are sorted by ID. The scan options are applied as given, with
+// WithMetadata always enabled to resolve extension IDs. Errors reported
+// by WithContinueOnError are skipped.
+func FindDuplicates(rootPath string, opts ...ScanOption) ([]*DuplicateGroup, error) {
+	opts = append(slices.Clone(opts), WithMetadata())
+	var copies []*ExtensionCopy
+	// content hash -> ID of an extension with that content
+	hashIDs := make(map[string]string)
+	for info, err := range Scan(rootPath, opts...) {
+		if err != nil {
+			var scanErr *ScanError
+			if errors.As(err, &scanErr) {
+				continue
+			}
+			return nil, err
+		}
+		// unreadable archives are still grouped by ID, just without a hash
+		hash, _ := ContentHash(info.Path)
+		if len(info.ID) > 0 && len(hash) > 0 {
+			hashIDs[hash] = info.ID
+		}
+		copies = append(copies, &ExtensionCopy{ExtensionInfo: info, ContentHash: hash})
+	}
+
+	// copies without an ID join the group of an extension with identical content
+	groups := make(map[string]*DuplicateGroup)
+	for _, c := range copies {
+		id := cmp.Or(c.ID, hashIDs[c.ContentHash])
+		key := id
+		switch {
+		case len(key) > 0:
+		case len(c.ContentHash) > 0:
+			key = "sha256:" + c.ContentHash
+		default:
+			key = "path:" + c.Path
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = &DuplicateGroup{ID: id}
+			groups[key] = group
+		}
+		group.Copies = append(group.Copies, c)
+	}
+
+	result := make([]*DuplicateGroup, 0, len(groups))
+	for _, group := range groups {
+		if len(group.Copies) < 2 {
+			continue
+		}
+		group.sort()
+		result = append(result, group)
+	}
+	slices.SortFunc(result, func(a, b *DuplicateGroup) int {
+		return cmp.Or(cmp.Compare(a.ID, b.ID), cmp.Compare(a.Copies[0].Path, b.Copies[0].Path))
+	})
+	return result, nil
+}
+
+func (g *DuplicateGroup) sort() {
+	slices.SortStableFunc(g.Copies, func(a, b *ExtensionCopy) int {
+		if c := compareVersionStrings(b.Version, a.Version); c != 0 {
+			return c
+		}
+		if c := b.ModTime.Compare(a.ModTime); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Path, b.Path)
+	})
+	g.Versions = g.Versions[:0]
+	for _, c := range g.Copies {
+		if len(c.Version) > 0 && !slices.Contains(g.Versions, c.Version) {
+			g.Versions = append(g.Versions, c.Version)
+		}
+	}
+}
+
+// Prune splits the copies of the group into the newest keep copies and the rest.
+// Identical copies count separately, so Prune(1) leaves exactly one copy.
+func (g *DuplicateGroup) Prune(keep int) (kept, removed []*ExtensionCopy) {
+	keep = max(keep, 0)
+	if keep >= len(g.Copies) {
+		return g.Copies, nil
+	}
+	return g.Copies[:keep], g.Copies[keep:]
+}
+
+// RemoveExtension deletes an extension file or unpacked directory.
+func RemoveExtension(info *ExtensionInfo) error {
+	if info.Type == tdir {
+		return os.RemoveAll(info.Path)
+	}
+	return os.Remove(info.Path)
+}
+
+// ContentHash returns a hex-encoded SHA-256 over the file names and digests
+// of an extension in an unpacked directory, a ZIP archive or a CRX3 file.
+// Files are hashed in name order; the crx3.id and crx3.sum files written
+// by Unpack are ignored.
+func ContentHash(path string) (string, error) {
+	digests, err := Digests(path)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, name := range slices.Sorted(maps.Keys(digests)) {
+		fmt.Fprintf(h, "%s\x00%s\n", name, digests[name])
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,92 @@
+package crx3
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	groups, err := FindDuplicates("testdata/scanroot")
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+
+	group := groups[0]
+	assert.Equal(t, "kpkcennohgffjdgaelocingbmkjnpjgc", group.ID)
+	assert.Equal(t, []string{"2.1.0"}, group.Versions)
+	// the crx files, the zip archive and the unpacked directories have the same content
+	require.Len(t, group.Copies, 7)
+	for _, c := range group.Copies {
+		assert.Equal(t, group.Copies[0].ContentHash, c.ContentHash, c.Path)
+	}
+}
+
+func TestFindDuplicates_Versions(t *testing.T) {
+	root := t.TempDir()
+	write := func(dir, id, version string, modTime time.Time) {
+		path := filepath.Join(root, dir)
+		require.NoError(t, os.MkdirAll(path, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(path, manifestFilename),
+			[]byte(`{"name":"test","version":"`+version+`","manifest_version":3}`), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(path, extensionID),
+			[]byte(id), 0644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	now := time.Now()
+	const id = "kpkcennohgffjdgaelocingbmkjnpjgc"
+	write("ext", id, "1.10", now.Add(-3*time.Hour))
+	write("ext(1)", id, "1.9", now)
+	write("ext(2)", id, "1.10", now.Add(-time.Hour))
+	write("other", "nigbihjmcbekdlkgdceknpanajdpncle", "1.0", now)
+
+	groups, err := FindDuplicates(root)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	group := groups[0]
+	assert.Equal(t, []string{"1.10", "1.9"}, group.Versions)
+
+	var paths []string
+	for _, c := range group.Copies {
+		paths = append(paths, filepath.Base(c.Path))
+	}
+	// newest version first, then the most recently modified copy
+	assert.Equal(t, []string{"ext(2)", "ext", "ext(1)"}, paths)
+
+	kept, removed := group.Prune(1)
+	require.Len(t, kept, 1)
+	require.Len(t, removed, 2)
+	assert.Equal(t, "ext(2)", filepath.Base(kept[0].Path))
+
+	kept, removed = group.Prune(5)
+	assert.Len(t, kept, 3)
+	assert.Empty(t, removed)
+
+	for _, c := range group.Copies[1:] {
+		require.NoError(t, RemoveExtension(c.ExtensionInfo))
+	}
+	groups, err = FindDuplicates(root)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+}
+
+func TestContentHash(t *testing.T) {
+	crxHash, err := ContentHash("testdata/scanroot/valid.crx")
+	require.NoError(t, err)
+	zipHash, err := ContentHash("testdata/scanroot/some_extension.zip")
+	require.NoError(t, err)
+	dirHash, err := ContentHash("testdata/scanroot/some_extension")
+	require.NoError(t, err)
+	assert.Equal(t, crxHash, zipHash)
+	assert.Equal(t, crxHash, dirHash)
+
+	otherHash, err := ContentHash("testdata/withkey.zip")
+	require.NoError(t, err)
+	assert.NotEqual(t, crxHash, otherHash)
+
+	_, err = ContentHash("testdata/withkey.crx.pem")
+	assert.ErrorIs(t, err, ErrUnknownFileExtension)
+}
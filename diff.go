@@ -0,0 +1,538 @@
+package crx3
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// File statuses reported by Diff.
+const (
+	FileAdded    = "added"
+	FileRemoved  = "removed"
+	FileModified = "modified"
+)
+
+const (
+	defaultDiffContext  = 3
+	defaultMaxDiffBytes = 1 << 20
+)
+
+// textDiffExts lists the file types Diff produces unified text diffs for.
+var textDiffExts = []string{".js", ".mjs", ".cjs", ".json", ".html", ".htm", ".css"}
+
+// DiffOption configures Diff.
+type DiffOption func(*diffOptions)
+
+type diffOptions struct {
+	context  int
+	maxBytes int
+	noText   bool
+}
+
+// WithDiffContext returns a DiffOption that sets the number of context lines
+// around every change in unified text diffs. The default is 3.
+func WithDiffContext(lines int) DiffOption {
+	return func(o *diffOptions) {
+		o.context = max(lines, 0)
+	}
+}
+
+// WithMaxTextDiffSize returns a DiffOption that skips the text diff of files
+// larger than size bytes. The default is 1 MiB.
+func WithMaxTextDiffSize(size int) DiffOption {
+	return func(o *diffOptions) {
+		o.maxBytes = size
+	}
+}
+
+// WithoutTextDiffs returns a DiffOption that only lists modified files
+// without computing unified text diffs.
+func WithoutTextDiffs() DiffOption {
+	return func(o *diffOptions) {
+		o.noText = true
+	}
+}
+
+// ExtensionDiff describes what changed between two versions of an extension.
+type ExtensionDiff struct {
+	Old       string        `json:"old"`
+	New       string        `json:"new"`
+	Signature SignatureDiff `json:"signature"`
+	Manifest  ManifestDiff  `json:"manifest"`
+	Files     []FileDiff    `json:"files"`
+}
+
+// ValueChange is a manifest value that differs between two versions.
+type ValueChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// SetChange lists the entries added to and removed from a manifest list.
+type SetChange struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// Empty reports whether nothing was added or removed.
+func (c SetChange) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0
+}
+
+// ManifestDiff lists the manifest changes relevant for a review. Hosts combine
+// host permissions, host patterns in permissions and content script matches.
+// The content security policy of manifest v3 is compared as "key: value" lines.
+type ManifestDiff struct {
+	Changes             []ValueChange `json:"changes,omitempty"`
+	Permissions         SetChange     `json:"permissions"`
+	OptionalPermissions SetChange     `json:"optionalPermissions"`
+	Hosts               SetChange     `json:"hosts"`
+	OptionalHosts       SetChange     `json:"optionalHosts"`
+}
+
+// Empty reports whether no manifest change was found.
+func (d *ManifestDiff) Empty() bool {
+	return len(d.Changes) == 0 && d.Permissions.Empty() && d.OptionalPermissions.Empty() &&
+		d.Hosts.Empty() && d.OptionalHosts.Empty()
+}
+
+// SignatureInfo identifies who signed one version of an extension.
+type SignatureInfo struct {
+	// ID is the extension ID recorded in the CRX header. Empty for directories and ZIP archives.
+	ID string `json:"id,omitempty"`
+	// ManifestKeyID is the ID derived from the manifest "key" field, if any.
+	ManifestKeyID string `json:"manifestKeyId,omitempty"`
+	// KeyFingerprints are the SHA-256 fingerprints of the public keys of all
+	// CRX header proofs, sorted.
+	KeyFingerprints []string `json:"keyFingerprints,omitempty"`
+}
+
+// SignatureDiff compares the signatures of two versions of an extension.
+// Keys and IDs are only compared when both versions have them, so comparing
+// a CRX file with an unpacked directory does not report a change.
+type SignatureDiff struct {
+	Old     SignatureInfo `json:"old"`
+	New     SignatureInfo `json:"new"`
+	Changed bool          `json:"changed"`
+}
+
+// FileDiff is a file added, removed or modified between two versions.
+// Patch holds a unified diff for modified text files.
+type FileDiff struct {
+	Path      string `json:"path"`
+	Status    string `json:"status"`
+	OldSize   int64  `json:"oldSize"`
+	NewSize   int64  `json:"newSize"`
+	SizeDelta int64  `json:"sizeDelta"`
+	Patch     string `json:"patch,omitempty"`
+}
+
+// Empty reports whether both versions are identical.
+func (d *ExtensionDiff) Empty() bool {
+	return len(d.Files) == 0 && !d.Signature.Changed && d.Manifest.Empty()
+}
+
+// Diff compares two versions of an extension. Each of them may be an unpacked
+// directory, a ZIP archive or a CRX3 file. The crx3.id and crx3.sum files
+// written by Unpack are ignored.
+func Diff(oldPath, newPath string, opts ...DiffOption) (*ExtensionDiff, error) {
+	conf := &diffOptions{context: defaultDiffContext, maxBytes: defaultMaxDiffBytes}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	before, err := readDiffSide(oldPath, conf)
+	if err != nil {
+		return nil, err
+	}
+	after, err := readDiffSide(newPath, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &ExtensionDiff{Old: oldPath, New: newPath, Files: []FileDiff{}}
+	d.Manifest = diffManifests(before.manifest, after.manifest)
+	d.Signature = SignatureDiff{Old: before.signature, New: after.signature}
+	d.Signature.Changed = signatureChanged(before.signature, after.signature)
+
+	names := slices.Sorted(maps.Keys(before.files))
+	for name := range after.files {
+		if _, ok := before.files[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		a, inOld := before.files[name]
+		b, inNew := after.files[name]
+		f := FileDiff{Path: name, OldSize: a.size, NewSize: b.size, SizeDelta: b.size - a.size}
+		switch {
+		case !inOld:
+			f.Status = FileAdded
+		case !inNew:
+			f.Status = FileRemoved
+		case a.sum != b.sum:
+			f.Status = FileModified
+			if a.text != nil && b.text != nil {
+				f.Patch, err = unifiedDiff(name, a.text, b.text, conf.context)
+				if err != nil {
+					return nil, err
+				}
+			}
+		default:
+			continue
+		}
+		d.Files = append(d.Files, f)
+	}
+	return d, nil
+}
+
+type diffFile struct {
+	size int64
+	sum  string
+	text []byte
+}
+
+type diffSide struct {
+	manifest  *diffManifest
+	signature SignatureInfo
+	files     map[string]diffFile
+}
+
+func readDiffSide(p string, conf *diffOptions) (*diffSide, error) {
+	if _, err := os.Stat(p); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrPathNotFound, p)
+	}
+	side := &diffSide{files: make(map[string]diffFile)}
+	var manifestData []byte
+	err := walkExtensionFiles(p, func(name string, r io.Reader) error {
+		var f diffFile
+		h := sha256.New()
+		if name == manifestFilename || !conf.noText && isTextDiffFile(name) {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			h.Write(data)
+			f.size = int64(len(data))
+			if name == manifestFilename {
+				manifestData = data
+			}
+			if !conf.noText && isTextDiffFile(name) && len(data) <= conf.maxBytes {
+				f.text = data
+			}
+		} else {
+			n, err := io.Copy(h, r)
+			if err != nil {
+				return err
+			}
+			f.size = n
+		}
+		f.sum = hex.EncodeToString(h.Sum(nil))
+		side.files[name] = f
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if manifestData == nil {
+		return nil, fmt.Errorf("%w: %s", ErrManifestNotFound, p)
+	}
+	if side.manifest, err = parseDiffManifest(manifestData); err != nil {
+		return nil, fmt.Errorf("crx3: %s: %w", p, err)
+	}
+	if len(side.manifest.Key) > 0 {
+		side.signature.ManifestKeyID, _ = IDFromPubKey([]byte(side.manifest.Key))
+	}
+	if isCRX(p) {
+		header, signedData, err := readCRXHeader(p)
+		if err != nil {
+			return nil, fmt.Errorf("crx3: failed to read header of %s: %w", p, err)
+		}
+		side.signature.ID = string(makeExtensionID(signedData.CrxId))
+		for _, proof := range slices.Concat(header.Sha256WithRsa, header.Sha256WithEcdsa) {
+			sum := sha256.Sum256(proof.PublicKey)
+			side.signature.KeyFingerprints = append(side.signature.KeyFingerprints, hex.EncodeToString(sum[:]))
+		}
+		slices.Sort(side.signature.KeyFingerprints)
+	}
+	return side, nil
+}
+
+func isTextDiffFile(name string) bool {
+	return slices.Contains(textDiffExts, strings.ToLower(path.Ext(name)))
+}
+
+func signatureChanged(a, b SignatureInfo) bool {
+	differ := func(x, y string) bool {
+		return len(x) > 0 && len(y) > 0 && x != y
+	}
+	if differ(a.ID, b.ID) || differ(a.ManifestKeyID, b.ManifestKeyID) {
+		return true
+	}
+	// a key was added to or removed from the manifest
+	if (len(a.ManifestKeyID) > 0) != (len(b.ManifestKeyID) > 0) {
+		return true
+	}
+	return len(a.KeyFingerprints) > 0 && len(b.KeyFingerprints) > 0 &&
+		!slices.Equal(a.KeyFingerprints, b.KeyFingerprints)
+}
+
+// diffManifest holds the manifest fields compared by Diff.
+type diffManifest struct {
+	Manifest
+	OptionalHostPermissions []string
+	ContentScriptMatches    []string
+	ContentSecurityPolicy   string
+}
+
+func parseDiffManifest(data []byte) (*diffManifest, error) {
+	m, err := ParseManifest(data)
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		OptionalHostPermissions []json.RawMessage `json:"optional_host_permissions"`
+		ContentScripts          []struct {
+			Matches []json.RawMessage `json:"matches"`
+		} `json:"content_scripts"`
+		ContentSecurityPolicy json.RawMessage `json:"content_security_policy"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidManifest, err)
+	}
+	dm := &diffManifest{
+		Manifest:                *m,
+		OptionalHostPermissions: rawStrings(raw.OptionalHostPermissions),
+		ContentSecurityPolicy:   policyString(raw.ContentSecurityPolicy),
+	}
+	for _, cs := range raw.ContentScripts {
+		dm.ContentScriptMatches = append(dm.ContentScriptMatches, rawStrings(cs.Matches)...)
+	}
+	return dm, nil
+}
+
+// policyString returns a manifest v2 policy as is and a manifest v3 policy
+// object as sorted "key: value" lines.
+func policyString(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var policies map[string]string
+	if err := json.Unmarshal(raw, &policies); err == nil {
+		lines := make([]string, 0, len(policies))
+		for _, key := range slices.Sorted(maps.Keys(policies)) {
+			lines = append(lines, key+": "+policies[key])
+		}
+		return strings.Join(lines, "\n")
+	}
+	return string(raw)
+}
+
+// isHostPattern reports whether a permission is a match pattern rather than an API permission.
+func isHostPattern(permission string) bool {
+	return permission == "<all_urls>" || strings.Contains(permission, "://")
+}
+
+// splitPermissions separates API permissions from host patterns.
+func splitPermissions(values []string) (apis, hosts []string) {
+	for _, v := range values {
+		if isHostPattern(v) {
+			hosts = append(hosts, v)
+		} else {
+			apis = append(apis, v)
+		}
+	}
+	return apis, hosts
+}
+
+func diffManifests(a, b *diffManifest) ManifestDiff {
+	var d ManifestDiff
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"name", a.Name, b.Name},
+		{"version", a.Version, b.Version},
+		{"version_name", a.VersionName, b.VersionName},
+		{"manifest_version", strconv.Itoa(a.ManifestVersion), strconv.Itoa(b.ManifestVersion)},
+		{"minimum_chrome_version", a.MinimumChromeVersion, b.MinimumChromeVersion},
+		{"update_url", a.UpdateURL, b.UpdateURL},
+		{"key", a.Key, b.Key},
+		{"content_security_policy", a.ContentSecurityPolicy, b.ContentSecurityPolicy},
+	}
+	for _, f := range fields {
+		if f.old != f.new {
+			d.Changes = append(d.Changes, ValueChange{Field: f.name, Old: f.old, New: f.new})
+		}
+	}
+
+	apisA, hostsA := splitPermissions(a.Permissions)
+	apisB, hostsB := splitPermissions(b.Permissions)
+	optA, optHostsA := splitPermissions(a.OptionalPermissions)
+	optB, optHostsB := splitPermissions(b.OptionalPermissions)
+	d.Permissions = diffSets(apisA, apisB)
+	d.OptionalPermissions = diffSets(optA, optB)
+	d.Hosts = diffSets(
+		slices.Concat(hostsA, a.HostPermissions, a.ContentScriptMatches),
+		slices.Concat(hostsB, b.HostPermissions, b.ContentScriptMatches),
+	)
+	d.OptionalHosts = diffSets(
+		slices.Concat(optHostsA, a.OptionalHostPermissions),
+		slices.Concat(optHostsB, b.OptionalHostPermissions),
+	)
+	return d
+}
+
+// diffSets returns the sorted entries only in b (added) and only in a (removed).
+func diffSets(a, b []string) SetChange {
+	var c SetChange
+	for _, v := range b {
+		if !slices.Contains(a, v) && !slices.Contains(c.Added, v) {
+			c.Added = append(c.Added, v)
+		}
+	}
+	for _, v := range a {
+		if !slices.Contains(b, v) && !slices.Contains(c.Removed, v) {
+			c.Removed = append(c.Removed, v)
+		}
+	}
+	slices.Sort(c.Added)
+	slices.Sort(c.Removed)
+	return c
+}
+
+func unifiedDiff(name string, a, b []byte, context int) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitDiffLines(a),
+		B:        splitDiffLines(b),
+		FromFile: "a/" + name,
+		ToFile:   "b/" + name,
+		Context:  context,
+	})
+}
+
+// splitDiffLines splits text into lines ending with a newline. A last line
+// without a newline carries the "\ No newline at end of file" marker, so it
+// differs from the same line with a newline and is printed like diff -u does.
+func splitDiffLines(text []byte) []string {
+	lines := strings.SplitAfter(string(text), "\n")
+	if last := lines[len(lines)-1]; len(last) == 0 {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] = last + "\n" + noNewlineMarker + "\n"
+	}
+	return lines
+}
+
+// WriteMarkdown writes the diff as a Markdown report. Signature changes are
+// reported first, followed by manifest changes, the file list and text diffs.
+func (d *ExtensionDiff) WriteMarkdown(w io.Writer) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Extension diff\n\n`%s` → `%s`\n\n", d.Old, d.New)
+	if d.Empty() {
+		buf.WriteString("No changes.\n")
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+
+	if d.Signature.Changed {
+		buf.WriteString("> [!WARNING]\n> The signing key or extension ID changed. " +
+			"Verify that the new version comes from the same publisher.\n\n")
+	}
+	sig := []struct {
+		name     string
+		old, new string
+	}{
+		{"Extension ID", d.Signature.Old.ID, d.Signature.New.ID},
+		{"Manifest key ID", d.Signature.Old.ManifestKeyID, d.Signature.New.ManifestKeyID},
+		{"Signing keys (SHA-256)", strings.Join(d.Signature.Old.KeyFingerprints, "<br>"),
+			strings.Join(d.Signature.New.KeyFingerprints, "<br>")},
+	}
+	buf.WriteString("## Signature\n\n| | Old | New |\n|---|---|---|\n")
+	for _, row := range sig {
+		fmt.Fprintf(&buf, "| %s | %s | %s |\n", row.name, markdownCell(row.old), markdownCell(row.new))
+	}
+	buf.WriteString("\n")
+
+	if !d.Manifest.Empty() {
+		buf.WriteString("## Manifest\n\n")
+		if len(d.Manifest.Changes) > 0 {
+			buf.WriteString("| Field | Old | New |\n|---|---|---|\n")
+			for _, c := range d.Manifest.Changes {
+				fmt.Fprintf(&buf, "| `%s` | %s | %s |\n", c.Field, markdownCell(c.Old), markdownCell(c.New))
+			}
+			buf.WriteString("\n")
+		}
+		sets := []struct {
+			name   string
+			change SetChange
+		}{
+			{"permissions", d.Manifest.Permissions},
+			{"optional permissions", d.Manifest.OptionalPermissions},
+			{"hosts", d.Manifest.Hosts},
+			{"optional hosts", d.Manifest.OptionalHosts},
+		}
+		for _, s := range sets {
+			if len(s.change.Added) > 0 {
+				fmt.Fprintf(&buf, "- **Added %s:** %s\n", s.name, markdownCodeList(s.change.Added))
+			}
+			if len(s.change.Removed) > 0 {
+				fmt.Fprintf(&buf, "- **Removed %s:** %s\n", s.name, markdownCodeList(s.change.Removed))
+			}
+		}
+		buf.WriteString("\n")
+	}
+
+	if len(d.Files) > 0 {
+		buf.WriteString("## Files\n\n| Status | Path | Old size | New size | Delta |\n|---|---|--:|--:|--:|\n")
+		for _, f := range d.Files {
+			fmt.Fprintf(&buf, "| %s | `%s` | %d | %d | %+d |\n", f.Status, f.Path, f.OldSize, f.NewSize, f.SizeDelta)
+		}
+		buf.WriteString("\n")
+		for _, f := range d.Files {
+			if len(f.Patch) == 0 {
+				continue
+			}
+			fmt.Fprintf(&buf, "### `%s`\n\n```diff\n%s", f.Path, f.Patch)
+			if !strings.HasSuffix(f.Patch, "\n") {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("```\n\n")
+		}
+	}
+	_, err := w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return err
+}
+
+func markdownCell(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+func markdownCodeList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "`" + v + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
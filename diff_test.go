@@ -0,0 +1,191 @@
+package crx3
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff_Identical(t *testing.T) {
+	// the same extension packed as CRX, ZIP and unpacked directory
+	paths := []string{
+		"testdata/scanroot/valid.crx",
+		"testdata/scanroot/some_extension.zip",
+		"testdata/scanroot/some_extension",
+	}
+	for _, a := range paths {
+		for _, b := range paths {
+			d, err := Diff(a, b)
+			require.NoError(t, err)
+			assert.True(t, d.Empty(), "%s -> %s: %+v", a, b, d)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	const oldPath = "testdata/scanroot/valid.crx"
+	newPath := t.TempDir()
+	require.NoError(t, os.CopyFS(newPath, os.DirFS("testdata/scanroot/some_extension")))
+
+	manifest := map[string]any{
+		"manifest_version": 3,
+		"name":             "Sample",
+		"version":          "2.2.0",
+		"permissions":      []string{"activeTab", "cookies", "https://*.example.com/*"},
+		"host_permissions": []string{"<all_urls>"},
+		"content_scripts": []map[string]any{
+			{"matches": []string{"https://example.org/*"}, "js": []string{"js/content.js"}},
+		},
+		"content_security_policy": map[string]string{"extension_pages": "script-src 'self'"},
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(newPath, manifestFilename), data, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(newPath, "js", "content.js"), []byte("console.log(1)\n"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(newPath, "favicon.ico")))
+	require.NoError(t, os.WriteFile(filepath.Join(newPath, "icons", "16.png"), []byte("png"), 0644))
+
+	d, err := Diff(oldPath, newPath)
+	require.NoError(t, err)
+	assert.False(t, d.Empty())
+
+	assert.Equal(t, []ValueChange{
+		{Field: "version", Old: "2.1.0", New: "2.2.0"},
+		{Field: "manifest_version", Old: "2", New: "3"},
+		{Field: "update_url", Old: "https://clients2.google.com/service/update2/crx", New: ""},
+		{Field: "content_security_policy", Old: "script-src 'self' ; object-src 'self'", New: "extension_pages: script-src 'self'"},
+	}, d.Manifest.Changes)
+	assert.Equal(t, SetChange{Added: []string{"cookies"}, Removed: []string{"tabCapture"}}, d.Manifest.Permissions)
+	assert.Equal(t, SetChange{Added: []string{"<all_urls>", "https://*.example.com/*", "https://example.org/*"}}, d.Manifest.Hosts)
+	assert.True(t, d.Manifest.OptionalHosts.Empty())
+
+	// the directory has no CRX header to compare with
+	assert.False(t, d.Signature.Changed)
+	assert.Equal(t, "kpkcennohgffjdgaelocingbmkjnpjgc", d.Signature.Old.ID)
+	assert.NotEmpty(t, d.Signature.Old.KeyFingerprints)
+
+	files := make(map[string]FileDiff)
+	for _, f := range d.Files {
+		files[f.Path] = f
+	}
+	require.Len(t, files, 4)
+	assert.Equal(t, FileAdded, files["js/content.js"].Status)
+	assert.Equal(t, int64(15), files["js/content.js"].SizeDelta)
+	assert.Equal(t, FileRemoved, files["favicon.ico"].Status)
+	assert.Equal(t, -files["favicon.ico"].OldSize, files["favicon.ico"].SizeDelta)
+	assert.Equal(t, FileModified, files["icons/16.png"].Status)
+	assert.Empty(t, files["icons/16.png"].Patch)
+	assert.Equal(t, FileModified, files[manifestFilename].Status)
+	assert.Contains(t, files[manifestFilename].Patch, "--- a/manifest.json\n+++ b/manifest.json\n")
+	assert.Contains(t, files[manifestFilename].Patch, `+  "version": "2.2.0"`)
+
+	d, err = Diff(oldPath, newPath, WithoutTextDiffs())
+	require.NoError(t, err)
+	for _, f := range d.Files {
+		assert.Empty(t, f.Patch, f.Path)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, d.WriteMarkdown(&buf))
+	md := buf.String()
+	assert.Contains(t, md, "| `version` | 2.1.0 | 2.2.0 |")
+	assert.Contains(t, md, "- **Added permissions:** `cookies`")
+	assert.Contains(t, md, "| removed | `favicon.ico` |")
+	assert.NotContains(t, md, "[!WARNING]")
+}
+
+func TestDiff_SignatureChanged(t *testing.T) {
+	d, err := Diff("testdata/dodyDol.crx", "testdata/withkey.crx")
+	require.NoError(t, err)
+	assert.True(t, d.Signature.Changed)
+	assert.NotEqual(t, d.Signature.Old.ID, d.Signature.New.ID)
+
+	var buf bytes.Buffer
+	require.NoError(t, d.WriteMarkdown(&buf))
+	assert.True(t, strings.Contains(buf.String(), "[!WARNING]"))
+}
+
+func TestDiff_Errors(t *testing.T) {
+	_, err := Diff("testdata/missing.crx", "testdata/withkey.crx")
+	assert.ErrorIs(t, err, ErrPathNotFound)
+
+	_, err = Diff("testdata/withkey.crx", "testdata/emptydir")
+	assert.ErrorIs(t, err, ErrManifestNotFound)
+}
+
+func TestSignatureChanged(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b SignatureInfo
+		want bool
+	}{
+		{name: "empty", want: false},
+		{name: "same id", a: SignatureInfo{ID: "a"}, b: SignatureInfo{ID: "a"}, want: false},
+		{name: "different id", a: SignatureInfo{ID: "a"}, b: SignatureInfo{ID: "b"}, want: true},
+		{name: "id only on one side", a: SignatureInfo{ID: "a"}, want: false},
+		{name: "manifest key added", b: SignatureInfo{ManifestKeyID: "a"}, want: true},
+		{name: "manifest key changed", a: SignatureInfo{ManifestKeyID: "a"}, b: SignatureInfo{ManifestKeyID: "b"}, want: true},
+		{name: "key rotated", a: SignatureInfo{KeyFingerprints: []string{"1"}}, b: SignatureInfo{KeyFingerprints: []string{"2"}}, want: true},
+		{name: "same keys", a: SignatureInfo{KeyFingerprints: []string{"1", "2"}}, b: SignatureInfo{KeyFingerprints: []string{"1", "2"}}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, signatureChanged(tt.a, tt.b))
+		})
+	}
+}
+
+func TestPolicyString(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{raw: ``, want: ""},
+		{raw: `"script-src 'self'"`, want: "script-src 'self'"},
+		{raw: `{"sandbox":"b","extension_pages":"a"}`, want: "extension_pages: a\nsandbox: b"},
+		{raw: `42`, want: "42"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, policyString(json.RawMessage(tt.raw)), tt.raw)
+	}
+}
+
+func TestUnifiedDiff_NoNewlineAtEOF(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "newline removed",
+			a:    "a\nb\n",
+			b:    "a\nb",
+			want: "--- a/x.js\n+++ b/x.js\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n",
+		},
+		{
+			name: "newline added",
+			a:    "a\nb",
+			b:    "a\nb\n",
+			want: "--- a/x.js\n+++ b/x.js\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			name: "last line changed without newline",
+			a:    "a\nb",
+			b:    "a\nc",
+			want: "--- a/x.js\n+++ b/x.js\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := unifiedDiff("x.js", []byte(tt.a), []byte(tt.b), defaultDiffContext)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, patch)
+		})
+	}
+}
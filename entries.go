@@ -0,0 +1,177 @@
+package crx3
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Entry describes a file or directory stored in the ZIP archive of a CRX3 file or a ZIP archive.
+type Entry struct {
+	Name           string    `json:"name"`
+	Size           uint64    `json:"size"`
+	CompressedSize uint64    `json:"compressedSize"`
+	Modified       time.Time `json:"modified"`
+	CRC32          uint32    `json:"crc32"`
+	IsDir          bool      `json:"isDir,omitempty"`
+}
+
+// openArchive opens the ZIP archive of a CRX3 file or a ZIP file. The CRX3
+// header is skipped by the zip reader, so nothing is copied or unpacked.
+func openArchive(filename string) (*zip.ReadCloser, error) {
+	if !isCRX(filename) && !isZip(filename) {
+		if _, err := os.Stat(filename); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrPathNotFound, filename)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFileFormat, filename)
+	}
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, fmt.Errorf("crx3: failed to open zip reader: %w", err)
+	}
+	return r, nil
+}
+
+// entryName normalizes a path inside an archive: slash-separated, without a leading "./" or "/".
+func entryName(name string) string {
+	name = strings.TrimPrefix(filepath.ToSlash(name), "./")
+	return strings.TrimLeft(name, "/")
+}
+
+// ListEntries returns the entries of a CRX3 file or a ZIP archive in archive order.
+func ListEntries(filename string) ([]Entry, error) {
+	r, err := openArchive(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	entries := make([]Entry, 0, len(r.File))
+	for _, f := range r.File {
+		entries = append(entries, Entry{
+			Name:           entryName(f.Name),
+			Size:           f.UncompressedSize64,
+			CompressedSize: f.CompressedSize64,
+			Modified:       f.Modified,
+			CRC32:          f.CRC32,
+			IsDir:          f.FileInfo().IsDir(),
+		})
+	}
+	return entries, nil
+}
+
+// CopyEntry writes the content of the file name stored in a CRX3 file or a
+// ZIP archive to w. It returns an error wrapping ErrEntryNotFound if there is no such file.
+func CopyEntry(w io.Writer, filename string, name string) error {
+	r, err := openArchive(filename)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	name = entryName(name)
+	for _, f := range r.File {
+		if entryName(f.Name) != name || f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("crx3: failed to open file %s: %w", f.Name, err)
+		}
+		defer rc.Close()
+		if _, err := io.Copy(w, rc); err != nil {
+			return fmt.Errorf("crx3: failed to read %s: %w", f.Name, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s in %s", ErrEntryNotFound, name, filename)
+}
+
+// ReadEntry returns the content of the file name stored in a CRX3 file or a ZIP archive.
+func ReadEntry(filename string, name string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := CopyEntry(&buf, filename, name); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExtractEntries extracts the files of a CRX3 file or a ZIP archive that
+// match one of the patterns into dir and returns their names. Patterns use
+// the filepath.Match syntax and are matched against the path inside the
+// archive, its base name and its parent directories, so "js" extracts the
+// whole js directory. Without patterns every file is extracted.
+func ExtractEntries(filename string, dir string, patterns ...string) ([]string, error) {
+	r, err := openArchive(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	root := filepath.Clean(dir)
+	extracted := []string{}
+	for _, f := range r.File {
+		name := entryName(f.Name)
+		if f.FileInfo().IsDir() || len(patterns) > 0 && !matchEntry(patterns, name) {
+			continue
+		}
+		target := filepath.Join(root, filepath.FromSlash(name))
+		if !isWithinDir(root, target) {
+			return extracted, fmt.Errorf("crx3: %s: illegal file path", f.Name)
+		}
+		if err := extractFile(f, target); err != nil {
+			return extracted, err
+		}
+		extracted = append(extracted, name)
+	}
+	return extracted, nil
+}
+
+// isWithinDir reports whether target is inside the directory root, so archive
+// entries like "../evil.js" cannot be written outside it. Both are cleaned paths.
+func isWithinDir(root, target string) bool {
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
+
+// matchEntry reports whether name, its base name or one of its parent
+// directories matches one of the patterns.
+func matchEntry(patterns []string, name string) bool {
+	if matchGlobs(patterns, name) {
+		return true
+	}
+	for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(strings.TrimSuffix(pattern, "/"), dir); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func extractFile(f *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("crx3: failed to open file %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return fmt.Errorf("crx3: failed to extract %s: %w", f.Name, err)
+	}
+	return out.Close()
+}
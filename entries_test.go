@@ -0,0 +1,153 @@
+package crx3
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListEntries(t *testing.T) {
+	for _, filename := range []string{"testdata/withkey.crx", "testdata/withkey.zip"} {
+		t.Run(filepath.Base(filename), func(t *testing.T) {
+			entries, err := ListEntries(filename)
+			require.NoError(t, err)
+			var names []string
+			for _, e := range entries {
+				if !e.IsDir {
+					names = append(names, e.Name)
+				}
+			}
+			assert.ElementsMatch(t, []string{"background.js", "images/image.jpeg", manifestFilename}, names)
+			for _, e := range entries {
+				if e.Name == "images/image.jpeg" {
+					assert.Equal(t, uint64(57468), e.Size)
+					assert.NotZero(t, e.CompressedSize)
+					assert.NotZero(t, e.CRC32)
+					assert.False(t, e.Modified.IsZero())
+				}
+			}
+		})
+	}
+
+	_, err := ListEntries("testdata/missing.crx")
+	assert.ErrorIs(t, err, ErrPathNotFound)
+	_, err = ListEntries("testdata/withkey.crx.pem")
+	assert.ErrorIs(t, err, ErrUnsupportedFileFormat)
+}
+
+func TestReadEntry(t *testing.T) {
+	expected, err := os.ReadFile("testdata/scanroot/some_extension/popup.html")
+	require.NoError(t, err)
+	for _, name := range []string{"popup.html", "./popup.html", "/popup.html"} {
+		data, err := ReadEntry("testdata/scanroot/valid.crx", name)
+		require.NoError(t, err)
+		assert.Equal(t, expected, data)
+	}
+
+	_, err = ReadEntry("testdata/scanroot/valid.crx", "missing.js")
+	assert.ErrorIs(t, err, ErrEntryNotFound)
+	_, err = ReadEntry("testdata/scanroot/valid.crx", "js")
+	assert.ErrorIs(t, err, ErrEntryNotFound)
+}
+
+func TestExtractEntries(t *testing.T) {
+	const crx = "testdata/scanroot/valid.crx"
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{name: "exact path", patterns: []string{"popup.html"}, want: []string{"popup.html"}},
+		{name: "base name", patterns: []string{"messages.json"}, want: []string{"_locales/en/messages.json"}},
+		{name: "glob", patterns: []string{"icons/16*.png"}, want: []string{"icons/16-light.png", "icons/16.png"}},
+		{name: "directory", patterns: []string{"_locales/"}, want: []string{"_locales/en/messages.json"}},
+		{name: "no match", patterns: []string{"*.exe"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			extracted, err := ExtractEntries(crx, dir, tt.patterns...)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, extracted)
+			for _, name := range tt.want {
+				data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+				require.NoError(t, err)
+				expected, err := ReadEntry(crx, name)
+				require.NoError(t, err)
+				assert.Equal(t, expected, data)
+			}
+		})
+	}
+
+	all, err := ExtractEntries(crx, t.TempDir())
+	require.NoError(t, err)
+	digests, err := Digests(crx)
+	require.NoError(t, err)
+	assert.Len(t, all, len(digests))
+}
+
+func TestExtractEntries_IllegalPath(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("../evil.js")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("alert(1)"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	filename := filepath.Join(t.TempDir(), "evil.zip")
+	require.NoError(t, os.WriteFile(filename, buf.Bytes(), 0644))
+	dir := filepath.Join(t.TempDir(), "out")
+	_, err = ExtractEntries(filename, dir)
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(dir), "evil.js"))
+}
+
+func TestIsWithinDir(t *testing.T) {
+	tests := []struct {
+		root   string
+		target string
+		want   bool
+	}{
+		{root: ".", target: "manifest.json", want: true},
+		{root: ".", target: "icons/16.png", want: true},
+		{root: ".", target: "..evil.js", want: true},
+		{root: ".", target: "../evil.js"},
+		{root: ".", target: ".."},
+		{root: ".", target: "."},
+		{root: "/", target: "/manifest.json", want: true},
+		{root: "/out", target: "/out/icons/16.png", want: true},
+		{root: "/out", target: "/outside.js"},
+		{root: "/out", target: "/evil.js"},
+		{root: "out", target: "out/manifest.json", want: true},
+		{root: "out", target: "manifest.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.root+" "+tt.target, func(t *testing.T) {
+			assert.Equal(t, tt.want, isWithinDir(filepath.FromSlash(tt.root), filepath.FromSlash(tt.target)))
+		})
+	}
+}
+
+func TestExtractEntries_WorkingDir(t *testing.T) {
+	crx, err := filepath.Abs("testdata/scanroot/valid.crx")
+	require.NoError(t, err)
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	extracted, err := ExtractEntries(crx, ".", "popup.html")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"popup.html"}, extracted)
+	assert.FileExists(t, filepath.Join(dir, "popup.html"))
+
+	abs := filepath.Join(dir, "abs")
+	extracted, err = ExtractEntries(crx, abs, "_locales/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"_locales/en/messages.json"}, extracted)
+	assert.FileExists(t, filepath.Join(abs, "_locales", "en", "messages.json"))
+}
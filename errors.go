@@ -9,4 +9,14 @@ var (
 	ErrPathNotFound          = errors.New("crx3: filepath not found")
 	ErrPrivateKeyNotFound    = errors.New("crx3: private key not found")
 	ErrInvalidReader         = errors.New("crx3: invalid reader")
+	ErrInvalidVersion        = errors.New("crx3: invalid version")
+	ErrManifestNotFound      = errors.New("crx3: manifest not found")
+	ErrInvalidManifest       = errors.New("crx3: invalid manifest")
+	ErrIndexNotFound         = errors.New("crx3: index not found")
+	ErrEntryNotFound         = errors.New("crx3: entry not found")
+	ErrIDMismatch            = errors.New("crx3: manifest key and signing key give different extension IDs")
+	ErrPassphraseRequired    = errors.New("crx3: private key is encrypted, passphrase required")
+	ErrIncorrectPassphrase   = errors.New("crx3: incorrect passphrase")
+	ErrUnsupportedKeyType    = errors.New("crx3: unsupported private key")
+	ErrKeyNotFound           = errors.New("crx3: key not found in key store")
 )
@@ -20,15 +20,37 @@ func (e Extension) String() string {
 	return string(e)
 }
 
+// IDOption is a function that configures Extension.ID.
+type IDOption func(*idOptions)
+
+// idOptions holds configuration for Extension.ID.
+type idOptions struct {
+	pathFallback bool
+}
+
+// IDWithPathFallback returns an IDOption that makes Extension.ID return the
+// developer-mode ID of an unpacked directory without a manifest key, as
+// computed by IDFromPath from the real absolute path of the directory.
+func IDWithPathFallback() IDOption {
+	return func(o *idOptions) {
+		o.pathFallback = true
+	}
+}
+
 // ID calculates the Chrome Extension ID for the Extension instance.
 // It supports directories, ZIP archives, and CRX3 files. If the extension is unpacked,
 // contained in a ZIP archive, or is a CRX3 file with a specified key in its manifest,
 // the ID is generated from this key. The function returns an error if the extension is empty,
 // the file cannot be read, the key is not found, or the file format is unsupported.
-func (e Extension) ID() (string, error) {
+// With IDWithPathFallback a directory without a key gets the ID derived from its path.
+func (e Extension) ID(opts ...IDOption) (string, error) {
 	if e.IsEmpty() {
 		return "", fmt.Errorf("%w: %s", ErrPathNotFound, e)
 	}
+	conf := new(idOptions)
+	for _, opt := range opts {
+		opt(conf)
+	}
 	switch {
 	case e.IsDir():
 		manifest := manifestFile(e.String())
@@ -37,6 +59,9 @@ func (e Extension) ID() (string, error) {
 			return "", fmt.Errorf("crx3: failed to read file %s: %w", manifest, err)
 		}
 		pubkey := parseKeyFromManifest(file)
+		if len(pubkey) == 0 && conf.pathFallback {
+			return unpackedPathID(e.String())
+		}
 		if len(pubkey) == 0 {
 			return "", fmt.Errorf("crx3: failed to parse key from manifest file %s", manifest)
 		}
@@ -150,20 +175,20 @@ func (e Extension) Unpack() error {
 }
 
 // PackTo packs zip file or an unpacked directory into a CRX3 file.
-func (e Extension) PackTo(dst string, pk *rsa.PrivateKey) error {
+func (e Extension) PackTo(dst string, pk *rsa.PrivateKey, opts ...PackOption) error {
 	if e.IsEmpty() {
 		return ErrPathNotFound
 	}
-	return Pack(e.String(), dst, pk)
+	return Pack(e.String(), dst, pk, opts...)
 }
 
 // Pack packs zip file or an unpacked directory into a CRX3 file.
-func (e Extension) Pack(pk *rsa.PrivateKey) error {
+func (e Extension) Pack(pk *rsa.PrivateKey, opts ...PackOption) error {
 	if e.IsEmpty() {
 		return ErrPathNotFound
 	}
 	dst := strings.TrimRight(e.String(), "/") + crxExt
-	return Pack(e.String(), dst, pk)
+	return Pack(e.String(), dst, pk, opts...)
 }
 
 // WriteTo packs the contents of the Extension into a CRX file and writes it to the provided io.Writer.
@@ -209,6 +234,18 @@ func (e Extension) WriteTo(w io.Writer, pk *rsa.PrivateKey) error {
 	return PackZipToCRX(reader, w, pk)
 }
 
+// unpackedPathID returns the developer-mode ID of the directory dir.
+func unpackedPathID(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if abs, err = filepath.EvalSymlinks(abs); err != nil {
+		return "", err
+	}
+	return IDFromPath(abs)
+}
+
 func manifestFile(path string) string {
 	return filepath.Join(path, "manifest.json")
 }
@@ -0,0 +1,137 @@
+package crx3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ExternalExtensionsDir is the directory Google Chrome on Linux reads
+// external extension preferences from. Chromium uses /usr/share/chromium/extensions.
+const ExternalExtensionsDir = "/usr/share/google-chrome/extensions"
+
+// ExternalExtension is the content of an external extension preferences file
+// (<id>.json) used to preinstall an extension. Either ExternalCRX together
+// with ExternalVersion or ExternalUpdateURL is set.
+type ExternalExtension struct {
+	ExternalCRX       string `json:"external_crx,omitempty"`
+	ExternalVersion   string `json:"external_version,omitempty"`
+	ExternalUpdateURL string `json:"external_update_url,omitempty"`
+}
+
+// ExternalOption is a function that configures WriteExternalExtension.
+type ExternalOption func(*externalOptions)
+
+type externalOptions struct {
+	copyTo    string
+	updateURL string
+}
+
+// ExternalCopyTo returns an option that copies the CRX file into dir
+// and points external_crx at the copy.
+func ExternalCopyTo(dir string) ExternalOption {
+	return func(o *externalOptions) {
+		o.copyTo = dir
+	}
+}
+
+// ExternalUpdateURL returns an option that writes an external_update_url
+// entry instead of external_crx and external_version.
+func ExternalUpdateURL(updateURL string) ExternalOption {
+	return func(o *externalOptions) {
+		o.updateURL = updateURL
+	}
+}
+
+// MakeExternalExtension builds the external extension preferences for the CRX file.
+// The extension ID is taken from the CRX header and the version from the manifest.
+func MakeExternalExtension(filename string, opts ...ExternalOption) (id string, ext ExternalExtension, err error) {
+	conf := new(externalOptions)
+	for _, opt := range opts {
+		opt(conf)
+	}
+	if id, err = ID(filename); err != nil {
+		return "", ext, fmt.Errorf("crx3/external: failed to read extension id: %w", err)
+	}
+	if len(conf.updateURL) > 0 {
+		ext.ExternalUpdateURL = conf.updateURL
+		return id, ext, nil
+	}
+	manifest, err := ReadManifest(filename)
+	if err != nil {
+		return "", ext, fmt.Errorf("crx3/external: %w", err)
+	}
+	if _, err := manifest.ParsedVersion(); err != nil {
+		return "", ext, fmt.Errorf("crx3/external: %w", err)
+	}
+	crxPath := filename
+	if len(conf.copyTo) > 0 {
+		crxPath = filepath.Join(conf.copyTo, filepath.Base(filename))
+	}
+	if crxPath, err = filepath.Abs(crxPath); err != nil {
+		return "", ext, err
+	}
+	ext.ExternalCRX = crxPath
+	ext.ExternalVersion = manifest.Version
+	return id, ext, nil
+}
+
+// WriteExternalExtension writes the external extension preferences file
+// <id>.json for the CRX file into dir and returns its path.
+// With ExternalCopyTo the CRX file is copied to the target location as well.
+func WriteExternalExtension(filename string, dir string, opts ...ExternalOption) (string, error) {
+	conf := new(externalOptions)
+	for _, opt := range opts {
+		opt(conf)
+	}
+	id, ext, err := MakeExternalExtension(filename, opts...)
+	if err != nil {
+		return "", err
+	}
+	if len(conf.copyTo) > 0 && len(ext.ExternalCRX) > 0 {
+		if err := os.MkdirAll(conf.copyTo, 0755); err != nil {
+			return "", err
+		}
+		same, err := isSameFile(filename, ext.ExternalCRX)
+		if err != nil {
+			return "", err
+		}
+		// copying a file onto itself would truncate it
+		if !same {
+			if _, err := CopyFile(filename, ext.ExternalCRX); err != nil {
+				return "", fmt.Errorf("crx3/external: failed to copy %s: %w", filename, err)
+			}
+		}
+	}
+	data, err := json.MarshalIndent(ext, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	prefsFilename := filepath.Join(dir, id+".json")
+	if err := os.WriteFile(prefsFilename, append(data, '\n'), 0644); err != nil {
+		return "", err
+	}
+	return prefsFilename, nil
+}
+
+// isSameFile reports whether src and dst are the same file. A missing dst is
+// not the same file.
+func isSameFile(src, dst string) (bool, error) {
+	srcStat, err := os.Stat(src)
+	if err != nil {
+		return false, err
+	}
+	dstStat, err := os.Stat(dst)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(srcStat, dstStat), nil
+}
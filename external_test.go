@@ -0,0 +1,106 @@
+package crx3
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteExternalExtension(t *testing.T) {
+	manifest, err := ReadManifest("./testdata/dodyDol.crx")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		src     string
+		opts    func(dir string) []ExternalOption
+		assert  func(t *testing.T, dir string, ext ExternalExtension)
+		wantErr bool
+	}{
+		{
+			name: "should write external_crx and external_version",
+			src:  "./testdata/dodyDol.crx",
+			assert: func(t *testing.T, dir string, ext ExternalExtension) {
+				abs, _ := filepath.Abs("./testdata/dodyDol.crx")
+				assert.Equal(t, abs, ext.ExternalCRX)
+				assert.Equal(t, manifest.Version, ext.ExternalVersion)
+				assert.Empty(t, ext.ExternalUpdateURL)
+			},
+		},
+		{
+			name: "should copy crx to target location",
+			src:  "./testdata/dodyDol.crx",
+			opts: func(dir string) []ExternalOption {
+				return []ExternalOption{ExternalCopyTo(filepath.Join(dir, "crx"))}
+			},
+			assert: func(t *testing.T, dir string, ext ExternalExtension) {
+				assert.Equal(t, filepath.Join(dir, "crx", "dodyDol.crx"), ext.ExternalCRX)
+				assert.FileExists(t, ext.ExternalCRX)
+			},
+		},
+		{
+			name: "should write external_update_url",
+			src:  "./testdata/dodyDol.crx",
+			opts: func(string) []ExternalOption {
+				return []ExternalOption{ExternalUpdateURL(WebStoreUpdateURL)}
+			},
+			assert: func(t *testing.T, dir string, ext ExternalExtension) {
+				assert.Equal(t, WebStoreUpdateURL, ext.ExternalUpdateURL)
+				assert.Empty(t, ext.ExternalCRX)
+			},
+		},
+		{
+			name:    "should return error when file is not a crx",
+			src:     "./testdata/withkey.zip",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var opts []ExternalOption
+			if tt.opts != nil {
+				opts = tt.opts(dir)
+			}
+			filename, err := WriteExternalExtension(tt.src, dir, opts...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join(dir, "kpkcennohgffjdgaelocingbmkjnpjgc.json"), filename)
+			data, err := os.ReadFile(filename)
+			require.NoError(t, err)
+			var ext ExternalExtension
+			require.NoError(t, json.Unmarshal(data, &ext))
+			tt.assert(t, dir, ext)
+		})
+	}
+}
+
+func TestWriteExternalExtension_CopyToSourceDir(t *testing.T) {
+	orig, err := os.ReadFile("./testdata/dodyDol.crx")
+	require.NoError(t, err)
+	dir := t.TempDir()
+	src := filepath.Join(dir, "dodyDol.crx")
+	require.NoError(t, os.WriteFile(src, orig, 0644))
+
+	t.Chdir(dir)
+	for _, copyTo := range []string{dir, "."} {
+		filename, err := WriteExternalExtension("dodyDol.crx", t.TempDir(), ExternalCopyTo(copyTo))
+		require.NoError(t, err)
+		data, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		var ext ExternalExtension
+		require.NoError(t, json.Unmarshal(data, &ext))
+		assert.Equal(t, src, ext.ExternalCRX)
+
+		data, err = os.ReadFile(src)
+		require.NoError(t, err)
+		assert.Equal(t, orig, data)
+	}
+}
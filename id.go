@@ -8,6 +8,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/mediabuyerbot/go-crx3/pb"
 
@@ -24,26 +25,11 @@ func ID(filename string) (id string, err error) {
 		return id, ErrUnsupportedFileFormat
 	}
 
-	crx, err := os.ReadFile(filename)
+	_, signedData, err := readCRXHeader(filename)
 	if err != nil {
 		return id, err
 	}
 
-	var (
-		headerSize = binary.LittleEndian.Uint32(crx[8:12])
-		metaSize   = uint32(12)
-		v          = crx[metaSize : headerSize+metaSize]
-		header     pb.CrxFileHeader
-		signedData pb.SignedData
-	)
-
-	if err := proto.Unmarshal(v, &header); err != nil {
-		return id, err
-	}
-	if err := proto.Unmarshal(header.SignedHeaderData, &signedData); err != nil {
-		return id, err
-	}
-
 	return string(makeExtensionID(signedData.CrxId)), nil
 }
 
@@ -80,6 +66,47 @@ func IDFromPubKey(pubKey []byte) (string, error) {
 	return string(makeExtensionID(digest)), nil
 }
 
+// IDFromPath returns the ID Chrome assigns to an unpacked extension without
+// a manifest key when it is loaded in developer mode: the SHA-256 of the bytes
+// of its absolute directory path, mapped to the a-p alphabet. Chrome resolves
+// symbolic links before hashing, so absPath should be the real path as returned
+// by filepath.EvalSymlinks. The path is hashed as on Linux and macOS; Chrome on
+// Windows hashes UTF-16 paths and gives other IDs.
+func IDFromPath(absPath string) (string, error) {
+	if !filepath.IsAbs(absPath) {
+		return "", fmt.Errorf("crx3/id: path is not absolute: %s", absPath)
+	}
+	digest := sha256.Sum256([]byte(filepath.Clean(absPath)))
+	return string(makeExtensionID(digest[:])), nil
+}
+
+// readCRXHeader reads and decodes the header of the CRX3 file filename.
+func readCRXHeader(filename string) (*pb.CrxFileHeader, *pb.SignedData, error) {
+	crx, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(crx) < 12 {
+		return nil, nil, ErrUnsupportedFileFormat
+	}
+	var (
+		headerSize = binary.LittleEndian.Uint32(crx[8:12])
+		metaSize   = uint32(12)
+		header     pb.CrxFileHeader
+		signedData pb.SignedData
+	)
+	if uint64(headerSize)+uint64(metaSize) > uint64(len(crx)) {
+		return nil, nil, ErrUnsupportedFileFormat
+	}
+	if err := proto.Unmarshal(crx[metaSize:headerSize+metaSize], &header); err != nil {
+		return nil, nil, err
+	}
+	if err := proto.Unmarshal(header.SignedHeaderData, &signedData); err != nil {
+		return nil, nil, err
+	}
+	return &header, &signedData, nil
+}
+
 func strIDx() map[rune]int {
 	index := make(map[rune]int)
 	src := "0123456789abcdef"
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestID(t *testing.T) {
@@ -57,7 +58,7 @@ func TestIDNegative_UnmarshalSignedData(t *testing.T) {
 	buf.WriteString("Cr24")
 	_ = binary.Write(buf, binary.LittleEndian, uint32(3))
 	mockdata := []byte(`some data section`)
-	header, err := makeHeader(mockdata, mockdata, mockdata)
+	header, err := makeHeader(nil, mockdata, mockdata, mockdata)
 	assert.Nil(t, err)
 	_ = binary.Write(buf, binary.LittleEndian, uint32(len(header)))
 	buf.Write(header)
@@ -133,3 +134,66 @@ zQIDAQAB
 		})
 	}
 }
+
+func TestIDFromPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "should return developer-mode ID from absolute path",
+			path: "/home/user/my-extension",
+			want: "nilnepjhjgfacmijbmgplkmpgnbgekgi",
+		},
+		{
+			name: "should ignore trailing slash",
+			path: "/home/user/my-extension/",
+			want: "nilnepjhjgfacmijbmgplkmpgnbgekgi",
+		},
+		{
+			name:    "should return error when path is relative",
+			path:    "my-extension",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IDFromPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IDFromPath() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("IDFromPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtension_ID_PathFallback(t *testing.T) {
+	const dir = "testdata/scanroot/some_extension"
+	_, err := Extension(dir).ID()
+	require.Error(t, err)
+
+	abs, err := filepath.Abs(dir)
+	require.NoError(t, err)
+	want, err := IDFromPath(abs)
+	require.NoError(t, err)
+	got, err := Extension(dir).ID(IDWithPathFallback())
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	// a symbolic link resolves to the directory it points to
+	link := filepath.Join(t.TempDir(), "link")
+	require.NoError(t, os.Symlink(abs, link))
+	got, err = Extension(link).ID(IDWithPathFallback())
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	// the manifest key wins over the path
+	got, err = Extension("testdata/extension").ID(IDWithPathFallback())
+	require.NoError(t, err)
+	require.Equal(t, "nigbihjmcbekdlkgdceknpanajdpncle", got)
+}
@@ -0,0 +1,77 @@
+package crx3
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// IDCheck compares the extension ID derived from the manifest "key" field
+// with the ID of the key that signed the CRX file. Chrome refuses to install
+// a CRX file whose IDs differ.
+type IDCheck struct {
+	// ManifestID is derived from the manifest key. Empty if there is no key.
+	ManifestID string `json:"manifestId,omitempty"`
+	// HeaderID is the ID recorded in the CRX header. Empty for directories and ZIP archives.
+	HeaderID string `json:"headerId,omitempty"`
+}
+
+// ID returns the ID Chrome assigns: the manifest ID if there is a key,
+// otherwise the header ID.
+func (c *IDCheck) ID() string {
+	if len(c.ManifestID) > 0 {
+		return c.ManifestID
+	}
+	return c.HeaderID
+}
+
+// Consistent reports whether the IDs agree. It is true if one of them is missing.
+func (c *IDCheck) Consistent() bool {
+	return len(c.ManifestID) == 0 || len(c.HeaderID) == 0 || c.ManifestID == c.HeaderID
+}
+
+// Err returns an error wrapping ErrIDMismatch if the IDs differ.
+func (c *IDCheck) Err() error {
+	if c.Consistent() {
+		return nil
+	}
+	return fmt.Errorf("%w: manifest key gives %s, signing key gives %s", ErrIDMismatch, c.ManifestID, c.HeaderID)
+}
+
+// CheckID reads the manifest key and, for CRX3 files, the header ID of an
+// unpacked directory, a ZIP archive or a CRX3 file.
+func CheckID(filename string) (*IDCheck, error) {
+	check := &IDCheck{}
+	var err error
+	check.ManifestID, err = manifestKeyID(filename)
+	if err != nil && !(isCRX(filename) && errors.Is(err, ErrManifestNotFound)) {
+		return nil, err
+	}
+	if isCRX(filename) {
+		if check.HeaderID, err = ID(filename); err != nil {
+			return nil, err
+		}
+	}
+	if len(check.ID()) == 0 {
+		return nil, fmt.Errorf("crx3: failed to parse key from manifest file %s", filename)
+	}
+	return check, nil
+}
+
+// manifestKeyID returns the ID derived from the manifest key of an unpacked
+// directory, a ZIP archive or a CRX3 file, or an empty string if there is no key.
+func manifestKeyID(filename string) (string, error) {
+	data, err := readManifestData(filename)
+	if err != nil {
+		return "", err
+	}
+	key := strings.TrimSpace(parseKeyFromManifest(data))
+	if len(key) == 0 {
+		return "", nil
+	}
+	id, err := IDFromPubKey([]byte(key))
+	if err != nil {
+		return "", fmt.Errorf("crx3: invalid key in manifest of %s: %w", filename, err)
+	}
+	return id, nil
+}
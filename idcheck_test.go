@@ -0,0 +1,86 @@
+package crx3
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckID(t *testing.T) {
+	tests := []struct {
+		filename   string
+		manifestID string
+		headerID   string
+		consistent bool
+	}{
+		{
+			filename:   "testdata/withkey.crx",
+			manifestID: "nigbihjmcbekdlkgdceknpanajdpncle",
+			headerID:   "hpngabcaekcmpoiomblebiidejafakch",
+		},
+		{
+			filename:   "testdata/withkey.zip",
+			manifestID: "nigbihjmcbekdlkgdceknpanajdpncle",
+			consistent: true,
+		},
+		{
+			filename:   "testdata/dodyDol.crx",
+			headerID:   "kpkcennohgffjdgaelocingbmkjnpjgc",
+			consistent: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.filename), func(t *testing.T) {
+			check, err := CheckID(tt.filename)
+			require.NoError(t, err)
+			assert.Equal(t, tt.manifestID, check.ManifestID)
+			assert.Equal(t, tt.headerID, check.HeaderID)
+			assert.Equal(t, tt.consistent, check.Consistent())
+			id, err := Extension(tt.filename).ID()
+			require.NoError(t, err)
+			assert.Equal(t, id, check.ID())
+			if tt.consistent {
+				assert.NoError(t, check.Err())
+			} else {
+				assert.ErrorIs(t, check.Err(), ErrIDMismatch)
+			}
+		})
+	}
+
+	_, err := CheckID("testdata/scanroot/some_extension")
+	assert.Error(t, err)
+	_, err = CheckID("testdata/missing")
+	assert.Error(t, err)
+}
+
+func TestPack_StrictID(t *testing.T) {
+	pk, err := LoadPrivateKey("testdata/withkey.crx.pem")
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	// the manifest key of withkey.zip does not belong to withkey.crx.pem
+	dst := filepath.Join(dir, "strict.crx")
+	err = Pack("testdata/withkey.zip", dst, pk, PackStrictID())
+	assert.ErrorIs(t, err, ErrIDMismatch)
+	assert.NoFileExists(t, dst)
+
+	require.NoError(t, Pack("testdata/withkey.zip", dst, pk))
+	check, err := CheckID(dst)
+	require.NoError(t, err)
+	assert.False(t, check.Consistent())
+
+	src := filepath.Join(dir, "extension")
+	require.NoError(t, UnzipTo(src, "testdata/withkey.zip"))
+	_, err = SetManifestKey(src, pk)
+	require.NoError(t, err)
+	require.NoError(t, Pack(src, dst, pk, PackStrictID()))
+	check, err = CheckID(dst)
+	require.NoError(t, err)
+	assert.True(t, check.Consistent())
+	assert.Equal(t, "hpngabcaekcmpoiomblebiidejafakch", check.ID())
+
+	// a directory without a key packs with any key
+	require.NoError(t, Pack("testdata/scanroot/some_extension", dst, pk, PackStrictID()))
+}
@@ -0,0 +1,241 @@
+package crx3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// IndexFilename is the name of the catalog file written by SaveIndex into the
+// workspace root. It starts with a dot, so Scan skips it.
+const IndexFilename = ".crx3-index.json"
+
+const indexFormatVersion = 1
+
+// Index is a persistent catalog of the extensions found in a workspace.
+// It is stored as a single JSON file and updated incrementally by UpdateIndex.
+type Index struct {
+	FormatVersion int           `json:"formatVersion"`
+	Root          string        `json:"root"`
+	UpdatedAt     time.Time     `json:"updatedAt"`
+	Entries       []*IndexEntry `json:"entries"`
+}
+
+// IndexEntry is an extension recorded in the index together with the
+// file size and modification time used to detect changes. For unpacked
+// directories they cover all files in the directory, see UpdateIndex.
+type IndexEntry struct {
+	ExtensionInfo
+	ContentHash string    `json:"contentHash,omitempty"`
+	FileSize    int64     `json:"fileSize"`
+	FileModTime time.Time `json:"fileModTime"`
+}
+
+// IndexStats reports what UpdateIndex did.
+type IndexStats struct {
+	Added     int `json:"added"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Removed   int `json:"removed"`
+}
+
+// IndexPath returns the location of the index file of a workspace.
+func IndexPath(root string) string {
+	return filepath.Join(root, IndexFilename)
+}
+
+// LoadIndex reads an index file. It returns an error wrapping
+// ErrIndexNotFound if the file does not exist.
+func LoadIndex(filename string) (*Index, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrIndexNotFound, filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var idx Index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("crx3/index: failed to parse %s: %w", filename, err)
+	}
+	if idx.FormatVersion != indexFormatVersion {
+		return nil, fmt.Errorf("crx3/index: unsupported format version %d in %s", idx.FormatVersion, filename)
+	}
+	return &idx, nil
+}
+
+// SaveIndex writes the index to filename, replacing the previous file atomically.
+func SaveIndex(idx *Index, filename string) error {
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// UpdateIndex scans root and returns an up to date index. Extensions whose file
+// size and modification time match the entry in prev are taken over without
+// being read again; new and changed ones are read with full metadata and a
+// content hash. For unpacked directories the total size of all files and the
+// newest modification time of any file or subdirectory are compared, so
+// nested edits are detected as well. prev may be nil.
+// The scan options are applied as given; unreadable paths are skipped.
+func UpdateIndex(root string, prev *Index, opts ...ScanOption) (*Index, IndexStats, error) {
+	var stats IndexStats
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, stats, err
+	}
+	known := make(map[string]*IndexEntry)
+	if prev != nil && prev.Root == root {
+		for _, e := range prev.Entries {
+			known[e.Path] = e
+		}
+	}
+
+	idx := &Index{FormatVersion: indexFormatVersion, Root: root}
+	opts = append(slices.Clone(opts), WithContinueOnError(), WithSortedResults())
+	for info, err := range Scan(root, opts...) {
+		if err != nil {
+			var scanErr *ScanError
+			if errors.As(err, &scanErr) {
+				continue
+			}
+			return nil, stats, err
+		}
+		size, modTime, err := indexStat(info)
+		if err != nil {
+			continue
+		}
+		if e, ok := known[info.Path]; ok {
+			delete(known, info.Path)
+			if e.Type == info.Type && e.FileSize == size && e.FileModTime.Equal(modTime) {
+				idx.Entries = append(idx.Entries, e)
+				stats.Unchanged++
+				continue
+			}
+			stats.Updated++
+		} else {
+			stats.Added++
+		}
+		info.enrich()
+		hash, _ := ContentHash(info.Path)
+		idx.Entries = append(idx.Entries, &IndexEntry{
+			ExtensionInfo: *info,
+			ContentHash:   hash,
+			FileSize:      size,
+			FileModTime:   modTime,
+		})
+	}
+	stats.Removed = len(known)
+	idx.UpdatedAt = time.Now().UTC()
+	return idx, stats, nil
+}
+
+// indexStat returns the size and modification time used to detect changes.
+// For unpacked directories these are the total size of all files and the
+// newest modification time of the directory and everything below it, so an
+// edit of any nested file counts as a change.
+func indexStat(info *ExtensionInfo) (int64, time.Time, error) {
+	stat, err := os.Stat(info.Path)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if info.Type != tdir {
+		return stat.Size(), stat.ModTime().UTC(), nil
+	}
+	var (
+		size    int64
+		modTime = stat.ModTime()
+	)
+	err = filepath.WalkDir(info.Path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if fi.ModTime().After(modTime) {
+			modTime = fi.ModTime()
+		}
+		if fi.Mode().IsRegular() {
+			size += fi.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return size, modTime.UTC(), nil
+}
+
+// Query yields the index entries matching the scan filters, in index order.
+// All filters of Scan are supported except WithMaxDepth; globs are matched
+// against the path relative to the index root.
+func (idx *Index) Query(opts ...ScanOption) iter.Seq[*IndexEntry] {
+	filter := new(scanFilter)
+	for _, opt := range opts {
+		opt(filter)
+	}
+	return func(yield func(*IndexEntry) bool) {
+		var count int
+		for _, e := range idx.Entries {
+			rel := relativePath(idx.Root, e.Path)
+			if !filter.hidden && isHidden(rel) || filter.isExcluded(rel) {
+				continue
+			}
+			if len(filter.names) > 0 && !filter.matchName(filepath.Base(e.Path)) {
+				continue
+			}
+			info := e.ExtensionInfo
+			c := scanCandidate{info: &info, rel: rel, modTime: e.FileModTime}
+			if !filter.matchCandidate(c) || !filter.matchInfo(&info) {
+				continue
+			}
+			if !yield(e) {
+				return
+			}
+			count++
+			if filter.maxCount > 0 && count >= filter.maxCount {
+				return
+			}
+		}
+	}
+}
+
+// ScanIndex loads the index of the workspace root and yields the extensions
+// matching the scan filters, like Scan does for the file system.
+func ScanIndex(root string, opts ...ScanOption) iter.Seq2[*ExtensionInfo, error] {
+	return func(yield func(*ExtensionInfo, error) bool) {
+		idx, err := LoadIndex(IndexPath(root))
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for e := range idx.Query(opts...) {
+			info := e.ExtensionInfo
+			if !yield(&info, nil) {
+				return
+			}
+		}
+	}
+}
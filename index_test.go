@@ -0,0 +1,100 @@
+package crx3
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateIndex(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.CopyFS(root, os.DirFS("testdata/scanroot")))
+	filename := IndexPath(root)
+
+	_, err := LoadIndex(filename)
+	require.ErrorIs(t, err, ErrIndexNotFound)
+
+	idx, stats, err := UpdateIndex(root, nil)
+	require.NoError(t, err)
+	assert.Equal(t, IndexStats{Added: 7}, stats)
+	require.NoError(t, SaveIndex(idx, filename))
+
+	loaded, err := LoadIndex(filename)
+	require.NoError(t, err)
+	assert.Equal(t, idx.Root, loaded.Root)
+	require.Len(t, loaded.Entries, 7)
+	for _, e := range loaded.Entries {
+		assert.NotEmpty(t, e.ContentHash, e.Path)
+		assert.Equal(t, "2.1.0", e.Version)
+		assert.Equal(t, []string{"activeTab", "tabCapture"}, e.RequestedPermissions)
+	}
+
+	// touch one extension, remove one and add one; the index file itself is not picked up
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(root, "valid.crx"), later, later))
+	require.NoError(t, os.Remove(filepath.Join(root, "some_extension.zip")))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "new"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "new", manifestFilename),
+		[]byte(`{"name":"new","version":"1.0","manifest_version":2,"permissions":["debugger"]}`), 0644))
+
+	idx, stats, err = UpdateIndex(root, loaded)
+	require.NoError(t, err)
+	assert.Equal(t, IndexStats{Added: 1, Updated: 1, Unchanged: 5, Removed: 1}, stats)
+	assert.Len(t, idx.Entries, 7)
+
+	// an edit of a nested file of an unpacked directory is a change as well
+	nested := filepath.Join(root, "some_extension", "nested.js")
+	require.NoError(t, os.WriteFile(nested, []byte("a"), 0644))
+	idx, stats, err = UpdateIndex(root, idx)
+	require.NoError(t, err)
+	assert.Equal(t, IndexStats{Updated: 1, Unchanged: 6}, stats)
+	require.NoError(t, os.WriteFile(nested, []byte("ab"), 0644))
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(nested, past, past))
+	_, stats, err = UpdateIndex(root, idx)
+	require.NoError(t, err)
+	assert.Equal(t, IndexStats{Updated: 1, Unchanged: 6}, stats)
+
+	// a different root starts from scratch
+	_, stats, err = UpdateIndex(root, &Index{FormatVersion: indexFormatVersion, Root: "/elsewhere"})
+	require.NoError(t, err)
+	assert.Equal(t, 7, stats.Added)
+}
+
+func TestIndex_Query(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.CopyFS(filepath.Join(root, "store"), os.DirFS("testdata/scanroot")))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "mv2"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "mv2", manifestFilename),
+		[]byte(`{"name":"debug","version":"1.0","manifest_version":2,"permissions":["debugger"]}`), 0644))
+	idx, _, err := UpdateIndex(root, nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveIndex(idx, IndexPath(root)))
+
+	query := func(opts ...ScanOption) []string {
+		var names []string
+		for info, err := range ScanIndex(root, opts...) {
+			require.NoError(t, err)
+			names = append(names, filepath.Base(info.Path))
+		}
+		slices.Sort(names)
+		return names
+	}
+	assert.Len(t, query(), 8)
+	assert.Equal(t, []string{"mv2"}, query(WithPermissionFilter("debugger"), WithManifestVersionFilter(2)))
+	assert.Empty(t, query(WithPermissionFilter("debugger"), WithManifestVersionFilter(3)))
+	assert.Equal(t, []string{"some_extension", "some_extension.zip"}, query(WithNameFilter("some_ext")))
+	assert.Equal(t, []string{"some_extension.zip"}, query(WithIncludeGlob("store/*.zip")))
+	assert.Equal(t, []string{"mv2"}, query(WithExcludeGlob("store/*")))
+	assert.Len(t, query(WithIDFilter("kpkcennohgffjdgaelocingbmkjnpjgc")), 5)
+	assert.Len(t, query(WithMaxResults(2)), 2)
+
+	for _, err := range ScanIndex(t.TempDir()) {
+		assert.ErrorIs(t, err, ErrIndexNotFound)
+	}
+}
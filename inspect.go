@@ -0,0 +1,298 @@
+package crx3
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mediabuyerbot/go-crx3/pb"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// Proof algorithms of a CRX3 header.
+const (
+	AlgorithmRSA   = "sha256_with_rsa"
+	AlgorithmECDSA = "sha256_with_ecdsa"
+)
+
+const (
+	crxMagic       = "Cr24"
+	crxVersion     = 3
+	crxPreludeSize = 12
+	crxIDSize      = 16
+)
+
+// CRXInfo describes the structure of a CRX3 file as returned by Inspect.
+type CRXInfo struct {
+	Path       string `json:"path"`
+	FileSize   int64  `json:"fileSize"`
+	Magic      string `json:"magic"`
+	Version    uint32 `json:"version"`
+	HeaderSize uint32 `json:"headerSize"`
+	// CrxID is the extension ID recorded in the signed header data.
+	CrxID    string     `json:"crxId,omitempty"`
+	CrxIDHex string     `json:"crxIdHex,omitempty"`
+	Proofs   []KeyProof `json:"proofs"`
+	// ZipOffset and ZipSize locate the embedded ZIP archive in the file.
+	ZipOffset     int64            `json:"zipOffset"`
+	ZipSize       int64            `json:"zipSize"`
+	Entries       int              `json:"entries"`
+	Manifest      *ManifestSummary `json:"manifest,omitempty"`
+	UnknownFields []UnknownField   `json:"unknownFields,omitempty"`
+	// Problems lists the structural and signature errors found.
+	Problems []string `json:"problems,omitempty"`
+}
+
+// KeyProof describes one signature of the CRX3 header.
+type KeyProof struct {
+	Algorithm string `json:"algorithm"`
+	// KeyType is "RSA" or "ECDSA" and KeySize the key size in bits.
+	KeyType string `json:"keyType,omitempty"`
+	KeySize int    `json:"keySize,omitempty"`
+	// Fingerprint is the hex-encoded SHA-256 hash of the DER-encoded public key.
+	Fingerprint string `json:"fingerprint"`
+	// ID is the extension ID derived from the public key.
+	ID             string `json:"id"`
+	MatchesCrxID   bool   `json:"matchesCrxId"`
+	SignatureSize  int    `json:"signatureSize"`
+	SignatureValid bool   `json:"signatureValid"`
+	Error          string `json:"error,omitempty"`
+}
+
+// ManifestSummary holds the main manifest fields of an inspected extension.
+type ManifestSummary struct {
+	Name            string   `json:"name"`
+	Version         string   `json:"version"`
+	ManifestVersion int      `json:"manifestVersion"`
+	HasKey          bool     `json:"hasKey"`
+	Permissions     []string `json:"permissions,omitempty"`
+}
+
+// UnknownField is a protobuf field of the header that crx3 does not know about.
+type UnknownField struct {
+	// Message is the header message the field was found in.
+	Message  string `json:"message"`
+	Number   int32  `json:"number"`
+	WireType string `json:"wireType"`
+	Size     int    `json:"size"`
+}
+
+// OK reports whether no problems were found.
+func (info *CRXInfo) OK() bool {
+	return len(info.Problems) == 0
+}
+
+// Inspect reads the CRX3 file filename and describes its structure: the
+// prelude, every key proof, the signed header data, the embedded ZIP archive
+// and the manifest. Inspect is meant for debugging broken packages, so
+// problems found after the prelude are recorded in CRXInfo.Problems
+// instead of being returned as an error.
+func Inspect(filename string) (*CRXInfo, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < crxPreludeSize {
+		return nil, fmt.Errorf("%w: file is too short", ErrUnsupportedFileFormat)
+	}
+	info := &CRXInfo{
+		Path:       filename,
+		FileSize:   int64(len(data)),
+		Magic:      string(data[0:4]),
+		Version:    binary.LittleEndian.Uint32(data[4:8]),
+		HeaderSize: binary.LittleEndian.Uint32(data[8:12]),
+		Proofs:     []KeyProof{},
+	}
+	if info.Magic != crxMagic {
+		return nil, fmt.Errorf("%w: magic number %q", ErrUnsupportedFileFormat, info.Magic)
+	}
+	if info.Version != crxVersion {
+		info.problem("unsupported CRX version %d", info.Version)
+	}
+	headerEnd := uint64(crxPreludeSize) + uint64(info.HeaderSize)
+	if headerEnd > uint64(len(data)) {
+		info.problem("header size %d exceeds the file size", info.HeaderSize)
+		return info, nil
+	}
+	info.ZipOffset = int64(headerEnd)
+	info.ZipSize = int64(len(data)) - info.ZipOffset
+	archive := data[headerEnd:]
+
+	var header pb.CrxFileHeader
+	if err := proto.Unmarshal(data[crxPreludeSize:headerEnd], &header); err != nil {
+		info.problem("failed to decode header: %v", err)
+	} else {
+		info.inspectHeader(&header, archive)
+	}
+	info.inspectArchive(archive)
+	return info, nil
+}
+
+func (info *CRXInfo) problem(format string, args ...any) {
+	info.Problems = append(info.Problems, fmt.Sprintf(format, args...))
+}
+
+func (info *CRXInfo) inspectHeader(header *pb.CrxFileHeader, archive []byte) {
+	info.addUnknownFields("CrxFileHeader", header)
+
+	var crxID []byte
+	var signedData pb.SignedData
+	if err := proto.Unmarshal(header.SignedHeaderData, &signedData); err != nil {
+		info.problem("failed to decode signed header data: %v", err)
+	} else {
+		info.addUnknownFields("SignedData", &signedData)
+		crxID = signedData.CrxId
+		if len(crxID) == crxIDSize {
+			info.CrxID = string(makeExtensionID(crxID))
+		} else {
+			info.problem("crx_id is %d bytes long, expected %d", len(crxID), crxIDSize)
+		}
+		info.CrxIDHex = hex.EncodeToString(crxID)
+	}
+
+	message := signedMessage(header.SignedHeaderData, archive)
+	proofs := []struct {
+		algorithm string
+		proofs    []*pb.AsymmetricKeyProof
+	}{
+		{AlgorithmRSA, header.Sha256WithRsa},
+		{AlgorithmECDSA, header.Sha256WithEcdsa},
+	}
+	var matched bool
+	for _, group := range proofs {
+		for i, p := range group.proofs {
+			info.addUnknownFields(fmt.Sprintf("AsymmetricKeyProof[%s][%d]", group.algorithm, i), p)
+			proof := inspectProof(group.algorithm, p, crxID, message)
+			if proof.MatchesCrxID && proof.SignatureValid {
+				matched = true
+			}
+			if !proof.SignatureValid {
+				info.problem("%s proof %d has an invalid signature", group.algorithm, i)
+			}
+			info.Proofs = append(info.Proofs, proof)
+		}
+	}
+	switch {
+	case len(info.Proofs) == 0:
+		info.problem("header has no key proofs")
+	case !matched && len(crxID) > 0:
+		info.problem("no valid key proof matches crx_id")
+	}
+}
+
+// signedMessage returns the digest every proof signs:
+// "CRX3 SignedData\x00" + size of the signed header data + signed header data + archive.
+func signedMessage(signedHeaderData, archive []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte("CRX3 SignedData\x00"))
+	_ = binary.Write(h, binary.LittleEndian, uint32(len(signedHeaderData)))
+	h.Write(signedHeaderData)
+	h.Write(archive)
+	return h.Sum(nil)
+}
+
+func inspectProof(algorithm string, p *pb.AsymmetricKeyProof, crxID, digest []byte) KeyProof {
+	sum := sha256.Sum256(p.PublicKey)
+	proof := KeyProof{
+		Algorithm:     algorithm,
+		Fingerprint:   hex.EncodeToString(sum[:]),
+		ID:            string(makeExtensionID(sum[:])),
+		MatchesCrxID:  len(crxID) == crxIDSize && bytes.Equal(sum[:crxIDSize], crxID),
+		SignatureSize: len(p.Signature),
+	}
+	key, err := x509.ParsePKIXPublicKey(p.PublicKey)
+	if err != nil {
+		proof.Error = fmt.Sprintf("failed to parse public key: %v", err)
+		return proof
+	}
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		proof.KeyType, proof.KeySize = KeyTypeRSA, key.N.BitLen()
+		proof.SignatureValid = algorithm == AlgorithmRSA &&
+			rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, p.Signature) == nil
+	case *ecdsa.PublicKey:
+		proof.KeyType, proof.KeySize = KeyTypeECDSA, key.Curve.Params().BitSize
+		proof.SignatureValid = algorithm == AlgorithmECDSA && ecdsa.VerifyASN1(key, digest, p.Signature)
+	default:
+		proof.Error = fmt.Sprintf("unsupported public key type %T", key)
+	}
+	return proof
+}
+
+// addUnknownFields records the fields of m that are not part of the CRX3 format.
+func (info *CRXInfo) addUnknownFields(message string, m proto.Message) {
+	raw := m.ProtoReflect().GetUnknown()
+	for len(raw) > 0 {
+		num, typ, n := protowire.ConsumeField(raw)
+		if n < 0 {
+			info.problem("%s: malformed unknown field: %v", message, protowire.ParseError(n))
+			return
+		}
+		info.UnknownFields = append(info.UnknownFields, UnknownField{
+			Message:  message,
+			Number:   int32(num),
+			WireType: wireTypeName(typ),
+			Size:     n,
+		})
+		raw = raw[n:]
+	}
+}
+
+func wireTypeName(typ protowire.Type) string {
+	switch typ {
+	case protowire.VarintType:
+		return "varint"
+	case protowire.Fixed32Type:
+		return "fixed32"
+	case protowire.Fixed64Type:
+		return "fixed64"
+	case protowire.BytesType:
+		return "bytes"
+	case protowire.StartGroupType:
+		return "group"
+	}
+	return fmt.Sprintf("type %d", typ)
+}
+
+func (info *CRXInfo) inspectArchive(archive []byte) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		info.problem("failed to open zip archive: %v", err)
+		return
+	}
+	info.Entries = len(zr.File)
+	f, err := zr.Open(manifestFilename)
+	if err != nil {
+		info.problem("%s not found in zip archive", manifestFilename)
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		info.problem("failed to read %s: %v", manifestFilename, err)
+		return
+	}
+	m, err := ParseManifest(data)
+	if err != nil {
+		info.problem("%v", err)
+		return
+	}
+	info.Manifest = &ManifestSummary{
+		Name:            m.Name,
+		Version:         m.Version,
+		ManifestVersion: m.ManifestVersion,
+		HasKey:          len(m.Key) > 0,
+		Permissions:     m.AllPermissions(),
+	}
+}
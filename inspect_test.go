@@ -0,0 +1,144 @@
+package crx3
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestInspect(t *testing.T) {
+	info, err := Inspect("testdata/withkey.crx")
+	require.NoError(t, err)
+	assert.True(t, info.OK(), info.Problems)
+	assert.Equal(t, "Cr24", info.Magic)
+	assert.Equal(t, uint32(3), info.Version)
+	assert.Equal(t, int64(info.HeaderSize)+12, info.ZipOffset)
+	assert.Equal(t, info.FileSize, info.ZipOffset+info.ZipSize)
+	assert.Equal(t, "hpngabcaekcmpoiomblebiidejafakch", info.CrxID)
+	assert.Equal(t, 3, info.Entries)
+	assert.Empty(t, info.UnknownFields)
+
+	require.Len(t, info.Proofs, 1)
+	proof := info.Proofs[0]
+	assert.Equal(t, AlgorithmRSA, proof.Algorithm)
+	assert.Equal(t, "RSA", proof.KeyType)
+	assert.Equal(t, 4096, proof.KeySize)
+	assert.Equal(t, info.CrxID, proof.ID)
+	assert.True(t, proof.MatchesCrxID)
+	assert.True(t, proof.SignatureValid)
+
+	require.NotNil(t, info.Manifest)
+	assert.Equal(t, "go-crx3", info.Manifest.Name)
+	assert.True(t, info.Manifest.HasKey)
+}
+
+func TestInspect_WebStore(t *testing.T) {
+	info, err := Inspect("testdata/scanroot/valid.crx")
+	require.NoError(t, err)
+	assert.True(t, info.OK(), info.Problems)
+	require.Len(t, info.Proofs, 3)
+
+	var algorithms []string
+	var matches int
+	for _, p := range info.Proofs {
+		algorithms = append(algorithms, p.Algorithm)
+		assert.True(t, p.SignatureValid)
+		if p.MatchesCrxID {
+			matches++
+		}
+	}
+	assert.Equal(t, []string{AlgorithmRSA, AlgorithmRSA, AlgorithmECDSA}, algorithms)
+	assert.Equal(t, 1, matches)
+	assert.Equal(t, "ECDSA", info.Proofs[2].KeyType)
+	assert.Equal(t, 256, info.Proofs[2].KeySize)
+}
+
+func TestInspect_Broken(t *testing.T) {
+	crx, err := os.ReadFile("testdata/withkey.crx")
+	require.NoError(t, err)
+	headerSize := binary.LittleEndian.Uint32(crx[8:12])
+	header := crx[12 : 12+headerSize]
+	archive := crx[12+headerSize:]
+
+	build := func(header, archive []byte) []byte {
+		out := append([]byte("Cr24"), crx[4:8]...)
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(header)))
+		out = append(out, header...)
+		return append(out, archive...)
+	}
+	tampered := append([]byte(nil), archive...)
+	tampered[len(tampered)/2] ^= 0xff
+	unknown := protowire.AppendTag(append([]byte(nil), header...), 99, protowire.VarintType)
+	unknown = protowire.AppendVarint(unknown, 1)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		problems int
+		check    func(t *testing.T, info *CRXInfo)
+	}{
+		{
+			name:     "tampered archive",
+			data:     build(header, tampered),
+			problems: 2,
+			check: func(t *testing.T, info *CRXInfo) {
+				assert.False(t, info.Proofs[0].SignatureValid)
+				assert.True(t, info.Proofs[0].MatchesCrxID)
+			},
+		},
+		{
+			name: "unknown header field",
+			data: build(unknown, archive),
+			check: func(t *testing.T, info *CRXInfo) {
+				assert.Equal(t, []UnknownField{{Message: "CrxFileHeader", Number: 99, WireType: "varint", Size: 3}}, info.UnknownFields)
+				// the header is not signed, so the proof stays valid
+				assert.True(t, info.Proofs[0].SignatureValid)
+			},
+		},
+		{
+			name:     "header size exceeds file",
+			data:     build(header, nil)[:12+len(header)/2],
+			problems: 1,
+			check: func(t *testing.T, info *CRXInfo) {
+				assert.Empty(t, info.Proofs)
+			},
+		},
+		{
+			name:     "no header",
+			data:     build(nil, archive),
+			problems: 2,
+			check: func(t *testing.T, info *CRXInfo) {
+				assert.Empty(t, info.Proofs)
+				assert.Equal(t, 3, info.Entries)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "broken.crx")
+			require.NoError(t, os.WriteFile(filename, tt.data, 0644))
+			info, err := Inspect(filename)
+			require.NoError(t, err)
+			assert.Len(t, info.Problems, tt.problems, info.Problems)
+			tt.check(t, info)
+		})
+	}
+}
+
+func TestInspect_Errors(t *testing.T) {
+	_, err := Inspect("testdata/withkey.zip")
+	assert.ErrorIs(t, err, ErrUnsupportedFileFormat)
+
+	filename := filepath.Join(t.TempDir(), "short.crx")
+	require.NoError(t, os.WriteFile(filename, []byte("Cr24"), 0644))
+	_, err = Inspect(filename)
+	assert.ErrorIs(t, err, ErrUnsupportedFileFormat)
+
+	_, err = Inspect("testdata/missing.crx")
+	assert.Error(t, err)
+}
@@ -0,0 +1,215 @@
+package crx3
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SumFilename is the name of the file with the SHA-256 digest of every
+// extension file that Unpack and UnpackTo write next to the crx3.id marker.
+// It uses the sha256sum format, so it can be checked with "sha256sum -c" too.
+const SumFilename = "crx3.sum"
+
+// FileDigests maps slash-separated file paths to hex-encoded SHA-256 digests.
+type FileDigests map[string]string
+
+// Digests returns the SHA-256 digest of every file of an extension in an
+// unpacked directory, a ZIP archive or a CRX3 file. The crx3.id and
+// crx3.sum files written by Unpack are not part of the extension and are skipped.
+func Digests(path string) (FileDigests, error) {
+	digests := make(FileDigests)
+	err := walkExtensionFiles(path, func(name string, r io.Reader) (err error) {
+		digests[name], err = digest(r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return digests, nil
+}
+
+func digestZip(zr *zip.Reader) (FileDigests, error) {
+	digests := make(FileDigests)
+	err := walkZipFiles(zr, func(name string, r io.Reader) (err error) {
+		digests[name], err = digest(r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return digests, nil
+}
+
+func digest(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// walkExtensionFiles calls fn with the slash-separated name and the content of
+// every regular file of an extension in an unpacked directory, a ZIP archive
+// or a CRX3 file. The crx3.id and crx3.sum files are skipped.
+func walkExtensionFiles(path string, fn func(name string, r io.Reader) error) error {
+	switch {
+	case isDir(path):
+		return filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			name := filepath.ToSlash(relativePath(path, p))
+			if isMarkerFile(name) {
+				return nil
+			}
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if err := fn(name, f); err != nil {
+				return fmt.Errorf("crx3: failed to read %s: %w", p, err)
+			}
+			return nil
+		})
+	case isZip(path), isCRX(path):
+		r, err := zip.OpenReader(path)
+		if err != nil {
+			return fmt.Errorf("crx3: failed to open zip reader: %w", err)
+		}
+		defer r.Close()
+		return walkZipFiles(&r.Reader, fn)
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownFileExtension, path)
+}
+
+func walkZipFiles(zr *zip.Reader, fn func(name string, r io.Reader) error) error {
+	for _, f := range zr.File {
+		name := strings.TrimPrefix(f.Name, "./")
+		if f.FileInfo().IsDir() || isMarkerFile(name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("crx3: failed to open file %s: %w", f.Name, err)
+		}
+		err = fn(name, rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("crx3: failed to read %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func isMarkerFile(name string) bool {
+	return name == extensionID || name == SumFilename
+}
+
+// WriteSumFile writes the digests to dir/crx3.sum, one "<digest>  <path>" line per file.
+func WriteSumFile(dir string, digests FileDigests) error {
+	var buf bytes.Buffer
+	for _, name := range slices.Sorted(maps.Keys(digests)) {
+		fmt.Fprintf(&buf, "%s  %s\n", digests[name], name)
+	}
+	return os.WriteFile(filepath.Join(dir, SumFilename), buf.Bytes(), 0644)
+}
+
+// ReadSumFile reads the digests recorded in dir/crx3.sum.
+func ReadSumFile(dir string) (FileDigests, error) {
+	data, err := os.ReadFile(filepath.Join(dir, SumFilename))
+	if err != nil {
+		return nil, err
+	}
+	digests := make(FileDigests)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if len(text) == 0 {
+			continue
+		}
+		sum, name, ok := strings.Cut(text, "  ")
+		if !ok || len(sum) != sha256.Size*2 {
+			return nil, fmt.Errorf("crx3: invalid %s line %d", SumFilename, line)
+		}
+		digests[name] = sum
+	}
+	return digests, scanner.Err()
+}
+
+// IntegrityReport lists the differences between an unpacked extension and its reference.
+type IntegrityReport struct {
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+// OK reports whether no differences were found.
+func (r *IntegrityReport) OK() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Modified) == 0
+}
+
+// CompareDigests reports the files added, removed and modified in actual
+// compared to expected. Paths are sorted.
+func CompareDigests(expected, actual FileDigests) *IntegrityReport {
+	report := &IntegrityReport{Added: []string{}, Removed: []string{}, Modified: []string{}}
+	for _, name := range slices.Sorted(maps.Keys(actual)) {
+		sum, ok := expected[name]
+		switch {
+		case !ok:
+			report.Added = append(report.Added, name)
+		case sum != actual[name]:
+			report.Modified = append(report.Modified, name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(expected)) {
+		if _, ok := actual[name]; !ok {
+			report.Removed = append(report.Removed, name)
+		}
+	}
+	return report
+}
+
+// CheckIntegrity compares the files of the unpacked extension in dir with a
+// reference. If reference is empty the digests recorded in dir/crx3.sum are
+// used, otherwise reference is the original CRX or ZIP file.
+func CheckIntegrity(dir string, reference string) (*IntegrityReport, error) {
+	if !isDir(dir) {
+		return nil, fmt.Errorf("%w: %s", ErrPathNotFound, dir)
+	}
+	var (
+		expected FileDigests
+		err      error
+	)
+	if len(reference) > 0 {
+		expected, err = Digests(reference)
+	} else {
+		expected, err = ReadSumFile(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("crx3: %s not found in %s, pass the original extension to compare with", SumFilename, dir)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	actual, err := Digests(dir)
+	if err != nil {
+		return nil, err
+	}
+	return CompareDigests(expected, actual), nil
+}
@@ -0,0 +1,77 @@
+package crx3
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnpackTo_WritesSumFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, UnpackTo("testdata/scanroot/valid.crx", dir, UnpackDisableSubdir()))
+
+	recorded, err := ReadSumFile(dir)
+	require.NoError(t, err)
+	expected, err := Digests("testdata/scanroot/valid.crx")
+	require.NoError(t, err)
+	assert.Equal(t, expected, recorded)
+	assert.NotContains(t, recorded, extensionID)
+	assert.Contains(t, recorded, manifestFilename)
+
+	report, err := CheckIntegrity(dir, "")
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	const crx = "testdata/scanroot/valid.crx"
+	dir := t.TempDir()
+	require.NoError(t, UnpackTo(crx, dir, UnpackDisableSubdir()))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, manifestFilename), []byte(`{}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "img", "extra.svg"), []byte(`<svg/>`), 0644))
+	require.NoError(t, os.Remove(filepath.Join(dir, "popup.html")))
+
+	want := &IntegrityReport{
+		Added:    []string{"img/extra.svg"},
+		Removed:  []string{"popup.html"},
+		Modified: []string{manifestFilename},
+	}
+	for _, reference := range []string{"", crx} {
+		report, err := CheckIntegrity(dir, reference)
+		require.NoError(t, err)
+		assert.False(t, report.OK())
+		assert.Equal(t, want, report)
+	}
+
+	require.NoError(t, os.Remove(filepath.Join(dir, SumFilename)))
+	_, err := CheckIntegrity(dir, "")
+	assert.Error(t, err)
+
+	_, err = CheckIntegrity(filepath.Join(dir, "missing"), crx)
+	assert.ErrorIs(t, err, ErrPathNotFound)
+}
+
+func TestReadSumFile(t *testing.T) {
+	dir := t.TempDir()
+	digests := FileDigests{
+		"a.js":         "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb",
+		"dir/name.txt": "3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d",
+	}
+	require.NoError(t, WriteSumFile(dir, digests))
+	data, err := os.ReadFile(filepath.Join(dir, SumFilename))
+	require.NoError(t, err)
+	assert.Equal(t, "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb  a.js\n"+
+		"3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d  dir/name.txt\n", string(data))
+
+	read, err := ReadSumFile(dir)
+	require.NoError(t, err)
+	assert.Equal(t, digests, read)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SumFilename), []byte("garbage\n"), 0644))
+	_, err = ReadSumFile(dir)
+	assert.Error(t, err)
+}
@@ -0,0 +1,260 @@
+package crx3
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// jsonObject is a JSON object that keeps the order of its keys, so edited
+// manifests keep the layout their authors chose.
+type jsonObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func (o *jsonObject) get(key string) (any, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+func (o *jsonObject) set(key string, value any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *jsonObject) delete(key string) bool {
+	if _, ok := o.values[key]; !ok {
+		return false
+	}
+	delete(o.values, key)
+	o.keys = slices.DeleteFunc(o.keys, func(k string) bool { return k == key })
+	return true
+}
+
+// MarshalJSON writes the keys in their original order without escaping HTML characters.
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := marshalJSON(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := marshalJSON(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// decodeOrderedJSON decodes a JSON document into *jsonObject, []any, string,
+// json.Number, bool and nil values.
+func decodeOrderedJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("unexpected data after the JSON document")
+	}
+	return v, nil
+}
+
+func decodeJSONValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := &jsonObject{values: make(map[string]any)}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := tok.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid object key %v", tok)
+			}
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(key, value)
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		_, err := dec.Token()
+		return arr, err
+	}
+	return tok, nil
+}
+
+// toOrderedJSON converts a Go value into the representation of decodeOrderedJSON.
+func toOrderedJSON(v any) (any, error) {
+	data, err := marshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeOrderedJSON(data)
+}
+
+// splitJSONPath splits a dot-separated path like "content_scripts.0.matches".
+func splitJSONPath(path string) ([]string, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("empty JSON path")
+	}
+	parts := strings.Split(path, ".")
+	if slices.Contains(parts, "") {
+		return nil, fmt.Errorf("%s: invalid JSON path", path)
+	}
+	return parts, nil
+}
+
+// setJSONPath sets the value at path in root and returns the new root.
+// Missing objects on the way are created. Array elements are addressed by
+// index; the index "-" appends to an array.
+func setJSONPath(root any, path string, value any) (any, error) {
+	parts, err := splitJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return setJSONValue(root, parts, value, path)
+}
+
+func setJSONValue(node any, parts []string, value any, path string) (any, error) {
+	if len(parts) == 0 {
+		return value, nil
+	}
+	key := parts[0]
+	switch n := node.(type) {
+	case nil:
+		obj := &jsonObject{values: make(map[string]any)}
+		child, err := setJSONValue(nil, parts[1:], value, path)
+		if err != nil {
+			return nil, err
+		}
+		obj.set(key, child)
+		return obj, nil
+	case *jsonObject:
+		current, _ := n.get(key)
+		child, err := setJSONValue(current, parts[1:], value, path)
+		if err != nil {
+			return nil, err
+		}
+		n.set(key, child)
+		return n, nil
+	case []any:
+		if key == "-" {
+			child, err := setJSONValue(nil, parts[1:], value, path)
+			if err != nil {
+				return nil, err
+			}
+			return append(n, child), nil
+		}
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(n) {
+			return nil, fmt.Errorf("%s: index %q out of range", path, key)
+		}
+		child, err := setJSONValue(n[i], parts[1:], value, path)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = child
+		return n, nil
+	}
+	return nil, fmt.Errorf("%s: %q is not an object or array", path, key)
+}
+
+// deleteJSONPath removes the value at path from root and returns the new root.
+func deleteJSONPath(root any, path string) (any, error) {
+	parts, err := splitJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return deleteJSONValue(root, parts, path)
+}
+
+func deleteJSONValue(node any, parts []string, path string) (any, error) {
+	key, last := parts[0], len(parts) == 1
+	switch n := node.(type) {
+	case *jsonObject:
+		if last {
+			if !n.delete(key) {
+				return nil, fmt.Errorf("%s: not found", path)
+			}
+			return n, nil
+		}
+		child, ok := n.get(key)
+		if !ok {
+			return nil, fmt.Errorf("%s: not found", path)
+		}
+		child, err := deleteJSONValue(child, parts[1:], path)
+		if err != nil {
+			return nil, err
+		}
+		n.set(key, child)
+		return n, nil
+	case []any:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(n) {
+			return nil, fmt.Errorf("%s: index %q out of range", path, key)
+		}
+		if last {
+			return slices.Delete(n, i, i+1), nil
+		}
+		child, err := deleteJSONValue(n[i], parts[1:], path)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = child
+		return n, nil
+	}
+	return nil, fmt.Errorf("%s: not found", path)
+}
+
+// newIndentEncoder returns an encoder for manifest files: two-space indent, no HTML escaping.
+func newIndentEncoder(w io.Writer) *json.Encoder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc
+}
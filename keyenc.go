@@ -0,0 +1,261 @@
+package crx3
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Key derivation functions for encrypted private keys.
+const (
+	KDFScrypt = "scrypt"
+	KDFPBKDF2 = "pbkdf2"
+)
+
+// The scrypt cost matches the OpenSSL default; OpenSSL refuses keys whose
+// parameters need more than 32 MiB of memory.
+const (
+	scryptN          = 1 << 14
+	scryptR          = 8
+	scryptP          = 1
+	pbkdf2Iterations = 600000
+	pbesSaltSize     = 16
+)
+
+var (
+	oidPBES2      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidScrypt     = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11591, 4, 11}
+	oidHMACSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// encryptedPrivateKeyInfo is the PKCS#8 EncryptedPrivateKeyInfo structure (RFC 5208).
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// pbes2Params are the PBES2 parameters (RFC 8018).
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// scryptParams are the scrypt parameters (RFC 7914).
+type scryptParams struct {
+	Salt                     []byte
+	CostParameter            int
+	BlockSize                int
+	ParallelizationParameter int
+	KeyLength                int `asn1:"optional"`
+}
+
+// encryptPKCS8 encrypts the PKCS#8 DER key with AES-256-CBC and a key derived
+// from passphrase by kdf and returns the DER EncryptedPrivateKeyInfo.
+func encryptPKCS8(der []byte, passphrase []byte, kdf string) ([]byte, error) {
+	salt := make([]byte, pbesSaltSize)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	const keyLen = 32
+	var (
+		key    []byte
+		kdfAlg pkix.AlgorithmIdentifier
+		params []byte
+		err    error
+	)
+	switch kdf {
+	case KDFScrypt, "":
+		if key, err = scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, keyLen); err != nil {
+			return nil, err
+		}
+		params, err = asn1.Marshal(scryptParams{
+			Salt:                     salt,
+			CostParameter:            scryptN,
+			BlockSize:                scryptR,
+			ParallelizationParameter: scryptP,
+			KeyLength:                keyLen,
+		})
+		kdfAlg.Algorithm = oidScrypt
+	case KDFPBKDF2:
+		if key, err = pbkdf2.Key(sha256.New, string(passphrase), salt, pbkdf2Iterations, keyLen); err != nil {
+			return nil, err
+		}
+		params, err = asn1.Marshal(pbkdf2Params{
+			Salt:           salt,
+			IterationCount: pbkdf2Iterations,
+			KeyLength:      keyLen,
+			PRF:            pkix.AlgorithmIdentifier{Algorithm: oidHMACSHA256, Parameters: asn1.NullRawValue},
+		})
+		kdfAlg.Algorithm = oidPBKDF2
+	default:
+		return nil, fmt.Errorf("crx3/keys: unsupported key derivation function %q", kdf)
+	}
+	if err != nil {
+		return nil, err
+	}
+	kdfAlg.Parameters = asn1.RawValue{FullBytes: params}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	padding := aes.BlockSize - len(der)%aes.BlockSize
+	data := make([]byte, len(der), len(der)+padding)
+	copy(data, der)
+	for range padding {
+		data = append(data, byte(padding))
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+
+	ivParam, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	pbes2, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: kdfAlg,
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParam}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: pbes2}},
+		EncryptedData: data,
+	})
+}
+
+// decryptPKCS8 decrypts a DER EncryptedPrivateKeyInfo using PBES2 with scrypt
+// or PBKDF2 and AES-CBC and returns the PKCS#8 DER key.
+func decryptPKCS8(der []byte, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if err := unmarshalDER(der, &info); err != nil {
+		return nil, fmt.Errorf("crx3/keys: invalid encrypted private key: %w", err)
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("crx3/keys: unsupported encryption algorithm %s, only PBES2 is supported", info.Algorithm.Algorithm)
+	}
+	var params pbes2Params
+	if err := unmarshalDER(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("crx3/keys: invalid PBES2 parameters: %w", err)
+	}
+
+	var keyLen int
+	switch alg := params.EncryptionScheme.Algorithm; {
+	case alg.Equal(oidAES128CBC):
+		keyLen = 16
+	case alg.Equal(oidAES192CBC):
+		keyLen = 24
+	case alg.Equal(oidAES256CBC):
+		keyLen = 32
+	default:
+		return nil, fmt.Errorf("crx3/keys: unsupported cipher %s", alg)
+	}
+	var iv []byte
+	if err := unmarshalDER(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil || len(iv) != aes.BlockSize {
+		return nil, errors.New("crx3/keys: invalid AES-CBC IV")
+	}
+
+	key, err := deriveKey(params.KeyDerivationFunc, passphrase, keyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	data := info.EncryptedData
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("crx3/keys: invalid encrypted data length")
+	}
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+	padding := int(out[len(out)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, ErrIncorrectPassphrase
+	}
+	for _, b := range out[len(out)-padding:] {
+		if int(b) != padding {
+			return nil, ErrIncorrectPassphrase
+		}
+	}
+	return out[:len(out)-padding], nil
+}
+
+func deriveKey(kdf pkix.AlgorithmIdentifier, passphrase []byte, keyLen int) ([]byte, error) {
+	switch {
+	case kdf.Algorithm.Equal(oidScrypt):
+		var p scryptParams
+		if err := unmarshalDER(kdf.Parameters.FullBytes, &p); err != nil {
+			return nil, fmt.Errorf("crx3/keys: invalid scrypt parameters: %w", err)
+		}
+		if p.KeyLength != 0 && p.KeyLength != keyLen {
+			return nil, fmt.Errorf("crx3/keys: scrypt key length %d does not match the cipher", p.KeyLength)
+		}
+		return scrypt.Key(passphrase, p.Salt, p.CostParameter, p.BlockSize, p.ParallelizationParameter, keyLen)
+	case kdf.Algorithm.Equal(oidPBKDF2):
+		var p pbkdf2Params
+		if err := unmarshalDER(kdf.Parameters.FullBytes, &p); err != nil {
+			return nil, fmt.Errorf("crx3/keys: invalid PBKDF2 parameters: %w", err)
+		}
+		if p.KeyLength != 0 && p.KeyLength != keyLen {
+			return nil, fmt.Errorf("crx3/keys: PBKDF2 key length %d does not match the cipher", p.KeyLength)
+		}
+		var h func() hash.Hash
+		switch prf := p.PRF.Algorithm; {
+		case len(prf) == 0, prf.Equal(oidHMACSHA1):
+			h = sha1.New
+		case prf.Equal(oidHMACSHA256):
+			h = sha256.New
+		case prf.Equal(oidHMACSHA384):
+			h = sha512.New384
+		case prf.Equal(oidHMACSHA512):
+			h = sha512.New
+		default:
+			return nil, fmt.Errorf("crx3/keys: unsupported PBKDF2 PRF %s", prf)
+		}
+		return pbkdf2.Key(h, string(passphrase), p.Salt, p.IterationCount, keyLen)
+	}
+	return nil, fmt.Errorf("crx3/keys: unsupported key derivation function %s", kdf.Algorithm)
+}
+
+// unmarshalDER decodes der into v and rejects trailing data.
+func unmarshalDER(der []byte, v any) error {
+	rest, err := asn1.Unmarshal(der, v)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return errors.New("trailing data")
+	}
+	return nil
+}
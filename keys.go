@@ -1,13 +1,66 @@
 package crx3
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"fmt"
 	"os"
+	"strings"
 )
 
+const (
+	pemTypeRSAPrivateKey       = "RSA PRIVATE KEY"
+	pemTypePrivateKey          = "PRIVATE KEY"
+	pemTypeECPrivateKey        = "EC PRIVATE KEY"
+	pemTypeEncryptedPrivateKey = "ENCRYPTED PRIVATE KEY"
+)
+
+// Private key types.
+const (
+	KeyTypeRSA   = "RSA"
+	KeyTypeECDSA = "ECDSA"
+)
+
+// KeyOption configures how private keys are written and read.
+type KeyOption func(*keyOptions)
+
+// keyOptions holds configuration for writing and reading private keys.
+type keyOptions struct {
+	passphrase []byte
+	kdf        string
+}
+
+// WithPassphrase returns a KeyOption that encrypts written private keys with
+// passphrase and decrypts encrypted private keys when they are read. Keys are
+// encrypted as PKCS#8 "ENCRYPTED PRIVATE KEY" with PBES2 and AES-256-CBC.
+func WithPassphrase(passphrase []byte) KeyOption {
+	return func(o *keyOptions) {
+		o.passphrase = passphrase
+	}
+}
+
+// WithKDF returns a KeyOption that selects the function deriving the
+// encryption key from the passphrase: KDFScrypt (the default) or KDFPBKDF2
+// with HMAC-SHA256.
+func WithKDF(kdf string) KeyOption {
+	return func(o *keyOptions) {
+		o.kdf = kdf
+	}
+}
+
+func newKeyOptions(opts []KeyOption) *keyOptions {
+	conf := new(keyOptions)
+	for _, opt := range opts {
+		opt(conf)
+	}
+	return conf
+}
+
 var defaultKeySize = 2048
 
 // SetDefaultKeySize sets the global default key size for RSA key generation.
@@ -46,45 +99,168 @@ func NewPrivateKey() (*rsa.PrivateKey, error) {
 	return rsa.GenerateKey(rand.Reader, defaultKeySize)
 }
 
+// NewECDSAPrivateKey returns a new ECDSA private key on the P-256 curve.
+// ECDSA keys are much faster to generate than RSA keys.
+func NewECDSAPrivateKey() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+// NewKey returns a new private key of keyType: KeyTypeRSA with the default
+// key size or KeyTypeECDSA. The key type is case-insensitive.
+func NewKey(keyType string) (crypto.Signer, error) {
+	switch strings.ToUpper(keyType) {
+	case KeyTypeRSA:
+		return NewPrivateKey()
+	case KeyTypeECDSA:
+		return NewECDSAPrivateKey()
+	}
+	return nil, fmt.Errorf("%w: key type %q", ErrUnsupportedKeyType, keyType)
+}
+
 // SavePrivateKey saves the provided 'key' private key to the specified 'filename'.
 // If 'key' is nil, it generates a new private key and saves it to the file.
-func SavePrivateKey(filename string, key *rsa.PrivateKey) error {
+// With WithPassphrase the key is encrypted. The file is only readable by its
+// owner, see WriteKeyFile.
+func SavePrivateKey(filename string, key *rsa.PrivateKey, opts ...KeyOption) error {
 	if key == nil {
 		key, _ = NewPrivateKey()
 	}
-	fd, err := os.Create(filename)
+	return SaveKey(filename, key, opts...)
+}
+
+// SaveKey saves an RSA or ECDSA private key to filename like SavePrivateKey.
+func SaveKey(filename string, key crypto.Signer, opts ...KeyOption) error {
+	data, err := MarshalPrivateKeyPEM(key, opts...)
 	if err != nil {
 		return err
 	}
-	defer fd.Close()
-	bytes, err := x509.MarshalPKCS8PrivateKey(key)
+	return WriteKeyFile(filename, data)
+}
+
+// WriteKeyFile writes the PEM data of a private key to filename with the
+// permissions 0600, so only the owner can read it. An existing file is
+// truncated and its permissions are changed before the key is written.
+func WriteKeyFile(filename string, data []byte) error {
+	fd, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return err
 	}
-	block := &pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: bytes,
+	if err := fd.Chmod(0600); err != nil {
+		fd.Close()
+		return err
 	}
-	_, err = fd.Write(pem.EncodeToMemory(block))
-	return err
+	if _, err := fd.Write(data); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
+// MarshalPrivateKeyPEM encodes an RSA or ECDSA private key as PKCS#8 PEM.
+// With WithPassphrase the key is encrypted as "ENCRYPTED PRIVATE KEY".
+// Unencrypted RSA keys keep the "RSA PRIVATE KEY" label written by earlier versions.
+func MarshalPrivateKeyPEM(key crypto.PrivateKey, opts ...KeyOption) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("crx3/keys: failed to marshal private key: %w", err)
+	}
+	conf := newKeyOptions(opts)
+	if len(conf.passphrase) > 0 {
+		if der, err = encryptPKCS8(der, conf.passphrase, conf.kdf); err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: pemTypeEncryptedPrivateKey, Bytes: der}), nil
+	}
+	blockType := pemTypePrivateKey
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		blockType = pemTypeRSAPrivateKey
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), nil
 }
 
 // LoadPrivateKey loads the RSA private key from the specified 'filename' into memory.
 // It returns the loaded private key or an error if the key cannot be loaded.
-func LoadPrivateKey(filename string) (*rsa.PrivateKey, error) {
-	buf, err := os.ReadFile(filename)
+// PKCS#8, encrypted PKCS#8 and PKCS#1 keys are supported; see ParsePrivateKeyPEM.
+func LoadPrivateKey(filename string, opts ...KeyOption) (*rsa.PrivateKey, error) {
+	key, err := LoadKey(filename, opts...)
 	if err != nil {
 		return nil, err
 	}
-	block, _ := pem.Decode(buf)
-	if block == nil {
-		return nil, ErrPrivateKeyNotFound
+	pk, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s holds a %T, not an RSA key", ErrUnsupportedKeyType, filename, key)
 	}
-	r, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	return pk, nil
+}
+
+// LoadKey loads an RSA or ECDSA private key from filename; see ParsePrivateKeyPEM.
+func LoadKey(filename string, opts ...KeyOption) (crypto.Signer, error) {
+	buf, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	return r.(*rsa.PrivateKey), nil
+	key, err := ParsePrivateKeyPEM(buf, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%s)", err, filename)
+	}
+	return key, nil
+}
+
+// ParsePrivateKeyPEM parses the first private key in PEM data. It reads PKCS#8
+// ("PRIVATE KEY"), PKCS#1 ("RSA PRIVATE KEY") and SEC1 ("EC PRIVATE KEY")
+// keys whatever their label says, and PKCS#8 "ENCRYPTED PRIVATE KEY" blocks
+// encrypted with PBES2, scrypt or PBKDF2 and AES-CBC. An encrypted key
+// without WithPassphrase returns ErrPassphraseRequired, a wrong passphrase
+// ErrIncorrectPassphrase.
+func ParsePrivateKeyPEM(data []byte, opts ...KeyOption) (crypto.Signer, error) {
+	conf := newKeyOptions(opts)
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, ErrPrivateKeyNotFound
+		}
+		switch block.Type {
+		case pemTypeEncryptedPrivateKey:
+			if len(conf.passphrase) == 0 {
+				return nil, ErrPassphraseRequired
+			}
+			der, err := decryptPKCS8(block.Bytes, conf.passphrase)
+			if err != nil {
+				return nil, err
+			}
+			key, err := parsePrivateKeyDER(der)
+			if err != nil {
+				// garbage decrypted by a wrong key may still be correctly padded
+				return nil, ErrIncorrectPassphrase
+			}
+			return key, nil
+		case pemTypePrivateKey, pemTypeRSAPrivateKey, pemTypeECPrivateKey:
+			if _, ok := block.Headers["DEK-Info"]; ok {
+				return nil, fmt.Errorf("%w: legacy encrypted PEM, convert it with 'openssl pkcs8 -topk8'", ErrUnsupportedKeyType)
+			}
+			return parsePrivateKeyDER(block.Bytes)
+		}
+	}
+}
+
+func parsePrivateKeyDER(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			return k, nil
+		case *ecdsa.PrivateKey:
+			return k, nil
+		}
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedKeyType, key)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: not a PKCS#8, PKCS#1 or SEC1 private key", ErrUnsupportedKeyType)
 }
 
 // PublicKeyToPEM converts the provided RSA public key to a PEM-encoded byte slice.
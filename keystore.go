@@ -0,0 +1,278 @@
+package crx3
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// KeyStoreEnv is the environment variable that overrides the default key store directory.
+const KeyStoreEnv = "CRX3_KEYSTORE"
+
+// Key sources reported by KeyStore.Find.
+const (
+	KeySourceManifest = "manifest"
+	KeySourceProof    = "proof"
+)
+
+// KeyStore is a directory of private keys named after the extension ID they
+// give, <id>.pem. Keys are stored as they were imported, so encrypted keys
+// stay encrypted and the ID of a stored key is known without a passphrase.
+type KeyStore struct {
+	dir string
+}
+
+// StoredKey describes a private key in a KeyStore.
+type StoredKey struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+	// KeyType is "RSA" or "ECDSA" and KeySize the key size in bits.
+	// Both are empty for encrypted keys.
+	KeyType   string    `json:"keyType,omitempty"`
+	KeySize   int       `json:"keySize,omitempty"`
+	Encrypted bool      `json:"encrypted"`
+	Modified  time.Time `json:"modified"`
+}
+
+// KeyMatch is a stored key that signed a CRX3 file or matches a manifest key.
+type KeyMatch struct {
+	// Source is KeySourceProof for a CRX3 header proof or KeySourceManifest for the manifest key.
+	Source string    `json:"source"`
+	ID     string    `json:"id"`
+	Key    StoredKey `json:"key"`
+}
+
+// DefaultKeyStoreDir returns the directory of the default key store:
+// $CRX3_KEYSTORE if set, otherwise crx3/keys in the user configuration
+// directory, e.g. ~/.config/crx3/keys on Linux.
+func DefaultKeyStoreDir() (string, error) {
+	if dir := os.Getenv(KeyStoreEnv); len(dir) > 0 {
+		return dir, nil
+	}
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("crx3/keystore: %w", err)
+	}
+	return filepath.Join(config, "crx3", "keys"), nil
+}
+
+// OpenKeyStore opens the key store in dir, creating the directory if needed.
+// An empty dir opens the default key store.
+func OpenKeyStore(dir string) (*KeyStore, error) {
+	if len(dir) == 0 {
+		var err error
+		if dir, err = DefaultKeyStoreDir(); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("crx3/keystore: %w", err)
+	}
+	return &KeyStore{dir: dir}, nil
+}
+
+// Dir returns the directory of the key store.
+func (s *KeyStore) Dir() string {
+	return s.dir
+}
+
+func (s *KeyStore) path(id string) string {
+	return filepath.Join(s.dir, id+pemExt)
+}
+
+// KeyID returns the extension ID given by the public key of a private key.
+func KeyID(key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return "", fmt.Errorf("crx3/keystore: failed to marshal public key: %w", err)
+	}
+	return string(makeExtensionID(makeCRXID(der))), nil
+}
+
+// Add stores key, encrypted with WithPassphrase if given, and returns it.
+// It fails with an error wrapping os.ErrExist if a key with the same ID is stored.
+func (s *KeyStore) Add(key crypto.Signer, opts ...KeyOption) (*StoredKey, error) {
+	id, err := KeyID(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := MarshalPrivateKeyPEM(key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return s.write(id, data)
+}
+
+// Import stores the private key of the PEM file filename under the ID it
+// gives. The file is copied as is; an encrypted key needs WithPassphrase to
+// compute its ID and stays encrypted. It fails with an error wrapping
+// os.ErrExist if a key with the same ID is stored.
+func (s *KeyStore) Import(filename string, opts ...KeyOption) (*StoredKey, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParsePrivateKeyPEM(data, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%s)", err, filename)
+	}
+	id, err := KeyID(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.write(id, data)
+}
+
+func (s *KeyStore) write(id string, data []byte) (*StoredKey, error) {
+	f, err := os.OpenFile(s.path(id), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("crx3/keystore: key %s: %w", id, os.ErrExist)
+		}
+		return nil, err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return s.Get(id)
+}
+
+// Get returns the stored key id. It returns an error wrapping ErrKeyNotFound if there is no such key.
+func (s *KeyStore) Get(id string) (*StoredKey, error) {
+	if !IsValidExtensionID(id) {
+		return nil, fmt.Errorf("%w: invalid extension ID %q", ErrKeyNotFound, id)
+	}
+	filename := s.path(id)
+	st, err := os.Stat(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	key := &StoredKey{ID: id, Path: filename, Modified: st.ModTime()}
+	signer, err := ParsePrivateKeyPEM(data)
+	switch {
+	case errors.Is(err, ErrPassphraseRequired):
+		key.Encrypted = true
+	case err != nil:
+		return nil, fmt.Errorf("crx3/keystore: %s: %w", filename, err)
+	default:
+		switch k := signer.(type) {
+		case *rsa.PrivateKey:
+			key.KeyType, key.KeySize = KeyTypeRSA, k.N.BitLen()
+		case *ecdsa.PrivateKey:
+			key.KeyType, key.KeySize = KeyTypeECDSA, k.Curve.Params().BitSize
+		}
+	}
+	return key, nil
+}
+
+// List returns the stored keys sorted by ID. Files that are not named after
+// an extension ID are ignored.
+func (s *KeyStore) List() ([]StoredKey, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("crx3/keystore: %w", err)
+	}
+	keys := []StoredKey{}
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), pemExt)
+		if !ok || e.IsDir() || !IsValidExtensionID(id) {
+			continue
+		}
+		key, err := s.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	slices.SortFunc(keys, func(a, b StoredKey) int { return strings.Compare(a.ID, b.ID) })
+	return keys, nil
+}
+
+// Export returns the PEM data of the stored key id as it was stored.
+func (s *KeyStore) Export(id string) ([]byte, error) {
+	key, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(key.Path)
+}
+
+// Load returns the stored key id, decrypted with WithPassphrase if needed.
+func (s *KeyStore) Load(id string, opts ...KeyOption) (crypto.Signer, error) {
+	key, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return LoadKey(key.Path, opts...)
+}
+
+// LoadPrivateKey returns the stored RSA key id, decrypted with WithPassphrase if needed.
+func (s *KeyStore) LoadPrivateKey(id string, opts ...KeyOption) (*rsa.PrivateKey, error) {
+	key, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return LoadPrivateKey(key.Path, opts...)
+}
+
+// Remove deletes the stored key id.
+func (s *KeyStore) Remove(id string) error {
+	key, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	return os.Remove(key.Path)
+}
+
+// Find returns the stored keys that signed the CRX3 file filename or match
+// the manifest key of a CRX3 file, ZIP archive or unpacked directory.
+func (s *KeyStore) Find(filename string) ([]KeyMatch, error) {
+	type candidate struct{ source, id string }
+	var candidates []candidate
+	if isCRX(filename) {
+		info, err := Inspect(filename)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range info.Proofs {
+			candidates = append(candidates, candidate{KeySourceProof, p.ID})
+		}
+	}
+	manifestID, err := manifestKeyID(filename)
+	if err != nil && !(isCRX(filename) && errors.Is(err, ErrManifestNotFound)) {
+		return nil, err
+	}
+	if len(manifestID) > 0 {
+		candidates = append(candidates, candidate{KeySourceManifest, manifestID})
+	}
+
+	matches := []KeyMatch{}
+	for _, c := range candidates {
+		key, err := s.Get(c.id)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, KeyMatch{Source: c.source, ID: c.id, Key: *key})
+	}
+	return matches, nil
+}
@@ -0,0 +1,169 @@
+package crx3
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keys")
+	ks, err := OpenKeyStore(dir)
+	require.NoError(t, err)
+	assert.Equal(t, dir, ks.Dir())
+	st, err := os.Stat(dir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), st.Mode().Perm())
+
+	keys, err := ks.List()
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	key, err := ks.Import("testdata/withkey.crx.pem")
+	require.NoError(t, err)
+	assert.Equal(t, "hpngabcaekcmpoiomblebiidejafakch", key.ID)
+	assert.Equal(t, "RSA", key.KeyType)
+	assert.Equal(t, 4096, key.KeySize)
+	assert.False(t, key.Encrypted)
+	st, err = os.Stat(key.Path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), st.Mode().Perm())
+
+	_, err = ks.Import("testdata/withkey.crx.pem")
+	assert.ErrorIs(t, err, os.ErrExist)
+
+	_, err = ks.Import("testdata/keys/rsa_scrypt.pem")
+	assert.ErrorIs(t, err, ErrPassphraseRequired)
+	encrypted, err := ks.Import("testdata/keys/rsa_scrypt.pem", WithPassphrase([]byte("secret")))
+	require.NoError(t, err)
+	assert.True(t, encrypted.Encrypted)
+	assert.Empty(t, encrypted.KeyType)
+
+	ec, err := LoadKey("testdata/keys/ec_sec1.pem")
+	require.NoError(t, err)
+	ecID, err := KeyID(ec)
+	require.NoError(t, err)
+	added, err := ks.Add(ec)
+	require.NoError(t, err)
+	assert.Equal(t, ecID, added.ID)
+	assert.Equal(t, "ECDSA", added.KeyType)
+	assert.Equal(t, 256, added.KeySize)
+
+	// files not named after an extension ID are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.pem"), []byte("x"), 0600))
+	keys, err = ks.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 3)
+	assert.True(t, keys[0].ID < keys[1].ID && keys[1].ID < keys[2].ID)
+
+	data, err := ks.Export(key.ID)
+	require.NoError(t, err)
+	orig, err := os.ReadFile("testdata/withkey.crx.pem")
+	require.NoError(t, err)
+	assert.Equal(t, orig, data)
+
+	pk, err := ks.LoadPrivateKey(encrypted.ID, WithPassphrase([]byte("secret")))
+	require.NoError(t, err)
+	id, err := KeyID(pk)
+	require.NoError(t, err)
+	assert.Equal(t, encrypted.ID, id)
+	_, err = ks.LoadPrivateKey(added.ID)
+	assert.ErrorIs(t, err, ErrUnsupportedKeyType)
+	signer, err := ks.Load(added.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ec, signer)
+
+	require.NoError(t, ks.Remove(key.ID))
+	_, err = ks.Get(key.ID)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.ErrorIs(t, ks.Remove(key.ID), ErrKeyNotFound)
+	_, err = ks.Get("../secret")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestDefaultKeyStoreDir(t *testing.T) {
+	t.Setenv(KeyStoreEnv, "/tmp/crx3-keys")
+	dir, err := DefaultKeyStoreDir()
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/crx3-keys", dir)
+
+	t.Setenv(KeyStoreEnv, "")
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/config")
+	dir, err = DefaultKeyStoreDir()
+	require.NoError(t, err)
+	config, err := os.UserConfigDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(config, "crx3", "keys"), dir)
+}
+
+func TestKeyStore_Find(t *testing.T) {
+	ks, err := OpenKeyStore(t.TempDir())
+	require.NoError(t, err)
+
+	matches, err := ks.Find("testdata/withkey.crx")
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+
+	_, err = ks.Import("testdata/withkey.crx.pem")
+	require.NoError(t, err)
+	tests := []struct {
+		name     string
+		filename string
+		expected []string
+	}{
+		{name: "crx signed by the stored key", filename: "testdata/withkey.crx", expected: []string{KeySourceProof}},
+		{name: "zip with another manifest key", filename: "testdata/withkey.zip"},
+		{name: "crx signed by another key", filename: "testdata/dodyDol.crx"},
+		{name: "dir without manifest key", filename: "testdata/scanroot/some_extension"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := ks.Find(tt.filename)
+			require.NoError(t, err)
+			var sources []string
+			for _, m := range matches {
+				assert.Equal(t, "hpngabcaekcmpoiomblebiidejafakch", m.ID)
+				sources = append(sources, m.Source)
+			}
+			assert.Equal(t, tt.expected, sources)
+		})
+	}
+
+	// a directory whose manifest key belongs to the stored key
+	pk, err := LoadPrivateKey("testdata/withkey.crx.pem")
+	require.NoError(t, err)
+	src := filepath.Join(t.TempDir(), "extension")
+	require.NoError(t, UnzipTo(src, "testdata/withkey.zip"))
+	_, err = SetManifestKey(src, pk)
+	require.NoError(t, err)
+	matches, err = ks.Find(src)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, KeySourceManifest, matches[0].Source)
+
+	_, err = ks.Find("testdata/missing.crx")
+	assert.Error(t, err)
+}
+
+func TestPack_KeyStore(t *testing.T) {
+	ks, err := OpenKeyStore(t.TempDir())
+	require.NoError(t, err)
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "stored.crx")
+
+	require.NoError(t, Pack("testdata/scanroot/some_extension", dst, nil, PackKeyStore(ks)))
+	assert.NoFileExists(t, filepath.Join(dir, "stored.pem"))
+	id, err := ID(dst)
+	require.NoError(t, err)
+	key, err := ks.Get(id)
+	require.NoError(t, err)
+	assert.Equal(t, id, key.ID)
+
+	matches, err := ks.Find(dst)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, KeySourceProof, matches[0].Source)
+}
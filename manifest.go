@@ -0,0 +1,140 @@
+package crx3
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+const manifestFilename = "manifest.json"
+
+// Manifest holds the manifest.json fields that crx3 understands.
+// Unknown fields are ignored; permission entries that are not plain
+// strings (e.g. object permissions of legacy apps) are skipped.
+type Manifest struct {
+	Name                 string   `json:"name"`
+	Version              string   `json:"version"`
+	VersionName          string   `json:"version_name,omitempty"`
+	ManifestVersion      int      `json:"manifest_version"`
+	MinimumChromeVersion string   `json:"minimum_chrome_version,omitempty"`
+	Key                  string   `json:"key,omitempty"`
+	UpdateURL            string   `json:"update_url,omitempty"`
+	Permissions          []string `json:"permissions,omitempty"`
+	OptionalPermissions  []string `json:"optional_permissions,omitempty"`
+	HostPermissions      []string `json:"host_permissions,omitempty"`
+}
+
+// ParseManifest decodes the contents of a manifest.json file.
+func ParseManifest(data []byte) (*Manifest, error) {
+	var raw struct {
+		Manifest
+		Permissions         []json.RawMessage `json:"permissions"`
+		OptionalPermissions []json.RawMessage `json:"optional_permissions"`
+		HostPermissions     []json.RawMessage `json:"host_permissions"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidManifest, err)
+	}
+	m := raw.Manifest
+	m.Permissions = rawStrings(raw.Permissions)
+	m.OptionalPermissions = rawStrings(raw.OptionalPermissions)
+	m.HostPermissions = rawStrings(raw.HostPermissions)
+	return &m, nil
+}
+
+// ReadManifest reads and parses manifest.json from an unpacked directory,
+// a ZIP archive or a CRX3 file.
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := readManifestData(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseManifest(data)
+}
+
+// ParsedVersion parses the manifest "version" field.
+func (m *Manifest) ParsedVersion() (Version, error) {
+	return ParseVersion(m.Version)
+}
+
+// AllPermissions returns the permissions, optional permissions and host
+// permissions requested by the manifest, sorted and without duplicates.
+func (m *Manifest) AllPermissions() []string {
+	all := slices.Concat(m.Permissions, m.OptionalPermissions, m.HostPermissions)
+	slices.Sort(all)
+	return slices.Compact(all)
+}
+
+// Validate lints the manifest against the rules Chrome enforces at install time.
+// All problems found are returned joined together, each wrapping ErrInvalidManifest.
+func (m *Manifest) Validate() error {
+	var errs []error
+	if len(m.Name) == 0 {
+		errs = append(errs, fmt.Errorf("%w: name is required", ErrInvalidManifest))
+	}
+	if len(m.Version) == 0 {
+		errs = append(errs, fmt.Errorf("%w: version is required", ErrInvalidManifest))
+	} else if _, err := ParseVersion(m.Version); err != nil {
+		errs = append(errs, fmt.Errorf("%w: version: %w", ErrInvalidManifest, err))
+	}
+	if m.ManifestVersion != 2 && m.ManifestVersion != 3 {
+		errs = append(errs, fmt.Errorf("%w: manifest_version must be 2 or 3, got %d",
+			ErrInvalidManifest, m.ManifestVersion))
+	}
+	if len(m.MinimumChromeVersion) > 0 {
+		if _, err := ParseVersion(m.MinimumChromeVersion); err != nil {
+			errs = append(errs, fmt.Errorf("%w: minimum_chrome_version: %w", ErrInvalidManifest, err))
+		}
+	}
+	if len(m.Key) > 0 {
+		if _, err := IDFromPubKey([]byte(m.Key)); err != nil {
+			errs = append(errs, fmt.Errorf("%w: key: %w", ErrInvalidManifest, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func readManifestData(path string) ([]byte, error) {
+	switch {
+	case isDir(path):
+		data, err := os.ReadFile(manifestFile(path))
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrManifestNotFound, path)
+		}
+		return data, err
+	case isZip(path), isCRX(path):
+		r, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, fmt.Errorf("crx3: failed to open zip reader: %w", err)
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			if f.Name != manifestFilename {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("crx3: failed to open file %s: %w", f.Name, err)
+			}
+			defer rc.Close()
+			return io.ReadAll(rc)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrManifestNotFound, path)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownFileExtension, path)
+}
+
+func rawStrings(values []json.RawMessage) []string {
+	var out []string
+	for _, v := range values {
+		var s string
+		if err := json.Unmarshal(v, &s); err == nil {
+			out = append(out, s)
+		}
+	}
+	return out
+}
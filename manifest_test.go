@@ -0,0 +1,101 @@
+package crx3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "should read manifest from directory", path: "./testdata/scanroot/with_manifest", want: "2.1.0"},
+		{name: "should read manifest from crx", path: "./testdata/dodyDol.crx"},
+		{name: "should read manifest from zip", path: "./testdata/withkey.zip"},
+		{name: "should return error when manifest not found", path: "./testdata/emptydir", wantErr: true},
+		{name: "should return error when path does not exist", path: "./testdata/notfound", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadManifest(tt.path)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, got.Version)
+			if len(tt.want) > 0 {
+				assert.Equal(t, tt.want, got.Version)
+			}
+		})
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	data := []byte(`{
+		"name": "test",
+		"version": "1.0",
+		"manifest_version": 2,
+		"permissions": ["tabs", {"fileSystem": ["write"]}, "https://*/*"]
+	}`)
+	m, err := ParseManifest(data)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tabs", "https://*/*"}, m.Permissions)
+	assert.NoError(t, m.Validate())
+
+	_, err = ParseManifest([]byte(`{`))
+	assert.ErrorIs(t, err, ErrInvalidManifest)
+}
+
+func TestManifest_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest Manifest
+		wantErr  bool
+	}{
+		{
+			name:     "should pass valid manifest",
+			manifest: Manifest{Name: "ext", Version: "1.2.3", ManifestVersion: 3, MinimumChromeVersion: "120.0.6099.109"},
+		},
+		{
+			name:     "should return error when version is invalid",
+			manifest: Manifest{Name: "ext", Version: "01.2", ManifestVersion: 3},
+			wantErr:  true,
+		},
+		{
+			name:     "should return error when version is missing",
+			manifest: Manifest{Name: "ext", ManifestVersion: 3},
+			wantErr:  true,
+		},
+		{
+			name:     "should return error when manifest_version is unsupported",
+			manifest: Manifest{Name: "ext", Version: "1", ManifestVersion: 1},
+			wantErr:  true,
+		},
+		{
+			name:     "should return error when minimum_chrome_version is invalid",
+			manifest: Manifest{Name: "ext", Version: "1", ManifestVersion: 3, MinimumChromeVersion: "latest"},
+			wantErr:  true,
+		},
+		{
+			name:     "should return error when key is invalid",
+			manifest: Manifest{Name: "ext", Version: "1", ManifestVersion: 3, Key: "bad"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.manifest.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidManifest)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package crx3
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const manifestKeyField = "key"
+
+// SetManifestKey writes the public key of pk, an RSA or ECDSA private key,
+// base64-encoded DER as expected by Chrome, into the "key" field of the
+// manifest.json of the unpacked extension dir, so that loading the directory in developer mode gives the
+// same ID as the CRX signed with pk. An existing key is replaced in place,
+// otherwise the field is appended; the rest of the file keeps its formatting.
+// It returns the extension ID, verified against the written manifest.
+func SetManifestKey(dir string, pk crypto.Signer) (string, error) {
+	if isNilSigner(pk) {
+		return "", fmt.Errorf("%w: for extension %s", ErrPrivateKeyNotFound, dir)
+	}
+	if err := checkSigner(pk); err != nil {
+		return "", err
+	}
+	publicKey, err := makePublicKey(pk)
+	if err != nil {
+		return "", err
+	}
+	key := base64.StdEncoding.EncodeToString(publicKey)
+
+	filename := manifestFile(dir)
+	orig, mode, err := readManifestFile(filename)
+	if err != nil {
+		return "", err
+	}
+	value, err := marshalJSON(key)
+	if err != nil {
+		return "", err
+	}
+	data, err := setJSONMember(orig, manifestKeyField, value)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %w", ErrInvalidManifest, filename, err)
+	}
+	id, err := KeyID(pk)
+	if err != nil {
+		return "", err
+	}
+	// check the new manifest before it replaces the old one
+	if err := checkManifestKeyID(filename, data, id); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filename, data, mode); err != nil {
+		return "", err
+	}
+	// verify what Chrome will read, and restore the old manifest if it differs
+	written, err := os.ReadFile(filename)
+	if err == nil {
+		err = checkManifestKeyID(filename, written, id)
+	}
+	if err != nil {
+		if restoreErr := os.WriteFile(filename, orig, mode); restoreErr != nil {
+			return "", errors.Join(err, restoreErr)
+		}
+		return "", err
+	}
+	return id, nil
+}
+
+// checkManifestKeyID parses the manifest data of filename and checks that its
+// key field gives the extension ID id.
+func checkManifestKeyID(filename string, data []byte, id string) error {
+	manifest, err := ParseManifest(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	manifestID, err := IDFromPubKey([]byte(manifest.Key))
+	if err != nil {
+		return err
+	}
+	if manifestID != id {
+		return fmt.Errorf("%w: manifest key of %s gives ID %s, but the signing key gives %s", ErrIDMismatch, filename, manifestID, id)
+	}
+	return nil
+}
+
+// RemoveManifestKey removes the "key" field from the manifest.json of the
+// unpacked extension dir, as required before uploading to the Chrome Web Store.
+// The rest of the file keeps its formatting. It reports whether there was a key.
+func RemoveManifestKey(dir string) (bool, error) {
+	filename := manifestFile(dir)
+	data, mode, err := readManifestFile(filename)
+	if err != nil {
+		return false, err
+	}
+	data, removed, err := removeJSONMember(data, manifestKeyField)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s: %w", ErrInvalidManifest, filename, err)
+	}
+	if !removed {
+		return false, nil
+	}
+	return true, os.WriteFile(filename, data, mode)
+}
+
+func readManifestFile(filename string) ([]byte, os.FileMode, error) {
+	st, err := os.Stat(filename)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %s", ErrManifestNotFound, filename)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, 0, fmt.Errorf("crx3: failed to read file %s: %w", filename, err)
+	}
+	return data, st.Mode().Perm(), nil
+}
+
+// jsonMember locates a member of a JSON object in the source text:
+// data[start:valueStart] is the name and the separator, data[valueStart:end] the value.
+type jsonMember struct {
+	name       string
+	start      int
+	nameEnd    int
+	valueStart int
+	end        int
+}
+
+// jsonObjectLayout locates the braces and members of the top-level JSON object in data.
+func jsonObjectLayout(data []byte) (open int, closing int, members []jsonMember, err error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if tok != json.Delim('{') {
+		return 0, 0, nil, errors.New("not a JSON object")
+	}
+	open = int(dec.InputOffset()) - 1
+	for dec.More() {
+		prev := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		m := jsonMember{name: tok.(string), nameEnd: int(dec.InputOffset())}
+		m.start = prev + bytes.IndexByte(data[prev:], '"')
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return 0, 0, nil, err
+		}
+		m.end = int(dec.InputOffset())
+		m.valueStart = m.end - len(value)
+		members = append(members, m)
+	}
+	if _, err := dec.Token(); err != nil {
+		return 0, 0, nil, err
+	}
+	closing = int(dec.InputOffset()) - 1
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return 0, 0, nil, errors.New("unexpected data after the JSON object")
+	}
+	return open, closing, members, nil
+}
+
+// findJSONMember returns the index of the member name, or -1.
+func findJSONMember(members []jsonMember, name string) (int, error) {
+	found := -1
+	for i, m := range members {
+		if m.name != name {
+			continue
+		}
+		if found >= 0 {
+			return -1, fmt.Errorf("duplicate %q field", name)
+		}
+		found = i
+	}
+	return found, nil
+}
+
+// setJSONMember replaces the value of the top-level member name with the
+// encoded value, or appends the member with the indentation of the first one.
+func setJSONMember(data []byte, name string, value []byte) ([]byte, error) {
+	open, closing, members, err := jsonObjectLayout(data)
+	if err != nil {
+		return nil, err
+	}
+	i, err := findJSONMember(members, name)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if i >= 0 {
+		out.Write(data[:members[i].valueStart])
+		out.Write(value)
+		out.Write(data[members[i].end:])
+		return out.Bytes(), nil
+	}
+
+	encodedName, err := marshalJSON(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		out.Write(data[:open+1])
+		out.Write(encodedName)
+		out.WriteString(": ")
+		out.Write(value)
+		out.Write(data[closing:])
+		return out.Bytes(), nil
+	}
+	first, last := members[0], members[len(members)-1]
+	indent := data[open+1 : first.start]
+	if len(indent) == 0 {
+		indent = []byte(" ")
+	}
+	out.Write(data[:last.end])
+	out.WriteByte(',')
+	out.Write(indent)
+	out.Write(encodedName)
+	out.Write(data[first.nameEnd:first.valueStart])
+	out.Write(value)
+	out.Write(data[last.end:])
+	return out.Bytes(), nil
+}
+
+// removeJSONMember removes the top-level member name together with its comma.
+func removeJSONMember(data []byte, name string) ([]byte, bool, error) {
+	open, closing, members, err := jsonObjectLayout(data)
+	if err != nil {
+		return nil, false, err
+	}
+	i, err := findJSONMember(members, name)
+	if err != nil || i < 0 {
+		return data, false, err
+	}
+	var from, to int
+	switch {
+	case len(members) == 1:
+		from, to = open+1, closing
+	case i == 0:
+		from, to = members[0].start, members[1].start
+	default:
+		from, to = members[i-1].end, members[i].end
+	}
+	out := append(bytes.Clone(data[:from]), data[to:]...)
+	return out, true, nil
+}
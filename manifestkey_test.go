@@ -0,0 +1,198 @@
+package crx3
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetJSONMember(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+		err      string
+	}{
+		{
+			name:     "append indented",
+			data:     "{\n  \"name\": \"a\",\n  \"list\": [1, 2]\n}\n",
+			expected: "{\n  \"name\": \"a\",\n  \"list\": [1, 2],\n  \"key\": \"K\"\n}\n",
+		},
+		{
+			name:     "append with tabs and compact separator",
+			data:     "{\n\t\"name\":\"a\"\n}",
+			expected: "{\n\t\"name\":\"a\",\n\t\"key\":\"K\"\n}",
+		},
+		{
+			name:     "append on one line",
+			data:     `{"name": "a"}`,
+			expected: `{"name": "a", "key": "K"}`,
+		},
+		{
+			name:     "replace in place",
+			data:     "{\n  \"key\":   \"old\",  \"name\": \"a\"\n}",
+			expected: "{\n  \"key\":   \"K\",  \"name\": \"a\"\n}",
+		},
+		{
+			name:     "nested key is not replaced",
+			data:     `{"a": {"key": "x"}}`,
+			expected: `{"a": {"key": "x"}, "key": "K"}`,
+		},
+		{
+			name:     "empty object",
+			data:     "{ }",
+			expected: `{"key": "K"}`,
+		},
+		{name: "array", data: "[]", err: "not a JSON object"},
+		{name: "duplicate", data: `{"key": 1, "key": 2}`, err: `duplicate "key" field`},
+		{name: "trailing data", data: `{} {}`, err: "unexpected data after the JSON object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := setJSONMember([]byte(tt.data), "key", []byte(`"K"`))
+			if len(tt.err) > 0 {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(out))
+		})
+	}
+}
+
+func TestRemoveJSONMember(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+		removed  bool
+	}{
+		{
+			name:     "last",
+			data:     "{\n  \"name\": \"a\",\n  \"key\": \"K\"\n}\n",
+			expected: "{\n  \"name\": \"a\"\n}\n",
+			removed:  true,
+		},
+		{
+			name:     "first",
+			data:     "{\n  \"key\": \"K\",\n  \"name\": \"a\"\n}\n",
+			expected: "{\n  \"name\": \"a\"\n}\n",
+			removed:  true,
+		},
+		{
+			name:     "middle",
+			data:     `{"a": 1, "key": "K", "b": 2}`,
+			expected: `{"a": 1, "b": 2}`,
+			removed:  true,
+		},
+		{
+			name:     "only",
+			data:     "{\n  \"key\": \"K\"\n}",
+			expected: "{}",
+			removed:  true,
+		},
+		{
+			name:     "missing",
+			data:     `{"a": {"key": "K"}}`,
+			expected: `{"a": {"key": "K"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, removed, err := removeJSONMember([]byte(tt.data), "key")
+			require.NoError(t, err)
+			assert.Equal(t, tt.removed, removed)
+			assert.Equal(t, tt.expected, string(out))
+		})
+	}
+}
+
+func TestSetManifestKey(t *testing.T) {
+	pk, err := LoadPrivateKey("testdata/withkey.crx.pem")
+	require.NoError(t, err)
+	crxID, err := ID("testdata/withkey.crx")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	manifest := "{\n  \"manifest_version\": 3,\n  \"name\": \"demo\",\n  \"version\": \"1.0\"\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, manifestFilename), []byte(manifest), 0600))
+
+	id, err := SetManifestKey(dir, pk)
+	require.NoError(t, err)
+	assert.Equal(t, crxID, id)
+	extID, err := Extension(dir).ID()
+	require.NoError(t, err)
+	assert.Equal(t, crxID, extID)
+
+	// setting the key again keeps the file as it is
+	data, err := os.ReadFile(filepath.Join(dir, manifestFilename))
+	require.NoError(t, err)
+	_, err = SetManifestKey(dir, pk)
+	require.NoError(t, err)
+	again, err := os.ReadFile(filepath.Join(dir, manifestFilename))
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+	st, err := os.Stat(filepath.Join(dir, manifestFilename))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), st.Mode().Perm())
+
+	removed, err := RemoveManifestKey(dir)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	data, err = os.ReadFile(filepath.Join(dir, manifestFilename))
+	require.NoError(t, err)
+	assert.Equal(t, manifest, string(data))
+
+	removed, err = RemoveManifestKey(dir)
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestSetManifestKey_ECDSA(t *testing.T) {
+	key, err := LoadKey("testdata/keys/ec_sec1.pem")
+	require.NoError(t, err)
+	keyID, err := KeyID(key)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, manifestFilename), []byte(`{"name": "demo", "version": "1.0"}`), 0644))
+
+	id, err := SetManifestKey(dir, key)
+	require.NoError(t, err)
+	assert.Equal(t, keyID, id)
+	extID, err := Extension(dir).ID()
+	require.NoError(t, err)
+	assert.Equal(t, keyID, extID)
+}
+
+func TestSetManifestKey_Errors(t *testing.T) {
+	pk, err := LoadPrivateKey("testdata/withkey.crx.pem")
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	_, err = SetManifestKey(dir, nil)
+	assert.ErrorIs(t, err, ErrPrivateKeyNotFound)
+	_, err = SetManifestKey(dir, pk)
+	assert.ErrorIs(t, err, ErrManifestNotFound)
+	_, err = RemoveManifestKey(dir)
+	assert.ErrorIs(t, err, ErrManifestNotFound)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, manifestFilename), []byte(`{"name": `), 0644))
+	_, err = SetManifestKey(dir, pk)
+	assert.ErrorIs(t, err, ErrInvalidManifest)
+
+	// the key can be spliced in, but the manifest does not parse: the file is kept
+	for _, manifest := range []string{
+		`{"name": "demo", "manifest_version": "3"}`,
+		`{"name": 42, "version": "1.0"}`,
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, manifestFilename), []byte(manifest), 0644))
+		_, err = SetManifestKey(dir, pk)
+		assert.ErrorIs(t, err, ErrInvalidManifest)
+		data, err := os.ReadFile(filepath.Join(dir, manifestFilename))
+		require.NoError(t, err)
+		assert.Equal(t, manifest, string(data))
+	}
+}
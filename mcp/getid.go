@@ -20,7 +20,10 @@ type getidParams struct {
 }
 
 type getidResult struct {
-	ID string `json:"id" jsonschema:"required, Chrome extension ID"`
+	ID         string `json:"id" jsonschema:"required, Chrome extension ID"`
+	ManifestID string `json:"manifestId,omitempty" jsonschema:"ID derived from the manifest key field, if any"`
+	HeaderID   string `json:"headerId,omitempty" jsonschema:"ID of the key that signed the .crx file"`
+	Consistent bool   `json:"consistent" jsonschema:"false if the manifest key and the signing key give different IDs, which Chrome rejects"`
 }
 
 func (h *handler) getidHandler(ctx context.Context, _ *sdkmcp.CallToolRequest, params getidParams) (*sdkmcp.CallToolResult, any, error) {
@@ -40,20 +43,28 @@ func (h *handler) getidHandler(ctx context.Context, _ *sdkmcp.CallToolRequest, p
 		return nil, nil, fmt.Errorf("extension not found %q`", params.Filepath)
 	}
 
-	id, err := h.svc.GetID(extensionFilepath)
+	check, err := h.svc.CheckID(extensionFilepath)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get extension ID from %q: %w", params.Filepath, err)
 	}
 
 	resp := &sdkmcp.CallToolResult{
 		StructuredContent: getidResult{
-			ID: id,
+			ID:         check.ID(),
+			ManifestID: check.ManifestID,
+			HeaderID:   check.HeaderID,
+			Consistent: check.Consistent(),
 		},
 	}
 
 	if !h.opts.DisabledMarkdown {
+		text := fmt.Sprintf("Extension ID: %s", check.ID())
+		if !check.Consistent() {
+			text += fmt.Sprintf("\n\nWARNING: the manifest key gives ID %s, but the .crx file is signed by a key with ID %s. Chrome will refuse to install it.",
+				check.ManifestID, check.HeaderID)
+		}
 		resp.Content = []sdkmcp.Content{
-			&sdkmcp.TextContent{Text: fmt.Sprintf("Extension ID: %s", id)},
+			&sdkmcp.TextContent{Text: text},
 		}
 	}
 
@@ -0,0 +1,94 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mediabuyerbot/go-crx3"
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	gomock "go.uber.org/mock/gomock"
+)
+
+func Test_handler_getidHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pwd, _ := os.Getwd()
+	toPath := func(other string) string {
+		return filepath.Join(pwd, other)
+	}
+
+	tests := []struct {
+		name    string
+		handler func() *handler
+		expect  func(*testing.T, *sdkmcp.CallToolResult)
+		params  getidParams
+		wantErr bool
+	}{
+		{
+			name: "should return error when extension not found",
+			handler: func() *handler {
+				return &handler{opts: &Options{WorkDir: "testdata/workspace"}}
+			},
+			params:  getidParams{Filepath: "missing.crx"},
+			wantErr: true,
+		},
+		{
+			name: "should return consistent id",
+			handler: func() *handler {
+				svc := NewMockcrx3service(ctrl)
+				svc.EXPECT().CheckID(toPath("testdata/workspace/extension.crx")).
+					Return(&crx3.IDCheck{HeaderID: "kpkcennohgffjdgaelocingbmkjnpjgc"}, nil)
+				return &handler{svc: svc, opts: &Options{WorkDir: "testdata/workspace"}}
+			},
+			params: getidParams{Filepath: "./extension.crx"},
+			expect: func(t *testing.T, res *sdkmcp.CallToolResult) {
+				assert.Equal(t, getidResult{
+					ID:         "kpkcennohgffjdgaelocingbmkjnpjgc",
+					HeaderID:   "kpkcennohgffjdgaelocingbmkjnpjgc",
+					Consistent: true,
+				}, res.StructuredContent)
+				assertText(t, res, "Extension ID: kpkcennohgffjdgaelocingbmkjnpjgc")
+			},
+		},
+		{
+			name: "should report mismatching ids",
+			handler: func() *handler {
+				svc := NewMockcrx3service(ctrl)
+				svc.EXPECT().CheckID(toPath("testdata/workspace/extension.crx")).
+					Return(&crx3.IDCheck{
+						ManifestID: "nigbihjmcbekdlkgdceknpanajdpncle",
+						HeaderID:   "hpngabcaekcmpoiomblebiidejafakch",
+					}, nil)
+				return &handler{svc: svc, opts: &Options{WorkDir: "testdata/workspace"}}
+			},
+			params: getidParams{Filepath: "./extension.crx"},
+			expect: func(t *testing.T, res *sdkmcp.CallToolResult) {
+				assert.Equal(t, getidResult{
+					ID:         "nigbihjmcbekdlkgdceknpanajdpncle",
+					ManifestID: "nigbihjmcbekdlkgdceknpanajdpncle",
+					HeaderID:   "hpngabcaekcmpoiomblebiidejafakch",
+				}, res.StructuredContent)
+				assertText(t, res, "WARNING")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, gotErr := tt.handler().getidHandler(context.Background(), nil, tt.params)
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Errorf("getidHandler() failed: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("getidHandler() succeeded unexpectedly")
+			}
+			tt.expect(t, got)
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package mcp
 
 import (
+	"cmp"
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -19,6 +21,77 @@ var (
 type scanParams struct {
 	Limit  int      `json:"limit,omitempty" jsonschema:"maximum number of extensions to return. Use 0 or omit for no limit."`
 	Filter []string `json:"filter,omitempty" jsonschema:"list of keywords to filter extensions by name. Case-insensitive partial match. Example: ['react', 'adblock'] matches any extension with 'react' or 'adblock' in the name."`
+
+	Types           []string `json:"types,omitempty" jsonschema:"only return extensions of these types: 'crx', 'zip' or 'dir'."`
+	IDs             []string `json:"ids,omitempty" jsonschema:"only return extensions with these 32-character extension IDs."`
+	MinSize         int64    `json:"minSize,omitempty" jsonschema:"minimum extension size in bytes."`
+	MaxSize         int64    `json:"maxSize,omitempty" jsonschema:"maximum extension size in bytes."`
+	ModifiedAfter   string   `json:"modifiedAfter,omitempty" jsonschema:"only return extensions modified at or after this date. RFC3339 or YYYY-MM-DD (midnight in the server's local time zone)."`
+	ModifiedBefore  string   `json:"modifiedBefore,omitempty" jsonschema:"only return extensions modified before this date. RFC3339 or YYYY-MM-DD (midnight in the server's local time zone)."`
+	ManifestVersion []int    `json:"manifestVersion,omitempty" jsonschema:"only return extensions with these manifest_version values. Example: [3]."`
+	Permissions     []string `json:"permissions,omitempty" jsonschema:"only return extensions requesting any of these permissions or host permissions. Example: ['tabs', '<all_urls>']."`
+	Include         []string `json:"include,omitempty" jsonschema:"glob patterns matched against the workspace-relative path or base name; only matching extensions are returned. Example: ['*.crx']."`
+	Exclude         []string `json:"exclude,omitempty" jsonschema:"glob patterns of paths to skip. Example: ['backup', '*.zip']."`
+	Hidden          bool     `json:"hidden,omitempty" jsonschema:"also scan hidden files and directories whose names start with a dot."`
+	FromIndex       bool     `json:"fromIndex,omitempty" jsonschema:"read the results from the workspace catalog built by 'crx3 index' instead of walking the file system. Much faster for large workspaces, but may be out of date."`
+}
+
+// options converts the filter parameters into scan options.
+// Symlinks are never followed, so the scan can't leave the workspace.
+func (p scanParams) options() ([]crx3.ScanOption, error) {
+	var opts []crx3.ScanOption
+	for _, filter := range p.Filter {
+		opts = append(opts, crx3.WithNameFilter(filter))
+	}
+	for _, t := range p.Types {
+		switch strings.ToLower(t) {
+		case "crx", "zip", "dir":
+		default:
+			return nil, fmt.Errorf("unknown extension type %q, expected crx, zip or dir", t)
+		}
+	}
+	if len(p.Types) > 0 {
+		opts = append(opts, crx3.WithTypeFilter(p.Types...))
+	}
+	if len(p.IDs) > 0 {
+		opts = append(opts, crx3.WithIDFilter(p.IDs...))
+	}
+	if p.MinSize < 0 || p.MaxSize < 0 {
+		return nil, fmt.Errorf("minSize and maxSize must be non-negative")
+	}
+	if p.MinSize > 0 || p.MaxSize > 0 {
+		opts = append(opts, crx3.WithSizeRange(p.MinSize, p.MaxSize))
+	}
+	if p.ModifiedAfter != "" {
+		t, err := crx3.ParseModifiedTime(p.ModifiedAfter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modifiedAfter: %w", err)
+		}
+		opts = append(opts, crx3.WithModifiedAfter(t))
+	}
+	if p.ModifiedBefore != "" {
+		t, err := crx3.ParseModifiedTime(p.ModifiedBefore)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modifiedBefore: %w", err)
+		}
+		opts = append(opts, crx3.WithModifiedBefore(t))
+	}
+	if len(p.ManifestVersion) > 0 {
+		opts = append(opts, crx3.WithManifestVersionFilter(p.ManifestVersion...))
+	}
+	if len(p.Permissions) > 0 {
+		opts = append(opts, crx3.WithPermissionFilter(p.Permissions...))
+	}
+	if len(p.Include) > 0 {
+		opts = append(opts, crx3.WithIncludeGlob(p.Include...))
+	}
+	if len(p.Exclude) > 0 {
+		opts = append(opts, crx3.WithExcludeGlob(p.Exclude...))
+	}
+	if p.Hidden {
+		opts = append(opts, crx3.WithHidden())
+	}
+	return opts, nil
 }
 
 type scanResult struct {
@@ -36,17 +109,23 @@ func (h *handler) scanHandler(ctx context.Context, _ *sdkmcp.CallToolRequest, pa
 		return nil, nil, fmt.Errorf(`limit must be less than 100`)
 	}
 
-	scanOpts := make([]crx3.ScanOption, 0, 4)
-	if len(params.Filter) > 0 {
-		for _, filter := range params.Filter {
-			scanOpts = append(scanOpts, crx3.WithNameFilter(filter))
-		}
+	scanOpts, err := params.options()
+	if err != nil {
+		return nil, nil, err
 	}
 	scanOpts = append(scanOpts, crx3.WithMaxResults(params.Limit))
 	scanOpts = append(scanOpts, crx3.WithMaxDepth(10))
+	scanOpts = append(scanOpts, crx3.WithMetadata())
 
+	scan := h.svc.Scan
+	if params.FromIndex {
+		scan = h.svc.ScanIndex
+	}
 	var results []*crx3.ExtensionInfo
-	for info, err := range h.svc.Scan(h.opts.WorkDir, scanOpts...) {
+	for info, err := range scan(h.opts.WorkDir, scanOpts...) {
+		if errors.Is(err, crx3.ErrIndexNotFound) {
+			return nil, nil, fmt.Errorf("workspace [%q] has no index, scan without fromIndex or run 'crx3 index' first", h.opts.WorkDir)
+		}
 		if err != nil {
 			return nil, nil, fmt.Errorf("scan workspace [%q] error: %w", h.opts.WorkDir, err)
 		}
@@ -81,28 +160,33 @@ func makeScanMarkdownTable(extensions []*crx3.ExtensionInfo) string {
 
 	var sb strings.Builder
 
-	sb.WriteString("| Name | Path | Type | Size | Modified |\n")
-	sb.WriteString("|------|------|------|------|----------|\n")
+	sb.WriteString("| Name | ID | Version | Path | Type | Size | Modified |\n")
+	sb.WriteString("|------|----|---------|------|------|------|----------|\n")
 
 	for _, ext := range extensions {
-		name := ext.Name
+		name := ext.ManifestName
+		if name == "" || strings.HasPrefix(name, "__MSG_") {
+			name = ext.Name
+		}
 		if name == "" {
 			name = "*unknown*"
 		}
 		var sizeStr string
-		if ext.Type == "dir" {
+		if ext.Type == "dir" && ext.Size == 0 {
 			sizeStr = "-"
 		} else {
 			sizeStr = fmt.Sprintf("%d", ext.Size)
 		}
 		name = escapeMarkdown(name)
+		id := escapeMarkdown(cmp.Or(ext.ID, "-"))
+		version := escapeMarkdown(cmp.Or(ext.Version, "-"))
 		path := escapeMarkdown(ext.Path)
 		et := escapeMarkdown(ext.Type)
 		sizeStr = escapeMarkdown(sizeStr)
 		modified := escapeMarkdown(ext.Modified)
 
-		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n",
-			name, path, et, sizeStr, modified))
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s | %s |\n",
+			name, id, version, path, et, sizeStr, modified))
 	}
 
 	return sb.String()
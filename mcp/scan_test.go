@@ -41,6 +41,36 @@ func Test_handler_scanHandler(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "should return error when type is unknown",
+			handler: func() *handler {
+				return &handler{opts: &Options{WorkDir: "/"}}
+			},
+			params: scanParams{
+				Types: []string{"xpi"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return error when date is invalid",
+			handler: func() *handler {
+				return &handler{opts: &Options{WorkDir: "/"}}
+			},
+			params: scanParams{
+				ModifiedAfter: "yesterday",
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return error when size is negative",
+			handler: func() *handler {
+				return &handler{opts: &Options{WorkDir: "/"}}
+			},
+			params: scanParams{
+				MinSize: -1,
+			},
+			wantErr: true,
+		},
 		{
 			name: "should return error when scan fails",
 			handler: func() *handler {
@@ -95,7 +125,7 @@ func Test_handler_scanHandler(t *testing.T) {
 			},
 			expect: func(t *testing.T, res *sdkmcp.CallToolResult) {
 				assert.Equal(t, 2, len(res.StructuredContent.(scanResult).Results))
-				assertText(t, res, "| Name | Path | Type | Size | Modified |")
+				assertText(t, res, "| Name | ID | Version | Path | Type | Size | Modified |")
 				assertText(t, res, "Test Extension")
 				assertText(t, res, "Another Extension")
 			},
@@ -147,6 +177,43 @@ func Test_handler_scanHandler(t *testing.T) {
 				assert.Empty(t, res.Content)
 			},
 		},
+		{
+			name: "should read results from the workspace index",
+			handler: func() *handler {
+				svc := NewMockcrx3service(ctrl)
+				svc.EXPECT().ScanIndex("testdata/workspace", gomock.Any()).Return(func(yield func(*crx3.ExtensionInfo, error) bool) {
+					yield(&crx3.ExtensionInfo{
+						Name:     "Indexed Extension",
+						Path:     "indexed/path",
+						Type:     "crx",
+						Size:     12345,
+						Modified: "2024-01-01",
+					}, nil)
+				})
+				return &handler{svc: svc, opts: &Options{WorkDir: "testdata/workspace"}}
+			},
+			params: scanParams{
+				FromIndex: true,
+			},
+			expect: func(t *testing.T, res *sdkmcp.CallToolResult) {
+				assert.Equal(t, 1, len(res.StructuredContent.(scanResult).Results))
+				assertText(t, res, "Indexed Extension")
+			},
+		},
+		{
+			name: "should return error when the workspace has no index",
+			handler: func() *handler {
+				svc := NewMockcrx3service(ctrl)
+				svc.EXPECT().ScanIndex("testdata/workspace", gomock.Any()).Return(func(yield func(*crx3.ExtensionInfo, error) bool) {
+					yield(nil, crx3.ErrIndexNotFound)
+				})
+				return &handler{svc: svc, opts: &Options{WorkDir: "testdata/workspace"}}
+			},
+			params: scanParams{
+				FromIndex: true,
+			},
+			wantErr: true,
+		},
 		{
 			name: "should use default limit when limit is 0",
 			handler: func() *handler {
@@ -212,12 +279,36 @@ func Test_makeScanMarkdownTable(t *testing.T) {
 			},
 		}
 		result := makeScanMarkdownTable(extensions)
-		assert.Contains(t, result, "| Name | Path | Type | Size | Modified |")
+		assert.Contains(t, result, "| Name | ID | Version | Path | Type | Size | Modified |")
 		assert.Contains(t, result, "Test Extension")
 		assert.Contains(t, result, "12345")
 		assert.Contains(t, result, "-")
 	})
 
+	t.Run("should render metadata", func(t *testing.T) {
+		extensions := []*crx3.ExtensionInfo{
+			{
+				Name:         "some name",
+				ManifestName: "Manifest Name",
+				ID:           "kpkcennohgffjdgaelocingbmkjnpjgc",
+				Version:      "2.1.0",
+				Path:         "dir/path",
+				Type:         "dir",
+				Size:         4096,
+				Modified:     "2024-01-02",
+			},
+			{
+				Name:         "localized",
+				ManifestName: "__MSG_appName__",
+				Path:         "test/path",
+				Type:         "crx",
+			},
+		}
+		result := makeScanMarkdownTable(extensions)
+		assert.Contains(t, result, "| Manifest Name | kpkcennohgffjdgaelocingbmkjnpjgc | 2.1.0 | dir/path | dir | 4096 | 2024-01-02 |")
+		assert.Contains(t, result, "| localized | - | - |")
+	})
+
 	t.Run("should handle empty name", func(t *testing.T) {
 		extensions := []*crx3.ExtensionInfo{
 			{
@@ -13,8 +13,10 @@ type crx3service interface {
 	PackTo(source string, dest string, pk *rsa.PrivateKey) error
 	SearchExtensionByName(ctx context.Context, name string) ([]crx3.SearchResult, error)
 	Scan(rootPath string, opts ...crx3.ScanOption) iter.Seq2[*crx3.ExtensionInfo, error]
+	ScanIndex(rootPath string, opts ...crx3.ScanOption) iter.Seq2[*crx3.ExtensionInfo, error]
 	DownloadFromWebStore(extensionID string, filename string) error
 	GetID(filename string) (string, error)
+	CheckID(filename string) (*crx3.IDCheck, error)
 	Base64(filename string) ([]byte, error)
 	UnzipTo(filename string, dirname string) error
 	ZipTo(source string, dest string) error
@@ -41,6 +43,10 @@ func (impl) Scan(rootPath string, opts ...crx3.ScanOption) iter.Seq2[*crx3.Exten
 	return crx3.Scan(rootPath, opts...)
 }
 
+func (impl) ScanIndex(rootPath string, opts ...crx3.ScanOption) iter.Seq2[*crx3.ExtensionInfo, error] {
+	return crx3.ScanIndex(rootPath, opts...)
+}
+
 func (impl) DownloadFromWebStore(extensionID string, filename string) error {
 	return crx3.DownloadFromWebStore(extensionID, filename)
 }
@@ -49,6 +55,10 @@ func (impl) GetID(filename string) (string, error) {
 	return crx3.Extension(filename).ID()
 }
 
+func (impl) CheckID(filename string) (*crx3.IDCheck, error) {
+	return crx3.CheckID(filename)
+}
+
 func (impl) Base64(filename string) ([]byte, error) {
 	return crx3.Extension(filename).Base64()
 }
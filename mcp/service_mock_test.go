@@ -58,6 +58,21 @@ func (mr *Mockcrx3serviceMockRecorder) Base64(filename any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Base64", reflect.TypeOf((*Mockcrx3service)(nil).Base64), filename)
 }
 
+// CheckID mocks base method.
+func (m *Mockcrx3service) CheckID(filename string) (*crx3.IDCheck, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckID", filename)
+	ret0, _ := ret[0].(*crx3.IDCheck)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckID indicates an expected call of CheckID.
+func (mr *Mockcrx3serviceMockRecorder) CheckID(filename any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckID", reflect.TypeOf((*Mockcrx3service)(nil).CheckID), filename)
+}
+
 // DownloadFromWebStore mocks base method.
 func (m *Mockcrx3service) DownloadFromWebStore(extensionID, filename string) error {
 	m.ctrl.T.Helper()
@@ -120,6 +135,25 @@ func (mr *Mockcrx3serviceMockRecorder) Scan(rootPath any, opts ...any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*Mockcrx3service)(nil).Scan), varargs...)
 }
 
+// ScanIndex mocks base method.
+func (m *Mockcrx3service) ScanIndex(rootPath string, opts ...crx3.ScanOption) iter.Seq2[*crx3.ExtensionInfo, error] {
+	m.ctrl.T.Helper()
+	varargs := []any{rootPath}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ScanIndex", varargs...)
+	ret0, _ := ret[0].(iter.Seq2[*crx3.ExtensionInfo, error])
+	return ret0
+}
+
+// ScanIndex indicates an expected call of ScanIndex.
+func (mr *Mockcrx3serviceMockRecorder) ScanIndex(rootPath any, opts ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{rootPath}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanIndex", reflect.TypeOf((*Mockcrx3service)(nil).ScanIndex), varargs...)
+}
+
 // SearchExtensionByName mocks base method.
 func (m *Mockcrx3service) SearchExtensionByName(ctx context.Context, name string) ([]crx3.SearchResult, error) {
 	m.ctrl.T.Helper()
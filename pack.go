@@ -3,12 +3,12 @@ package crx3
 import (
 	"bytes"
 	"crypto"
+	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/binary"
-	"encoding/pem"
 	"fmt"
 	"io"
 	"os"
@@ -21,14 +21,17 @@ import (
 )
 
 // PackZipToCRX reads a ZIP archive from the provided Reader, signs it using
-// the provided RSA private key, and writes the signed CRX file to the provided Writer.
+// the provided RSA or ECDSA private key, and writes the signed CRX file to the provided Writer.
 // This function is essential for producing production-ready CRX files that require
 // digital signatures to be installed in browsers. The function will return an error
 // if any issues occur during the zip reading, signing, or CRX writing processes.
-func PackZipToCRX(zip io.ReadSeeker, w io.Writer, pk *rsa.PrivateKey) error {
-	if zip == nil || w == nil || pk == nil {
+func PackZipToCRX(zip io.ReadSeeker, w io.Writer, pk crypto.Signer) error {
+	if zip == nil || w == nil || isNilSigner(pk) {
 		return fmt.Errorf("crx3/pack: zip or writer or privateKey is nil")
 	}
+	if err := checkSigner(pk); err != nil {
+		return err
+	}
 	publicKey, err := makePublicKey(pk)
 	if err != nil {
 		return fmt.Errorf("crx3/pack: failed to make public key: %w", err)
@@ -41,7 +44,7 @@ func PackZipToCRX(zip io.ReadSeeker, w io.Writer, pk *rsa.PrivateKey) error {
 	if err != nil {
 		return fmt.Errorf("crx3/pack: failed to make signature: %w", err)
 	}
-	header, err := makeHeader(publicKey, signature, signedData)
+	header, err := makeHeader(pk, publicKey, signature, signedData)
 	if err != nil {
 		return fmt.Errorf("crx3/pack: failed to make header: %w", err)
 	}
@@ -63,6 +66,7 @@ func PackZipToCRX(zip io.ReadSeeker, w io.Writer, pk *rsa.PrivateKey) error {
 //
 //	w    : An io.Writer to which the PEM encoded private key will be written.
 //	key  : A non-nil *rsa.PrivateKey that will be marshalled and written.
+//	opts : Optional KeyOption values, e.g. WithPassphrase to encrypt the key.
 //
 // Returns:
 //
@@ -90,19 +94,15 @@ func PackZipToCRX(zip io.ReadSeeker, w io.Writer, pk *rsa.PrivateKey) error {
 //
 //	This function does not close the io.Writer; the caller is responsible for managing
 //	the writer's lifecycle, including opening and closing it.
-func WritePrivateKey(w io.Writer, key *rsa.PrivateKey) error {
+func WritePrivateKey(w io.Writer, key *rsa.PrivateKey, opts ...KeyOption) error {
 	if key == nil {
 		return ErrPrivateKeyNotFound
 	}
-	bytes, err := x509.MarshalPKCS8PrivateKey(key)
+	data, err := MarshalPrivateKeyPEM(key, opts...)
 	if err != nil {
 		return fmt.Errorf("crx3/pack: failed to marshal private key: %w", err)
 	}
-	block := &pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: bytes,
-	}
-	if _, err := w.Write(pem.EncodeToMemory(block)); err != nil {
+	if _, err := w.Write(data); err != nil {
 		return fmt.Errorf("crx3/pack: failed to write private key: %w", err)
 	}
 	return nil
@@ -149,11 +149,46 @@ const (
 	pemExt = ".pem"
 )
 
+// PackOption is a function that configures packing behavior.
+type PackOption func(*packOptions)
+
+// packOptions holds configuration for packing CRX files.
+type packOptions struct {
+	strictID bool
+	keyStore *KeyStore
+	signer   crypto.Signer
+}
+
+// PackStrictID returns a PackOption that makes Pack fail with ErrIDMismatch
+// if the manifest has a key field that gives another extension ID than the
+// signing key. Chrome refuses to install such a CRX file.
+func PackStrictID() PackOption {
+	return func(o *packOptions) {
+		o.strictID = true
+	}
+}
+
+// PackKeyStore returns a PackOption that stores the private key generated by
+// Pack when no key is given in ks instead of next to the CRX file.
+func PackKeyStore(ks *KeyStore) PackOption {
+	return func(o *packOptions) {
+		o.keyStore = ks
+	}
+}
+
+// PackSigner returns a PackOption that signs with key, an RSA or ECDSA
+// private key, instead of the RSA key passed to Pack.
+func PackSigner(key crypto.Signer) PackOption {
+	return func(o *packOptions) {
+		o.signer = key
+	}
+}
+
 // Pack packs a zip file or unzipped directory into a crx extension.
 // It takes the source 'src' (zip file or directory), target 'dst' CRX file path,
 // and a private key 'pk' (optional). If 'pk' is nil, it generates a new private key.
 // It creates a CRX extension from the source and writes it to the destination.
-func Pack(src string, dst string, pk *rsa.PrivateKey) (err error) {
+func Pack(src string, dst string, pk *rsa.PrivateKey, opts ...PackOption) (err error) {
 	var (
 		publicKey      []byte
 		signedData     []byte
@@ -179,25 +214,43 @@ func Pack(src string, dst string, pk *rsa.PrivateKey) (err error) {
 		return err
 	}
 
+	conf := new(packOptions)
+	for _, opt := range opts {
+		opt(conf)
+	}
+	key := conf.signer
+	if isNilSigner(key) {
+		key = nil
+	}
+	if key == nil && pk != nil {
+		key = pk
+	}
 	// make default private key
-	if pk == nil {
-		pk, err = NewPrivateKey()
-		if err != nil {
+	if key == nil {
+		if pk, err = NewPrivateKey(); err != nil {
 			return err
 		}
-		isDefaultPk = true
+		key, isDefaultPk = pk, true
+	}
+	if err := checkSigner(key); err != nil {
+		return err
 	}
 
-	if publicKey, err = makePublicKey(pk); err != nil {
+	if publicKey, err = makePublicKey(key); err != nil {
 		return err
 	}
+	if conf.strictID {
+		if err := checkManifestKey(src, publicKey); err != nil {
+			return err
+		}
+	}
 	if signedData, err = makeSignedData(publicKey); err != nil {
 		return err
 	}
-	if signature, err = makeSign(zipData, signedData, pk); err != nil {
+	if signature, err = makeSign(zipData, signedData, key); err != nil {
 		return err
 	}
-	if header, err = makeHeader(publicKey, signature, signedData); err != nil {
+	if header, err = makeHeader(key, publicKey, signature, signedData); err != nil {
 		return err
 	}
 	if _, err := zipData.Seek(0, 0); err != nil {
@@ -212,7 +265,11 @@ func Pack(src string, dst string, pk *rsa.PrivateKey) (err error) {
 	if err := writeToCRX(dst, zipData, header); err != nil {
 		return err
 	}
-	if isDefaultPk {
+	if isDefaultPk && conf.keyStore != nil {
+		if _, err := conf.keyStore.Add(pk); err != nil {
+			return err
+		}
+	} else if isDefaultPk {
 		if err := saveDefaultPrivateKey(dst, pk); err != nil {
 			return err
 		}
@@ -220,6 +277,16 @@ func Pack(src string, dst string, pk *rsa.PrivateKey) (err error) {
 	return nil
 }
 
+// checkManifestKey compares the ID of the manifest key of src, if any, with the ID of publicKey.
+func checkManifestKey(src string, publicKey []byte) error {
+	manifestID, err := manifestKeyID(src)
+	if err != nil {
+		return err
+	}
+	check := IDCheck{ManifestID: manifestID, HeaderID: string(makeExtensionID(makeCRXID(publicKey)))}
+	return check.Err()
+}
+
 func writeToCRX(filename string, zipFile io.ReadSeeker, header []byte) error {
 	crx, err := os.Create(filename)
 	if err != nil {
@@ -268,8 +335,32 @@ func makeCRXID(publicKey []byte) []byte {
 	return hash.Sum(nil)[0:16]
 }
 
-func makePublicKey(pk *rsa.PrivateKey) ([]byte, error) {
-	return x509.MarshalPKIXPublicKey(&pk.PublicKey)
+// isNilSigner reports whether key is nil, also if it holds a nil
+// *rsa.PrivateKey or *ecdsa.PrivateKey, whose Public method would panic.
+func isNilSigner(key crypto.Signer) bool {
+	switch k := key.(type) {
+	case nil:
+		return true
+	case *rsa.PrivateKey:
+		return k == nil
+	case *ecdsa.PrivateKey:
+		return k == nil
+	}
+	return false
+}
+
+// checkSigner returns ErrUnsupportedKeyType unless key is an RSA or ECDSA
+// private key, the key types of CRX3 header proofs.
+func checkSigner(key crypto.Signer) error {
+	switch key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey:
+		return nil
+	}
+	return fmt.Errorf("%w: %T", ErrUnsupportedKeyType, key)
+}
+
+func makePublicKey(pk crypto.Signer) ([]byte, error) {
+	return x509.MarshalPKIXPublicKey(pk.Public())
 }
 
 func makeSignedData(publicKey []byte) ([]byte, error) {
@@ -279,7 +370,9 @@ func makeSignedData(publicKey []byte) ([]byte, error) {
 	return proto.Marshal(signedData)
 }
 
-func makeSign(r io.Reader, signedData []byte, pk *rsa.PrivateKey) ([]byte, error) {
+// makeSign signs the CRX3 signed data and the zip archive with an RSA
+// PKCS#1 v1.5 or an ASN.1 ECDSA signature, depending on pk.
+func makeSign(r io.Reader, signedData []byte, pk crypto.Signer) ([]byte, error) {
 	sign := sha256.New()
 	sign.Write([]byte("CRX3 SignedData\x00"))
 	if err := binary.Write(sign, binary.LittleEndian, uint32(len(signedData))); err != nil {
@@ -289,18 +382,21 @@ func makeSign(r io.Reader, signedData []byte, pk *rsa.PrivateKey) ([]byte, error
 	if _, err := io.Copy(sign, r); err != nil {
 		return nil, err
 	}
-	return rsa.SignPKCS1v15(rand.Reader, pk, crypto.SHA256, sign.Sum(nil))
+	return pk.Sign(rand.Reader, sign.Sum(nil), crypto.SHA256)
 }
 
-func makeHeader(pubKey, signature, signedData []byte) ([]byte, error) {
-	header := &pb.CrxFileHeader{
-		Sha256WithRsa: []*pb.AsymmetricKeyProof{
-			{
-				PublicKey: pubKey,
-				Signature: signature,
-			},
+func makeHeader(pk crypto.Signer, pubKey, signature, signedData []byte) ([]byte, error) {
+	proofs := []*pb.AsymmetricKeyProof{
+		{
+			PublicKey: pubKey,
+			Signature: signature,
 		},
-		SignedHeaderData: signedData,
+	}
+	header := &pb.CrxFileHeader{SignedHeaderData: signedData}
+	if _, ok := pk.(*ecdsa.PrivateKey); ok {
+		header.Sha256WithEcdsa = proofs
+	} else {
+		header.Sha256WithRsa = proofs
 	}
 	return proto.Marshal(header)
 }
@@ -2,6 +2,8 @@ package crx3
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/ecdsa"
 	"crypto/rsa"
 	"encoding/pem"
 	"io"
@@ -9,6 +11,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -200,3 +203,37 @@ func TestWritePrivateKey(t *testing.T) {
 		})
 	}
 }
+
+func TestNilSigner(t *testing.T) {
+	zipData, err := os.ReadFile("testdata/withkey.zip")
+	require.NoError(t, err)
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		key  crypto.Signer
+	}{
+		{name: "nil interface"},
+		{name: "nil rsa key", key: (*rsa.PrivateKey)(nil)},
+		{name: "nil ecdsa key", key: (*ecdsa.PrivateKey)(nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, isNilSigner(tt.key))
+			err := PackZipToCRX(bytes.NewReader(zipData), io.Discard, tt.key)
+			assert.Error(t, err)
+			err = Patch("testdata/withkey.crx", filepath.Join(dir, "patched.crx"), tt.key)
+			assert.ErrorIs(t, err, ErrPrivateKeyNotFound)
+			_, err = SetManifestKey("testdata/scanroot/some_extension", tt.key)
+			assert.ErrorIs(t, err, ErrPrivateKeyNotFound)
+		})
+	}
+
+	// a nil PackSigner falls back to the key passed to Pack
+	pk, err := LoadPrivateKey("testdata/withkey.crx.pem")
+	require.NoError(t, err)
+	dst := filepath.Join(dir, "packed.crx")
+	require.NoError(t, Pack("testdata/withkey.zip", dst, pk, PackSigner((*rsa.PrivateKey)(nil))))
+	id, err := ID(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "hpngabcaekcmpoiomblebiidejafakch", id)
+}
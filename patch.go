@@ -0,0 +1,269 @@
+package crx3
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// PatchOp is one change applied by Patch and PatchZip. Operations are applied in order.
+type PatchOp func(*patchFiles) error
+
+// patchFiles is the file list of an archive being patched. Unchanged files
+// are copied to the new archive without being recompressed.
+type patchFiles struct {
+	names []string
+	files map[string]*patchFile
+	now   time.Time
+}
+
+type patchFile struct {
+	orig *zip.File
+	data []byte // nil while the file is unchanged
+}
+
+func (p *patchFiles) read(name string) ([]byte, error) {
+	f, ok := p.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrEntryNotFound, name)
+	}
+	if f.data != nil {
+		return f.data, nil
+	}
+	rc, err := f.orig.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", name, err)
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func (p *patchFiles) put(name string, data []byte) {
+	if data == nil {
+		data = []byte{}
+	}
+	if f, ok := p.files[name]; ok {
+		f.data = data
+		return
+	}
+	p.names = append(p.names, name)
+	p.files[name] = &patchFile{data: data}
+}
+
+func (p *patchFiles) remove(name string) {
+	delete(p.files, name)
+	p.names = slices.DeleteFunc(p.names, func(n string) bool { return n == name })
+}
+
+// editManifest decodes manifest.json, lets fn change it and writes it back
+// indented with two spaces. The order of object keys is kept.
+func (p *patchFiles) editManifest(fn func(root any) (any, error)) error {
+	data, err := p.read(manifestFilename)
+	if err != nil {
+		return err
+	}
+	root, err := decodeOrderedJSON(data)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidManifest, err)
+	}
+	if root, err = fn(root); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := newIndentEncoder(&buf)
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	p.put(manifestFilename, buf.Bytes())
+	return nil
+}
+
+// SetManifestValue returns a PatchOp that sets the manifest value at a
+// dot-separated JSON path such as "update_url" or "content_scripts.0.matches".
+// Missing objects are created; the array index "-" appends to an array.
+// value may be any value that encoding/json can marshal.
+func SetManifestValue(path string, value any) PatchOp {
+	return func(p *patchFiles) error {
+		v, err := toOrderedJSON(value)
+		if err != nil {
+			return fmt.Errorf("set %s: %w", path, err)
+		}
+		return p.editManifest(func(root any) (any, error) {
+			root, err := setJSONPath(root, path, v)
+			if err != nil {
+				return nil, fmt.Errorf("set %w", err)
+			}
+			return root, nil
+		})
+	}
+}
+
+// DeleteManifestValue returns a PatchOp that removes the manifest value at a
+// dot-separated JSON path. It fails if there is no such value.
+func DeleteManifestValue(path string) PatchOp {
+	return func(p *patchFiles) error {
+		return p.editManifest(func(root any) (any, error) {
+			root, err := deleteJSONPath(root, path)
+			if err != nil {
+				return nil, fmt.Errorf("delete %w", err)
+			}
+			return root, nil
+		})
+	}
+}
+
+// PutFile returns a PatchOp that adds the file name or replaces its content.
+func PutFile(name string, data []byte) PatchOp {
+	return func(p *patchFiles) error {
+		name = entryName(name)
+		if len(name) == 0 {
+			return fmt.Errorf("put: empty file name")
+		}
+		p.put(name, data)
+		return nil
+	}
+}
+
+// RemoveFiles returns a PatchOp that removes the files matching pattern.
+// The pattern is matched like in ExtractEntries. It fails if no file matches.
+func RemoveFiles(pattern string) PatchOp {
+	return func(p *patchFiles) error {
+		var matched bool
+		for _, name := range slices.Clone(p.names) {
+			if matchEntry([]string{pattern}, name) {
+				p.remove(name)
+				matched = true
+			}
+		}
+		if !matched {
+			return fmt.Errorf("remove: no files match %q", pattern)
+		}
+		return nil
+	}
+}
+
+// ApplyDiff returns a PatchOp that applies a unified diff, as produced by
+// "diff -u" or "git diff", to the files of the archive. The diff may create,
+// change and delete several files. Hunks must match exactly but may have moved.
+func ApplyDiff(diff []byte) PatchOp {
+	return func(p *patchFiles) error {
+		patches, err := parseUnifiedDiff(diff)
+		if err != nil {
+			return fmt.Errorf("diff: %w", err)
+		}
+		for _, fp := range patches {
+			switch {
+			case fp.newName == devNull:
+				if _, ok := p.files[fp.oldName]; !ok {
+					return fmt.Errorf("diff: %w: %s", ErrEntryNotFound, fp.oldName)
+				}
+				p.remove(fp.oldName)
+			case fp.oldName == devNull:
+				data, err := applyHunks(nil, fp.hunks)
+				if err != nil {
+					return fmt.Errorf("diff: %s: %w", fp.newName, err)
+				}
+				p.put(fp.newName, data)
+			default:
+				data, err := p.read(fp.oldName)
+				if err != nil {
+					return fmt.Errorf("diff: %w", err)
+				}
+				if data, err = applyHunks(data, fp.hunks); err != nil {
+					return fmt.Errorf("diff: %s: %w", fp.oldName, err)
+				}
+				if fp.oldName != fp.newName {
+					p.remove(fp.oldName)
+				}
+				p.put(fp.newName, data)
+			}
+		}
+		return nil
+	}
+}
+
+// PatchZip applies the operations to the files of the ZIP archive r and writes
+// the resulting archive to w. Unchanged files are copied without being
+// recompressed; directory entries are dropped.
+func PatchZip(r *zip.Reader, w io.Writer, ops ...PatchOp) error {
+	p := &patchFiles{files: make(map[string]*patchFile), now: time.Now()}
+	for _, f := range r.File {
+		name := entryName(f.Name)
+		if f.FileInfo().IsDir() || isMarkerFile(name) {
+			continue
+		}
+		if _, ok := p.files[name]; ok {
+			return fmt.Errorf("crx3/patch: duplicate file %s", name)
+		}
+		p.names = append(p.names, name)
+		p.files[name] = &patchFile{orig: f}
+	}
+	for _, op := range ops {
+		if err := op(p); err != nil {
+			return fmt.Errorf("crx3/patch: %w", err)
+		}
+	}
+
+	zw := zip.NewWriter(w)
+	for _, name := range p.names {
+		f := p.files[name]
+		if f.data == nil {
+			if err := zw.Copy(f.orig); err != nil {
+				return fmt.Errorf("crx3/patch: failed to copy %s: %w", name, err)
+			}
+			continue
+		}
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: p.now})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// Patch applies the operations to the extension in the CRX3 file or ZIP
+// archive src and writes the result to dst as a CRX3 file signed with pk, an
+// RSA or ECDSA private key.
+// src and dst may be the same file. The extension ID follows pk unless the
+// manifest has a key field.
+func Patch(src string, dst string, pk crypto.Signer, ops ...PatchOp) error {
+	if isNilSigner(pk) {
+		return fmt.Errorf("%w: to sign %s", ErrPrivateKeyNotFound, dst)
+	}
+	r, err := openArchive(src)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	err = PatchZip(&r.Reader, &buf, ops...)
+	r.Close()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := PackZipToCRX(bytes.NewReader(buf.Bytes()), tmp, pk); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
@@ -0,0 +1,276 @@
+package crx3
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const patchManifest = `{
+  "name": "demo",
+  "version": "1.0",
+  "manifest_version": 3,
+  "permissions": ["storage"],
+  "content_scripts": [{"matches": ["<all_urls>"], "js": ["content.js"]}]
+}
+`
+
+func newPatchZip(t *testing.T, files map[string]string) *zip.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{manifestFilename, "content.js", "js/a.js", "js/b.js", "crx3.id"} {
+		content, ok := files[name]
+		if !ok {
+			continue
+		}
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	return r
+}
+
+func readPatchedZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		_, err = buf.ReadFrom(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = buf.String()
+	}
+	return files
+}
+
+func TestPatchZip(t *testing.T) {
+	source := map[string]string{
+		manifestFilename: patchManifest,
+		"content.js":     "console.log(1);\n",
+		"js/a.js":        "a\n",
+		"js/b.js":        "b\n",
+		"crx3.id":        "abc",
+	}
+	tests := []struct {
+		name     string
+		ops      []PatchOp
+		expected map[string]string
+		err      string
+	}{
+		{
+			name: "set and delete manifest values",
+			ops: []PatchOp{
+				SetManifestValue("version", "1.1"),
+				SetManifestValue("permissions.-", "tabs"),
+				SetManifestValue("content_scripts.0.run_at", "document_end"),
+				SetManifestValue("background.service_worker", "sw.js"),
+				DeleteManifestValue("manifest_version"),
+			},
+			expected: map[string]string{
+				manifestFilename: `{
+  "name": "demo",
+  "version": "1.1",
+  "permissions": [
+    "storage",
+    "tabs"
+  ],
+  "content_scripts": [
+    {
+      "matches": [
+        "<all_urls>"
+      ],
+      "js": [
+        "content.js"
+      ],
+      "run_at": "document_end"
+    }
+  ],
+  "background": {
+    "service_worker": "sw.js"
+  }
+}
+`,
+				"content.js": "console.log(1);\n",
+				"js/a.js":    "a\n",
+				"js/b.js":    "b\n",
+			},
+		},
+		{
+			name: "put and remove files",
+			ops: []PatchOp{
+				PutFile("content.js", []byte("console.log(2);\n")),
+				PutFile("./sw.js", []byte("self.x = 1;\n")),
+				RemoveFiles("js"),
+			},
+			expected: map[string]string{
+				manifestFilename: patchManifest,
+				"content.js":     "console.log(2);\n",
+				"sw.js":          "self.x = 1;\n",
+			},
+		},
+		{
+			name: "apply diff",
+			ops: []PatchOp{ApplyDiff([]byte(`diff --git a/content.js b/content.js
+--- a/content.js
++++ b/content.js
+@@ -1 +1,2 @@
+ console.log(1);
++console.log(2);
+--- a/js/a.js
++++ /dev/null
+@@ -1 +0,0 @@
+-a
+--- /dev/null
++++ b/js/c.js
+@@ -0,0 +1 @@
++c
+`))},
+			expected: map[string]string{
+				manifestFilename: patchManifest,
+				"content.js":     "console.log(1);\nconsole.log(2);\n",
+				"js/b.js":        "b\n",
+				"js/c.js":        "c\n",
+			},
+		},
+		{
+			name: "set unknown array index",
+			ops:  []PatchOp{SetManifestValue("permissions.5", "tabs")},
+			err:  `crx3/patch: set permissions.5: index "5" out of range`,
+		},
+		{
+			name: "set inside a string",
+			ops:  []PatchOp{SetManifestValue("name.short", "d")},
+			err:  `crx3/patch: set name.short: "short" is not an object or array`,
+		},
+		{
+			name: "delete missing value",
+			ops:  []PatchOp{DeleteManifestValue("update_url")},
+			err:  "crx3/patch: delete update_url: not found",
+		},
+		{
+			name: "invalid path",
+			ops:  []PatchOp{SetManifestValue("a..b", 1)},
+			err:  "crx3/patch: set a..b: invalid JSON path",
+		},
+		{
+			name: "remove without match",
+			ops:  []PatchOp{RemoveFiles("*.css")},
+			err:  `crx3/patch: remove: no files match "*.css"`,
+		},
+		{
+			name: "diff does not apply",
+			ops: []PatchOp{ApplyDiff([]byte(`--- a/content.js
++++ b/content.js
+@@ -1 +1 @@
+-console.log(3);
++console.log(2);
+`))},
+			err: "crx3/patch: diff: content.js: hunk 1 (line 1) does not apply",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := PatchZip(newPatchZip(t, source), &buf, tt.ops...)
+			if len(tt.err) > 0 {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, readPatchedZip(t, buf.Bytes()))
+		})
+	}
+}
+
+func TestPatchZip_KeepsUnchangedFiles(t *testing.T) {
+	r := newPatchZip(t, map[string]string{manifestFilename: patchManifest, "content.js": "x\n"})
+	var buf bytes.Buffer
+	require.NoError(t, PatchZip(r, &buf))
+	out, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, out.File, 2)
+	for i, f := range out.File {
+		assert.Equal(t, r.File[i].Name, f.Name)
+		assert.Equal(t, r.File[i].CRC32, f.CRC32)
+		assert.Equal(t, r.File[i].Modified, f.Modified)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	pk, err := LoadPrivateKey("testdata/withkey.crx.pem")
+	require.NoError(t, err)
+	dst := filepath.Join(t.TempDir(), "patched.crx")
+
+	err = Patch("testdata/withkey.crx", dst, pk,
+		SetManifestValue("version", "1000"),
+		DeleteManifestValue("key"),
+		PutFile("options.html", []byte("<html></html>\n")),
+	)
+	require.NoError(t, err)
+
+	info, err := Inspect(dst)
+	require.NoError(t, err)
+	assert.True(t, info.OK(), info.Problems)
+	require.Len(t, info.Proofs, 1)
+	assert.True(t, info.Proofs[0].MatchesCrxID)
+
+	manifest, err := ReadEntry(dst, manifestFilename)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"manifest_version\": 2,\n  \"name\": \"go-crx3\",\n  \"version\": \"1000\"\n}\n", string(manifest))
+	data, err := ReadEntry(dst, "options.html")
+	require.NoError(t, err)
+	assert.Equal(t, "<html></html>\n", string(data))
+	_, err = ReadEntry(dst, "images/image.jpeg")
+	assert.NoError(t, err)
+
+	st, err := os.Stat(dst)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), st.Mode().Perm())
+}
+
+func TestPatch_ECDSA(t *testing.T) {
+	key, err := LoadKey("testdata/keys/ec_sec1.pem")
+	require.NoError(t, err)
+	keyID, err := KeyID(key)
+	require.NoError(t, err)
+	dst := filepath.Join(t.TempDir(), "patched.crx")
+
+	require.NoError(t, Patch("testdata/withkey.crx", dst, key, DeleteManifestValue("key")))
+	info, err := Inspect(dst)
+	require.NoError(t, err)
+	assert.True(t, info.OK(), info.Problems)
+	require.Len(t, info.Proofs, 1)
+	assert.Equal(t, AlgorithmECDSA, info.Proofs[0].Algorithm)
+	assert.True(t, info.Proofs[0].MatchesCrxID)
+	id, err := ID(dst)
+	require.NoError(t, err)
+	assert.Equal(t, keyID, id)
+}
+
+func TestPatch_Errors(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "patched.crx")
+	err := Patch("testdata/withkey.crx", dst, nil)
+	assert.ErrorIs(t, err, ErrPrivateKeyNotFound)
+
+	pk, err := LoadPrivateKey("testdata/withkey.crx.pem")
+	require.NoError(t, err)
+	err = Patch("testdata/missing.crx", dst, pk)
+	assert.ErrorIs(t, err, ErrPathNotFound)
+	err = Patch("testdata/withkey.crx", dst, pk, RemoveFiles("missing.js"))
+	assert.Error(t, err)
+	assert.NoFileExists(t, dst)
+}
@@ -0,0 +1,222 @@
+package crx3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// WebStoreUpdateURL is the update URL of the Chrome Web Store.
+const WebStoreUpdateURL = "https://clients2.google.com/service/update2/crx"
+
+const (
+	policyForcelistKey = "ExtensionInstallForcelist"
+	policySettingsKey  = "ExtensionSettings"
+	policyDefaultScope = "*"
+)
+
+// Installation modes supported by the ExtensionSettings policy.
+const (
+	InstallModeAllowed         = "allowed"
+	InstallModeBlocked         = "blocked"
+	InstallModeForceInstalled  = "force_installed"
+	InstallModeNormalInstalled = "normal_installed"
+	InstallModeRemoved         = "removed"
+)
+
+// ExtensionSettings is a single entry of the ExtensionSettings enterprise policy.
+type ExtensionSettings struct {
+	InstallationMode    string   `json:"installation_mode,omitempty"`
+	UpdateURL           string   `json:"update_url,omitempty"`
+	AllowedPermissions  []string `json:"allowed_permissions,omitempty"`
+	BlockedPermissions  []string `json:"blocked_permissions,omitempty"`
+	RuntimeAllowedHosts []string `json:"runtime_allowed_hosts,omitempty"`
+	RuntimeBlockedHosts []string `json:"runtime_blocked_hosts,omitempty"`
+}
+
+// Policy represents a Chrome managed policy file, such as
+// /etc/opt/chrome/policies/managed/extensions.json on Linux.
+// Only the extension related policies are modelled; any other policy
+// found in a loaded file is kept as is and written back unchanged.
+type Policy struct {
+	ExtensionInstallForcelist []string
+	ExtensionSettings         map[string]ExtensionSettings
+
+	other map[string]json.RawMessage
+}
+
+// LoadPolicy reads a policy file from disk.
+func LoadPolicy(filename string) (*Policy, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	p := new(Policy)
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("crx3/policy: failed to parse %s: %w", filename, err)
+	}
+	return p, nil
+}
+
+// AddExtension adds or replaces the settings for the extension with the given ID.
+// Extensions with the force_installed mode are also added to ExtensionInstallForcelist.
+// If the settings have no update URL, the Chrome Web Store URL is used.
+func (p *Policy) AddExtension(id string, settings ExtensionSettings) error {
+	if !IsValidExtensionID(id) {
+		return fmt.Errorf("crx3/policy: invalid extension id %q", id)
+	}
+	if len(settings.InstallationMode) == 0 {
+		settings.InstallationMode = InstallModeForceInstalled
+	}
+	if len(settings.UpdateURL) == 0 && needsUpdateURL(settings.InstallationMode) {
+		settings.UpdateURL = WebStoreUpdateURL
+	}
+	if p.ExtensionSettings == nil {
+		p.ExtensionSettings = make(map[string]ExtensionSettings)
+	}
+	p.ExtensionSettings[id] = settings
+	p.removeFromForcelist(id)
+	if settings.InstallationMode == InstallModeForceInstalled {
+		p.ExtensionInstallForcelist = append(p.ExtensionInstallForcelist, id+";"+settings.UpdateURL)
+	}
+	return nil
+}
+
+// Merge merges other into p. Settings and forcelist entries from other
+// take precedence over the ones already present in p.
+func (p *Policy) Merge(other *Policy) {
+	if other == nil {
+		return
+	}
+	for _, entry := range other.ExtensionInstallForcelist {
+		id, _, _ := strings.Cut(entry, ";")
+		p.removeFromForcelist(id)
+		p.ExtensionInstallForcelist = append(p.ExtensionInstallForcelist, entry)
+	}
+	if len(other.ExtensionSettings) > 0 && p.ExtensionSettings == nil {
+		p.ExtensionSettings = make(map[string]ExtensionSettings)
+	}
+	maps.Copy(p.ExtensionSettings, other.ExtensionSettings)
+	if len(other.other) > 0 && p.other == nil {
+		p.other = make(map[string]json.RawMessage)
+	}
+	maps.Copy(p.other, other.other)
+}
+
+// Validate checks the extension policies the way Chrome would when loading them.
+// All problems found are returned joined together.
+func (p *Policy) Validate() error {
+	var errs []error
+	for i, entry := range p.ExtensionInstallForcelist {
+		id, updateURL, _ := strings.Cut(entry, ";")
+		if !IsValidExtensionID(id) {
+			errs = append(errs, fmt.Errorf("crx3/policy: %s[%d]: invalid extension id %q",
+				policyForcelistKey, i, id))
+		}
+		if strings.Contains(entry, ";") && len(updateURL) == 0 {
+			errs = append(errs, fmt.Errorf("crx3/policy: %s[%d]: empty update url",
+				policyForcelistKey, i))
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(p.ExtensionSettings)) {
+		settings := p.ExtensionSettings[id]
+		if id != policyDefaultScope && !IsValidExtensionID(id) {
+			errs = append(errs, fmt.Errorf("crx3/policy: %s: invalid extension id %q",
+				policySettingsKey, id))
+		}
+		switch settings.InstallationMode {
+		case "", InstallModeAllowed, InstallModeBlocked, InstallModeRemoved:
+		case InstallModeForceInstalled, InstallModeNormalInstalled:
+			if id == policyDefaultScope {
+				errs = append(errs, fmt.Errorf("crx3/policy: %s: %q can't use installation mode %q",
+					policySettingsKey, id, settings.InstallationMode))
+			}
+			if len(settings.UpdateURL) == 0 {
+				errs = append(errs, fmt.Errorf("crx3/policy: %s: %q requires update_url for mode %q",
+					policySettingsKey, id, settings.InstallationMode))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("crx3/policy: %s: %q has unknown installation mode %q",
+				policySettingsKey, id, settings.InstallationMode))
+		}
+		for _, perm := range settings.AllowedPermissions {
+			if slices.Contains(settings.BlockedPermissions, perm) {
+				errs = append(errs, fmt.Errorf("crx3/policy: %s: %q permission %q is both allowed and blocked",
+					policySettingsKey, id, perm))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p Policy) MarshalJSON() ([]byte, error) {
+	out := make(map[string]any, len(p.other)+2)
+	for k, v := range p.other {
+		out[k] = v
+	}
+	if len(p.ExtensionInstallForcelist) > 0 {
+		out[policyForcelistKey] = p.ExtensionInstallForcelist
+	}
+	if len(p.ExtensionSettings) > 0 {
+		out[policySettingsKey] = p.ExtensionSettings
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *Policy) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*p = Policy{}
+	if v, ok := raw[policyForcelistKey]; ok {
+		if err := json.Unmarshal(v, &p.ExtensionInstallForcelist); err != nil {
+			return fmt.Errorf("%s: %w", policyForcelistKey, err)
+		}
+		delete(raw, policyForcelistKey)
+	}
+	if v, ok := raw[policySettingsKey]; ok {
+		if err := json.Unmarshal(v, &p.ExtensionSettings); err != nil {
+			return fmt.Errorf("%s: %w", policySettingsKey, err)
+		}
+		delete(raw, policySettingsKey)
+	}
+	if len(raw) > 0 {
+		p.other = raw
+	}
+	return nil
+}
+
+// WritePolicyFile writes the policy as indented JSON to filename.
+func WritePolicyFile(filename string, p *Policy) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// ResolveExtensionID returns the extension ID for src, which can be either
+// an extension ID, a CRX3 file, a ZIP archive or an unpacked directory.
+func ResolveExtensionID(src string) (string, error) {
+	if IsValidExtensionID(src) && !fileExists(src) && !dirExists(src) {
+		return src, nil
+	}
+	return Extension(src).ID()
+}
+
+func (p *Policy) removeFromForcelist(id string) {
+	p.ExtensionInstallForcelist = slices.DeleteFunc(p.ExtensionInstallForcelist, func(entry string) bool {
+		return entry == id || strings.HasPrefix(entry, id+";")
+	})
+}
+
+func needsUpdateURL(mode string) bool {
+	return mode == InstallModeForceInstalled || mode == InstallModeNormalInstalled
+}
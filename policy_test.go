@@ -0,0 +1,125 @@
+package crx3
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_AddExtension(t *testing.T) {
+	p := new(Policy)
+	require.Error(t, p.AddExtension("invalid", ExtensionSettings{}))
+
+	id := "kpkcennohgffjdgaelocingbmkjnpjgc"
+	require.NoError(t, p.AddExtension(id, ExtensionSettings{
+		BlockedPermissions:  []string{"debugger"},
+		RuntimeBlockedHosts: []string{"*://*.example.com"},
+	}))
+	assert.Equal(t, []string{id + ";" + WebStoreUpdateURL}, p.ExtensionInstallForcelist)
+	assert.Equal(t, InstallModeForceInstalled, p.ExtensionSettings[id].InstallationMode)
+	assert.NoError(t, p.Validate())
+
+	// replacing with a non forced mode removes the forcelist entry
+	require.NoError(t, p.AddExtension(id, ExtensionSettings{InstallationMode: InstallModeBlocked}))
+	assert.Empty(t, p.ExtensionInstallForcelist)
+	assert.Empty(t, p.ExtensionSettings[id].UpdateURL)
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		wantErr bool
+	}{
+		{
+			name: "should pass default scope settings",
+			policy: Policy{ExtensionSettings: map[string]ExtensionSettings{
+				"*": {InstallationMode: InstallModeBlocked},
+			}},
+		},
+		{
+			name:    "should return error when forcelist id is invalid",
+			policy:  Policy{ExtensionInstallForcelist: []string{"bad;" + WebStoreUpdateURL}},
+			wantErr: true,
+		},
+		{
+			name:    "should return error when forcelist update url is empty",
+			policy:  Policy{ExtensionInstallForcelist: []string{"kpkcennohgffjdgaelocingbmkjnpjgc;"}},
+			wantErr: true,
+		},
+		{
+			name: "should return error when installation mode is unknown",
+			policy: Policy{ExtensionSettings: map[string]ExtensionSettings{
+				"kpkcennohgffjdgaelocingbmkjnpjgc": {InstallationMode: "forced"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "should return error when forced extension has no update url",
+			policy: Policy{ExtensionSettings: map[string]ExtensionSettings{
+				"kpkcennohgffjdgaelocingbmkjnpjgc": {InstallationMode: InstallModeForceInstalled},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "should return error when permission is allowed and blocked",
+			policy: Policy{ExtensionSettings: map[string]ExtensionSettings{
+				"kpkcennohgffjdgaelocingbmkjnpjgc": {AllowedPermissions: []string{"tabs"}, BlockedPermissions: []string{"tabs"}},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPolicy_MergeKeepsOtherPolicies(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "policy.json")
+	require.NoError(t, os.WriteFile(filename, []byte(`{
+		"HomepageLocation": "https://example.com",
+		"ExtensionInstallForcelist": ["kpkcennohgffjdgaelocingbmkjnpjgc;https://old.example.com/update"]
+	}`), 0644))
+
+	existing, err := LoadPolicy(filename)
+	require.NoError(t, err)
+
+	generated := new(Policy)
+	require.NoError(t, generated.AddExtension("kpkcennohgffjdgaelocingbmkjnpjgc", ExtensionSettings{}))
+	existing.Merge(generated)
+
+	require.NoError(t, WritePolicyFile(filename, existing))
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	var out map[string]any
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, "https://example.com", out["HomepageLocation"])
+	assert.Equal(t, []any{"kpkcennohgffjdgaelocingbmkjnpjgc;" + WebStoreUpdateURL}, out["ExtensionInstallForcelist"])
+	assert.Contains(t, out, "ExtensionSettings")
+}
+
+func TestResolveExtensionID(t *testing.T) {
+	id, err := ResolveExtensionID("kpkcennohgffjdgaelocingbmkjnpjgc")
+	require.NoError(t, err)
+	assert.Equal(t, "kpkcennohgffjdgaelocingbmkjnpjgc", id)
+
+	id, err = ResolveExtensionID("./testdata/dodyDol.crx")
+	require.NoError(t, err)
+	assert.Equal(t, "kpkcennohgffjdgaelocingbmkjnpjgc", id)
+
+	_, err = ResolveExtensionID("./testdata/notfound")
+	assert.Error(t, err)
+}
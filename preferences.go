@@ -0,0 +1,185 @@
+package crx3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	preferencesFilename       = "Preferences"
+	securePreferencesFilename = "Secure Preferences"
+
+	// windowsToUnixEpoch is the number of seconds between
+	// 1601-01-01 (base::Time epoch) and 1970-01-01.
+	windowsToUnixEpoch = 11644473600
+)
+
+// Install sources reported in ExtensionState.InstallSource.
+const (
+	InstallSourceWebStore    = "webstore"
+	InstallSourceInternal    = "internal"
+	InstallSourcePolicy      = "policy"
+	InstallSourceUnpacked    = "unpacked"
+	InstallSourceCommandLine = "command_line"
+	InstallSourceExternal    = "external"
+	InstallSourceComponent   = "component"
+	InstallSourceUnknown     = "unknown"
+)
+
+// ExtensionState is the per-profile state of an extension stored by Chrome in
+// the extensions.settings section of the Preferences and Secure Preferences files.
+type ExtensionState struct {
+	Enabled            bool      `json:"enabled"`
+	InstallSource      string    `json:"installSource"`
+	Location           int       `json:"location"`
+	FromWebStore       bool      `json:"fromWebStore"`
+	GrantedPermissions []string  `json:"grantedPermissions,omitempty"`
+	GrantedHosts       []string  `json:"grantedHosts,omitempty"`
+	InstallTime        time.Time `json:"installTime,omitzero"`
+	Path               string    `json:"path,omitempty"`
+}
+
+type extensionPrefs struct {
+	State              *int            `json:"state"`
+	DisableReasons     json.RawMessage `json:"disable_reasons"`
+	Location           int             `json:"location"`
+	FromWebStore       bool            `json:"from_webstore"`
+	InstallTime        string          `json:"install_time"`
+	Path               string          `json:"path"`
+	GrantedPermissions struct {
+		API                 []string `json:"api"`
+		ManifestPermissions []string `json:"manifest_permissions"`
+		ExplicitHost        []string `json:"explicit_host"`
+		ScriptableHost      []string `json:"scriptable_host"`
+	} `json:"granted_permissions"`
+}
+
+// ReadProfileState reads the extension state of a browser profile directory
+// from its Preferences and Secure Preferences files. Settings found in
+// Secure Preferences take precedence. The result is keyed by extension ID.
+func ReadProfileState(profileDir string) (map[string]*ExtensionState, error) {
+	merged := make(map[string]map[string]json.RawMessage)
+	var found bool
+	for _, name := range []string{preferencesFilename, securePreferencesFilename} {
+		settings, err := readExtensionSettings(filepath.Join(profileDir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+		for id, fields := range settings {
+			if merged[id] == nil {
+				merged[id] = make(map[string]json.RawMessage)
+			}
+			maps.Copy(merged[id], fields)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("crx3/profile: no preferences found in %s: %w", profileDir, os.ErrNotExist)
+	}
+
+	states := make(map[string]*ExtensionState, len(merged))
+	for id, fields := range merged {
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		var prefs extensionPrefs
+		if err := json.Unmarshal(data, &prefs); err != nil {
+			return nil, fmt.Errorf("crx3/profile: invalid settings for extension %s: %w", id, err)
+		}
+		states[id] = prefs.state()
+	}
+	return states, nil
+}
+
+func readExtensionSettings(filename string) (map[string]map[string]json.RawMessage, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var prefs struct {
+		Extensions struct {
+			Settings map[string]map[string]json.RawMessage `json:"settings"`
+		} `json:"extensions"`
+	}
+	if err := json.Unmarshal(data, &prefs); err != nil {
+		return nil, fmt.Errorf("crx3/profile: failed to parse %s: %w", filename, err)
+	}
+	return prefs.Extensions.Settings, nil
+}
+
+func (p *extensionPrefs) state() *ExtensionState {
+	granted := p.GrantedPermissions
+	hosts := slices.Concat(granted.ExplicitHost, granted.ScriptableHost)
+	slices.Sort(hosts)
+	return &ExtensionState{
+		Enabled:            p.enabled(),
+		InstallSource:      installSource(p.Location, p.FromWebStore),
+		Location:           p.Location,
+		FromWebStore:       p.FromWebStore,
+		GrantedPermissions: slices.Concat(granted.API, granted.ManifestPermissions),
+		GrantedHosts:       slices.Compact(hosts),
+		InstallTime:        chromeTime(p.InstallTime),
+		Path:               p.Path,
+	}
+}
+
+func (p *extensionPrefs) enabled() bool {
+	if p.State != nil && *p.State == 0 {
+		return false
+	}
+	if len(p.DisableReasons) == 0 {
+		return true
+	}
+	var mask int
+	if err := json.Unmarshal(p.DisableReasons, &mask); err == nil {
+		return mask == 0
+	}
+	var reasons []int
+	if err := json.Unmarshal(p.DisableReasons, &reasons); err == nil {
+		return len(reasons) == 0
+	}
+	return true
+}
+
+// installSource maps Chrome's ManifestLocation enum to an install source.
+func installSource(location int, fromWebStore bool) string {
+	switch location {
+	case 1:
+		if fromWebStore {
+			return InstallSourceWebStore
+		}
+		return InstallSourceInternal
+	case 2, 3, 6:
+		return InstallSourceExternal
+	case 4:
+		return InstallSourceUnpacked
+	case 5, 10:
+		return InstallSourceComponent
+	case 7, 9:
+		return InstallSourcePolicy
+	case 8:
+		return InstallSourceCommandLine
+	}
+	return InstallSourceUnknown
+}
+
+// chromeTime converts a base::Time value serialized as microseconds
+// since 1601-01-01 UTC into a time.Time.
+func chromeTime(s string) time.Time {
+	usec, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || usec <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMicro(usec - windowsToUnixEpoch*1_000_000).UTC()
+}
@@ -0,0 +1,115 @@
+package crx3
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testPreferences = `{
+	"extensions": {
+		"settings": {
+			"kpkcennohgffjdgaelocingbmkjnpjgc": {
+				"location": 1,
+				"from_webstore": true,
+				"install_time": "13345678901234567",
+				"path": "kpkcennohgffjdgaelocingbmkjnpjgc/2.1.0_0",
+				"granted_permissions": {
+					"api": ["tabs", "storage"],
+					"explicit_host": ["https://*.example.com/*"],
+					"scriptable_host": ["https://*.example.com/*", "https://a.test/*"]
+				}
+			},
+			"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa": {
+				"location": 9,
+				"disable_reasons": [1]
+			}
+		}
+	}
+}`
+	testSecurePreferences = `{
+	"extensions": {
+		"settings": {
+			"kpkcennohgffjdgaelocingbmkjnpjgc": {
+				"state": 0
+			},
+			"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb": {
+				"location": 4,
+				"path": "/home/dev/my-extension"
+			}
+		}
+	}
+}`
+)
+
+func TestReadProfileState(t *testing.T) {
+	dir := t.TempDir()
+	makeProfileFixture(t, dir, map[string]string{
+		"Preferences":        testPreferences,
+		"Secure Preferences": testSecurePreferences,
+	})
+
+	states, err := ReadProfileState(dir)
+	require.NoError(t, err)
+	require.Len(t, states, 3)
+
+	webstore := states["kpkcennohgffjdgaelocingbmkjnpjgc"]
+	assert.False(t, webstore.Enabled)
+	assert.Equal(t, InstallSourceWebStore, webstore.InstallSource)
+	assert.Equal(t, []string{"tabs", "storage"}, webstore.GrantedPermissions)
+	assert.Equal(t, []string{"https://*.example.com/*", "https://a.test/*"}, webstore.GrantedHosts)
+	assert.Equal(t, time.Date(2023, 11, 28, 21, 1, 41, 234567000, time.UTC), webstore.InstallTime)
+
+	policy := states["aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"]
+	assert.False(t, policy.Enabled)
+	assert.Equal(t, InstallSourcePolicy, policy.InstallSource)
+
+	unpacked := states["bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"]
+	assert.True(t, unpacked.Enabled)
+	assert.Equal(t, InstallSourceUnpacked, unpacked.InstallSource)
+	assert.True(t, unpacked.InstallTime.IsZero())
+}
+
+func TestReadProfileState_Errors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := ReadProfileState(dir)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Preferences"), []byte(`{`), 0644))
+	_, err = ReadProfileState(dir)
+	assert.Error(t, err)
+}
+
+func TestScanBrowserProfiles_WithProfileState(t *testing.T) {
+	root := t.TempDir()
+	unpackedDir := filepath.Join(root, "dev-extension")
+	makeProfileFixture(t, root, map[string]string{
+		"Default/Extensions/kpkcennohgffjdgaelocingbmkjnpjgc/2.1.0_0/manifest.json": `{"version":"2.1.0"}`,
+		"Default/Preferences":         testPreferences,
+		"dev-extension/manifest.json": `{"name":"Dev","version":"0.1"}`,
+		"Profile 2/Preferences": `{"extensions":{"settings":{"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb":{"location":4,"path":"` +
+			filepath.ToSlash(unpackedDir) + `"}}}}`,
+	})
+
+	var got []*InstalledExtension
+	for ext, err := range ScanBrowserProfiles([]Browser{{Name: "chrome", UserDataDir: root}}, WithProfileState()) {
+		require.NoError(t, err)
+		got = append(got, ext)
+	}
+	require.Len(t, got, 2)
+
+	require.NotNil(t, got[0].State)
+	assert.Equal(t, InstallSourceWebStore, got[0].State.InstallSource)
+	assert.True(t, got[0].State.Enabled)
+
+	assert.Equal(t, "Profile 2", got[1].Profile)
+	assert.Equal(t, unpackedDir, got[1].Path)
+	assert.Equal(t, "Dev", got[1].Name)
+	assert.Equal(t, "0.1", got[1].Version)
+	assert.Equal(t, InstallSourceUnpacked, got[1].State.InstallSource)
+}
@@ -0,0 +1,225 @@
+package crx3
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const profileExtensionsDir = "Extensions"
+
+// Browser describes the user data directory of a Chromium-family browser.
+type Browser struct {
+	Name        string `json:"name"`
+	UserDataDir string `json:"userDataDir"`
+}
+
+// InstalledExtension describes an extension installed into a browser profile.
+// State is only set when scanning with WithProfileState.
+type InstalledExtension struct {
+	Browser string          `json:"browser"`
+	Profile string          `json:"profile"`
+	ID      string          `json:"id"`
+	Version string          `json:"version"`
+	Name    string          `json:"name,omitempty"`
+	Path    string          `json:"path"`
+	State   *ExtensionState `json:"state,omitempty"`
+}
+
+func (e *InstalledExtension) String() string {
+	return fmt.Sprintf(
+		"InstalledExtension{Browser: %s, Profile: %s, ID: %s, Version: %s, Name: %s, Path: %s}",
+		e.Browser, e.Profile, e.ID, e.Version, e.Name, e.Path)
+}
+
+// ProfileOption is a function that configures ScanBrowserProfiles.
+type ProfileOption func(*profileOptions)
+
+type profileOptions struct {
+	withState bool
+}
+
+// WithProfileState returns a ProfileOption that joins every installed extension
+// with its state from the profile Preferences and Secure Preferences files.
+// Unpacked extensions loaded in developer mode live outside of the profile
+// Extensions directory and are only reported with this option.
+func WithProfileState() ProfileOption {
+	return func(o *profileOptions) {
+		o.withState = true
+	}
+}
+
+// LinuxBrowsers returns the default user data directories of Chrome, Chromium,
+// Brave, Edge, Vivaldi and Opera on Linux for the given home directory.
+// Directories are returned whether or not they exist.
+func LinuxBrowsers(home string) []Browser {
+	config := filepath.Join(home, ".config")
+	return []Browser{
+		{Name: "chrome", UserDataDir: filepath.Join(config, "google-chrome")},
+		{Name: "chrome-beta", UserDataDir: filepath.Join(config, "google-chrome-beta")},
+		{Name: "chrome-unstable", UserDataDir: filepath.Join(config, "google-chrome-unstable")},
+		{Name: "chromium", UserDataDir: filepath.Join(config, "chromium")},
+		{Name: "brave", UserDataDir: filepath.Join(config, "BraveSoftware", "Brave-Browser")},
+		{Name: "edge", UserDataDir: filepath.Join(config, "microsoft-edge")},
+		{Name: "vivaldi", UserDataDir: filepath.Join(config, "vivaldi")},
+		{Name: "opera", UserDataDir: filepath.Join(config, "opera")},
+	}
+}
+
+// ScanBrowserProfiles walks the profiles of the given browsers and yields every
+// installed extension found in <user-data-dir>/<profile>/Extensions/<id>/<version>_N.
+// A user data directory that itself contains an Extensions directory, like Opera's,
+// is treated as a single profile. Browsers whose user data directory does not
+// exist are skipped silently. Other errors are yielded and scanning continues.
+func ScanBrowserProfiles(browsers []Browser, opts ...ProfileOption) iter.Seq2[*InstalledExtension, error] {
+	conf := new(profileOptions)
+	for _, opt := range opts {
+		opt(conf)
+	}
+	return func(yield func(*InstalledExtension, error) bool) {
+		for _, browser := range browsers {
+			if !dirExists(browser.UserDataDir) {
+				continue
+			}
+			for _, profile := range browserProfiles(browser.UserDataDir, conf.withState) {
+				for ext, err := range scanProfileExtensions(browser, profile, conf) {
+					if !yield(ext, err) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// browserProfiles returns the profile directories of a user data directory.
+// With withPrefs, directories holding only a Preferences file count as profiles too.
+func browserProfiles(userDataDir string, withPrefs bool) []string {
+	isProfile := func(dir string) bool {
+		return dirExists(filepath.Join(dir, profileExtensionsDir)) ||
+			withPrefs && fileExists(filepath.Join(dir, preferencesFilename))
+	}
+	var profiles []string
+	if isProfile(userDataDir) {
+		profiles = append(profiles, userDataDir)
+	}
+	entries, err := os.ReadDir(userDataDir)
+	if err != nil {
+		return profiles
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		profile := filepath.Join(userDataDir, entry.Name())
+		if isProfile(profile) {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
+func scanProfileExtensions(browser Browser, profile string, conf *profileOptions) iter.Seq2[*InstalledExtension, error] {
+	return func(yield func(*InstalledExtension, error) bool) {
+		profileName := filepath.Base(profile)
+		if profile == browser.UserDataDir {
+			profileName = "Default"
+		}
+		var states map[string]*ExtensionState
+		if conf.withState {
+			var err error
+			states, err = ReadProfileState(profile)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				if !yield(nil, err) {
+					return
+				}
+			}
+		}
+		seen := make(map[string]bool)
+		extensionsDir := filepath.Join(profile, profileExtensionsDir)
+		ids, err := os.ReadDir(extensionsDir)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			if !yield(nil, fmt.Errorf("crx3/profile: failed to read %s: %w", extensionsDir, err)) {
+				return
+			}
+		}
+		for _, idEntry := range ids {
+			if !idEntry.IsDir() || !IsValidExtensionID(idEntry.Name()) {
+				continue
+			}
+			idDir := filepath.Join(extensionsDir, idEntry.Name())
+			versions, err := os.ReadDir(idDir)
+			if err != nil {
+				if !yield(nil, fmt.Errorf("crx3/profile: failed to read %s: %w", idDir, err)) {
+					return
+				}
+				continue
+			}
+			for _, versionEntry := range versions {
+				if !versionEntry.IsDir() {
+					continue
+				}
+				path := filepath.Join(idDir, versionEntry.Name())
+				ext := &InstalledExtension{
+					Browser: browser.Name,
+					Profile: profileName,
+					ID:      idEntry.Name(),
+					Version: installedVersion(versionEntry.Name()),
+					Path:    path,
+				}
+				ext.readManifest()
+				if conf.withState {
+					ext.State = states[ext.ID]
+					seen[ext.ID] = true
+				}
+				if !yield(ext, nil) {
+					return
+				}
+			}
+		}
+
+		// unpacked extensions are referenced by an absolute path in the preferences
+		for _, id := range slices.Sorted(maps.Keys(states)) {
+			state := states[id]
+			if seen[id] || !filepath.IsAbs(state.Path) {
+				continue
+			}
+			ext := &InstalledExtension{
+				Browser: browser.Name,
+				Profile: profileName,
+				ID:      id,
+				Path:    state.Path,
+				State:   state,
+			}
+			ext.readManifest()
+			if !yield(ext, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (e *InstalledExtension) readManifest() {
+	manifest, err := ReadManifest(e.Path)
+	if err != nil {
+		return
+	}
+	e.Name = manifest.Name
+	if len(manifest.Version) > 0 {
+		e.Version = manifest.Version
+	}
+}
+
+// installedVersion strips the install counter Chrome appends to
+// version directories, e.g. "1.2.3_0" becomes "1.2.3".
+func installedVersion(dirname string) string {
+	if i := strings.LastIndex(dirname, "_"); i > 0 {
+		return dirname[:i]
+	}
+	return dirname
+}
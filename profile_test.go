@@ -0,0 +1,76 @@
+package crx3
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeProfileFixture(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		filename := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+	}
+}
+
+func TestScanBrowserProfiles(t *testing.T) {
+	home := t.TempDir()
+	const id = "kpkcennohgffjdgaelocingbmkjnpjgc"
+	makeProfileFixture(t, home, map[string]string{
+		".config/google-chrome/Default/Extensions/" + id + "/2.1.0_0/manifest.json":  `{"name":"Sample","version":"2.1.0"}`,
+		".config/google-chrome/Profile 1/Extensions/" + id + "/2.2_0/background.js":  ``,
+		".config/google-chrome/Profile 1/Extensions/Temp/.keep":                      ``,
+		".config/BraveSoftware/Brave-Browser/Default/Extensions/" + id + "/3_0/a.js": ``,
+		".config/opera/Extensions/" + id + "/1.0.1_0/manifest.json":                  `{"name":"Opera","version":"1.0.1"}`,
+		".config/google-chrome/Local State":                                          `{}`,
+		".config/google-chrome/Default/Preferences":                                  `{}`,
+	})
+
+	var got []*InstalledExtension
+	for ext, err := range ScanBrowserProfiles(LinuxBrowsers(home)) {
+		require.NoError(t, err)
+		got = append(got, ext)
+	}
+	require.Len(t, got, 4)
+
+	assert.Equal(t, "chrome", got[0].Browser)
+	assert.Equal(t, "Default", got[0].Profile)
+	assert.Equal(t, id, got[0].ID)
+	assert.Equal(t, "2.1.0", got[0].Version)
+	assert.Equal(t, "Sample", got[0].Name)
+
+	assert.Equal(t, "Profile 1", got[1].Profile)
+	assert.Equal(t, "2.2", got[1].Version)
+	assert.Empty(t, got[1].Name)
+
+	assert.Equal(t, "brave", got[2].Browser)
+	assert.Equal(t, "3", got[2].Version)
+
+	assert.Equal(t, "opera", got[3].Browser)
+	assert.Equal(t, "Default", got[3].Profile)
+	assert.Equal(t, "Opera", got[3].Name)
+}
+
+func TestScanBrowserProfiles_CustomUserDataDir(t *testing.T) {
+	root := t.TempDir()
+	makeProfileFixture(t, root, map[string]string{
+		"Default/Extensions/kpkcennohgffjdgaelocingbmkjnpjgc/1_0/manifest.json": `{"version":"1"}`,
+	})
+	browsers := []Browser{
+		{Name: "custom", UserDataDir: root},
+		{Name: "missing", UserDataDir: filepath.Join(root, "missing")},
+	}
+	var got []*InstalledExtension
+	for ext, err := range ScanBrowserProfiles(browsers) {
+		require.NoError(t, err)
+		got = append(got, ext)
+	}
+	require.Len(t, got, 1)
+	assert.Equal(t, "custom", got[0].Browser)
+	assert.Equal(t, "1", got[0].Version)
+}
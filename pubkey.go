@@ -0,0 +1,152 @@
+package crx3
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	"github.com/mediabuyerbot/go-crx3/pb"
+	"golang.org/x/crypto/ssh"
+)
+
+const pemTypePublicKey = "PUBLIC KEY"
+
+// PublicKey is an RSA or ECDSA public key, e.g. of a CRX3 header proof.
+type PublicKey struct {
+	// Algorithm is AlgorithmRSA or AlgorithmECDSA for keys of header proofs
+	// and empty otherwise.
+	Algorithm string
+	// DER is the DER-encoded PKIX public key, as stored in the header and the manifest key.
+	DER []byte
+	// Key is the parsed *rsa.PublicKey or *ecdsa.PublicKey.
+	Key crypto.PublicKey
+	// ID is the extension ID derived from the key.
+	ID string
+	// MatchesCrxID reports whether ID is the crx_id of the header.
+	MatchesCrxID bool
+}
+
+// PublicKeys returns the public keys of all proofs of the CRX3 file filename,
+// RSA proofs first. A CRX3 file is usually signed by the developer key, whose
+// ID matches the crx_id, and for Web Store extensions also by the store key.
+func PublicKeys(filename string) ([]PublicKey, error) {
+	if !isCRX(filename) {
+		return nil, fmt.Errorf("%w: %s is not a CRX3 file", ErrUnsupportedFileFormat, filename)
+	}
+	header, signedData, err := readCRXHeader(filename)
+	if err != nil {
+		return nil, err
+	}
+	crxID := string(makeExtensionID(signedData.CrxId))
+	groups := []struct {
+		algorithm string
+		proofs    []*pb.AsymmetricKeyProof
+	}{
+		{AlgorithmRSA, header.Sha256WithRsa},
+		{AlgorithmECDSA, header.Sha256WithEcdsa},
+	}
+	keys := []PublicKey{}
+	for _, group := range groups {
+		for i, proof := range group.proofs {
+			key, err := ParsePublicKey(proof.PublicKey)
+			if err != nil {
+				return nil, fmt.Errorf("crx3/pubkey: %s proof %d: %w", group.algorithm, i, err)
+			}
+			key.Algorithm = group.algorithm
+			key.MatchesCrxID = len(signedData.CrxId) == crxIDSize && key.ID == crxID
+			keys = append(keys, *key)
+		}
+	}
+	return keys, nil
+}
+
+// ParsePublicKey parses a DER-encoded PKIX RSA or ECDSA public key.
+func ParsePublicKey(der []byte) (*PublicKey, error) {
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("crx3/pubkey: failed to parse public key: %w", err)
+	}
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+	default:
+		return nil, fmt.Errorf("%w: public key %T", ErrUnsupportedKeyType, key)
+	}
+	sum := sha256.Sum256(der)
+	return &PublicKey{DER: der, Key: key, ID: string(makeExtensionID(sum[:]))}, nil
+}
+
+// Type returns KeyTypeRSA or KeyTypeECDSA.
+func (k PublicKey) Type() string {
+	if _, ok := k.Key.(*ecdsa.PublicKey); ok {
+		return KeyTypeECDSA
+	}
+	return KeyTypeRSA
+}
+
+// Base64 returns the base64 DER key, the format of the manifest key field.
+func (k PublicKey) Base64() string {
+	return base64.StdEncoding.EncodeToString(k.DER)
+}
+
+// PEM returns the key as a PEM "PUBLIC KEY" block.
+func (k PublicKey) PEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: pemTypePublicKey, Bytes: k.DER})
+}
+
+// SSHFingerprint returns the OpenSSH SHA256 fingerprint of the key, as printed
+// by ssh-keygen -l, e.g. "SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8".
+func (k PublicKey) SSHFingerprint() (string, error) {
+	key, err := ssh.NewPublicKey(k.Key)
+	if err != nil {
+		return "", fmt.Errorf("crx3/pubkey: %w", err)
+	}
+	return ssh.FingerprintSHA256(key), nil
+}
+
+// jwk is a JSON Web Key (RFC 7517) of an RSA or EC public key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWK returns the key as a JSON Web Key (RFC 7517, RFC 7518) with the
+// extension ID as key ID and RS256 or ES256 as algorithm of header proof keys.
+func (k PublicKey) JWK() ([]byte, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	out := jwk{Kid: k.ID}
+	switch key := k.Key.(type) {
+	case *rsa.PublicKey:
+		out.Kty, out.N, out.E = "RSA", b64(key.N.Bytes()), b64(big.NewInt(int64(key.E)).Bytes())
+		if k.Algorithm == AlgorithmRSA {
+			out.Alg = "RS256"
+		}
+	case *ecdsa.PublicKey:
+		point, err := key.Bytes()
+		if err != nil {
+			return nil, fmt.Errorf("crx3/pubkey: %w", err)
+		}
+		// uncompressed point: 0x04 || x || y
+		size := (len(point) - 1) / 2
+		out.Kty, out.Crv = "EC", key.Curve.Params().Name
+		out.X, out.Y = b64(point[1:1+size]), b64(point[1+size:])
+		if k.Algorithm == AlgorithmECDSA && out.Crv == "P-256" {
+			out.Alg = "ES256"
+		}
+	default:
+		return nil, fmt.Errorf("%w: public key %T", ErrUnsupportedKeyType, k.Key)
+	}
+	return json.Marshal(out)
+}
@@ -0,0 +1,137 @@
+package crx3
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mediabuyerbot/go-crx3/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPublicKeys(t *testing.T) {
+	keys, err := PublicKeys("testdata/withkey.crx")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	key := keys[0]
+	assert.Equal(t, AlgorithmRSA, key.Algorithm)
+	assert.Equal(t, KeyTypeRSA, key.Type())
+	assert.Equal(t, "hpngabcaekcmpoiomblebiidejafakch", key.ID)
+	assert.True(t, key.MatchesCrxID)
+	// ssh-keygen -l of the public key of testdata/withkey.crx.pem
+	fingerprint, err := key.SSHFingerprint()
+	require.NoError(t, err)
+	assert.Equal(t, "SHA256:6tMxXLqbkkfWXgU8FCMSiZ4gf+1W0TRHLIO6ViO43fY", fingerprint)
+
+	pk, err := LoadPrivateKey("testdata/withkey.crx.pem")
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&pk.PublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, der, key.DER)
+	id, err := IDFromPubKey([]byte(key.Base64()))
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, id)
+	block, rest := pem.Decode(key.PEM())
+	require.NotNil(t, block)
+	assert.Empty(t, rest)
+	assert.Equal(t, "PUBLIC KEY", block.Type)
+	assert.Equal(t, der, block.Bytes)
+
+	_, err = PublicKeys("testdata/withkey.zip")
+	assert.ErrorIs(t, err, ErrUnsupportedFileFormat)
+}
+
+func TestPublicKeys_MultipleProofs(t *testing.T) {
+	rsaKey, err := InsecureTestKey([]byte("rsa"), KeyTypeRSA)
+	require.NoError(t, err)
+	ecKey, err := InsecureTestKey([]byte("ecdsa"), KeyTypeECDSA)
+	require.NoError(t, err)
+	rsaDER, err := makePublicKey(rsaKey)
+	require.NoError(t, err)
+	ecDER, err := makePublicKey(ecKey)
+	require.NoError(t, err)
+	signedData, err := makeSignedData(ecDER)
+	require.NoError(t, err)
+	header, err := proto.Marshal(&pb.CrxFileHeader{
+		Sha256WithRsa:    []*pb.AsymmetricKeyProof{{PublicKey: rsaDER, Signature: []byte("sig")}},
+		Sha256WithEcdsa:  []*pb.AsymmetricKeyProof{{PublicKey: ecDER, Signature: []byte("sig")}},
+		SignedHeaderData: signedData,
+	})
+	require.NoError(t, err)
+	buf := bytes.NewBufferString("Cr24")
+	_ = binary.Write(buf, binary.LittleEndian, uint32(3))
+	_ = binary.Write(buf, binary.LittleEndian, uint32(len(header)))
+	buf.Write(header)
+	filename := filepath.Join(t.TempDir(), "proofs.crx")
+	require.NoError(t, os.WriteFile(filename, buf.Bytes(), 0644))
+
+	keys, err := PublicKeys(filename)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	rsaID, err := KeyID(rsaKey)
+	require.NoError(t, err)
+	ecID, err := KeyID(ecKey)
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmRSA, keys[0].Algorithm)
+	assert.Equal(t, rsaID, keys[0].ID)
+	assert.False(t, keys[0].MatchesCrxID)
+	assert.Equal(t, AlgorithmECDSA, keys[1].Algorithm)
+	assert.Equal(t, KeyTypeECDSA, keys[1].Type())
+	assert.Equal(t, ecID, keys[1].ID)
+	assert.True(t, keys[1].MatchesCrxID)
+}
+
+func TestPublicKey_JWK(t *testing.T) {
+	tests := []struct {
+		name      string
+		keyType   string
+		algorithm string
+		expected  map[string]string
+	}{
+		{name: "rsa proof", keyType: KeyTypeRSA, algorithm: AlgorithmRSA, expected: map[string]string{"kty": "RSA", "alg": "RS256", "e": "AQAB"}},
+		{name: "ecdsa proof", keyType: KeyTypeECDSA, algorithm: AlgorithmECDSA, expected: map[string]string{"kty": "EC", "alg": "ES256", "crv": "P-256"}},
+		{name: "ecdsa", keyType: KeyTypeECDSA, expected: map[string]string{"kty": "EC", "crv": "P-256"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := InsecureTestKey([]byte("jwk"), tt.keyType)
+			require.NoError(t, err)
+			der, err := makePublicKey(signer)
+			require.NoError(t, err)
+			key, err := ParsePublicKey(der)
+			require.NoError(t, err)
+			key.Algorithm = tt.algorithm
+
+			data, err := key.JWK()
+			require.NoError(t, err)
+			var jwk map[string]string
+			require.NoError(t, json.Unmarshal(data, &jwk))
+			for name, value := range tt.expected {
+				assert.Equal(t, value, jwk[name], name)
+			}
+			assert.Equal(t, key.ID, jwk["kid"])
+			if tt.keyType == KeyTypeECDSA {
+				assert.Len(t, jwk["x"], 43)
+				assert.Len(t, jwk["y"], 43)
+				assert.NotContains(t, jwk, "n")
+			} else {
+				assert.Len(t, jwk["n"], 342)
+			}
+			if len(tt.algorithm) == 0 {
+				assert.NotContains(t, jwk, "alg")
+			}
+		})
+	}
+}
+
+func TestParsePublicKey_Errors(t *testing.T) {
+	_, err := ParsePublicKey([]byte("not a key"))
+	assert.ErrorContains(t, err, "crx3/pubkey: failed to parse public key")
+}
@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"io/fs"
 	"iter"
+	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 )
 
 const (
@@ -24,19 +27,60 @@ var extensionNameRe = regexp.MustCompile(`^([a-zA-Z0-9_]+_)?([a-p]{32})(?:\.(crx
 
 // ExtensionInfo represents metadata about a Chrome extension found during scanning.
 // It includes the extension's name, file path, type (crx, zip, or dir),
-// size in bytes, and modification time formatted as a string.
+// size in bytes and modification time formatted as a string.
+//
+// Version is only set when scanning with WithVersion or WithMetadata, and
+// only if the manifest could be read. Fields below Version are only set when
+// scanning with WithMetadata.
 type ExtensionInfo struct {
 	Name     string `json:"name"`
 	Path     string `json:"path"`
 	Type     string `json:"type"`
 	Size     int64  `json:"size"`
 	Modified string `json:"modified"`
+	Version  string `json:"version,omitempty"`
+
+	ID              string `json:"id,omitempty"`
+	IDSource        string `json:"idSource,omitempty"`
+	ManifestName    string `json:"manifestName,omitempty"`
+	ManifestVersion int    `json:"manifestVersion,omitempty"`
+	Permissions     int    `json:"permissions,omitempty"`
+	// RequestedPermissions holds the permissions, optional permissions and
+	// host permissions requested by the manifest, sorted and deduplicated.
+	RequestedPermissions []string  `json:"requestedPermissions,omitempty"`
+	ModTime              time.Time `json:"modTime,omitzero"`
 }
 
 func (e *ExtensionInfo) String() string {
 	return fmt.Sprintf(
-		"ExtensionInfo{Name: %s, Path: %s, Type: %s, Size: %d, Modified: %s}",
-		e.Name, e.Path, e.Type, e.Size, e.Modified)
+		"ExtensionInfo{Name: %s, Path: %s, Type: %s, Size: %d, Modified: %s, Version: %s, ID: %s}",
+		e.Name, e.Path, e.Type, e.Size, e.Modified, e.Version, e.ID)
+}
+
+// SortByVersion sorts extensions in ascending order of their manifest version,
+// which Scan only reads with WithVersion or WithMetadata.
+// Extensions without a valid version are placed first; the sort is stable,
+// so equal versions keep their scan order.
+func SortByVersion(extensions []*ExtensionInfo) {
+	slices.SortStableFunc(extensions, func(a, b *ExtensionInfo) int {
+		return compareVersionStrings(a.Version, b.Version)
+	})
+}
+
+// compareVersionStrings compares two Chrome versions. Invalid or missing
+// versions sort before valid ones and compare equal to each other.
+func compareVersionStrings(a, b string) int {
+	va, errA := ParseVersion(a)
+	vb, errB := ParseVersion(b)
+	switch {
+	case errA != nil && errB != nil:
+		return 0
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	return va.Compare(vb)
 }
 
 // ScanOption is a function that configures the internal scan filter.
@@ -59,6 +103,24 @@ func WithNameFilter(query string) ScanOption {
 	}
 }
 
+// WithMetadata returns a ScanOption that enriches every result with data read
+// from the extension itself: the extension ID, the manifest name, version and
+// manifest_version, the number of requested permissions, the modification time
+// as time.Time and, for unpacked directories, the total size of all files.
+func WithMetadata() ScanOption {
+	return func(f *scanFilter) {
+		f.metadata = true
+	}
+}
+
+// WithVersion returns a ScanOption that reads the manifest version of every
+// result, e.g. for SortByVersion, without the rest of WithMetadata.
+func WithVersion() ScanOption {
+	return func(f *scanFilter) {
+		f.version = true
+	}
+}
+
 // WithMaxDepth returns a ScanOption that limits the directory traversal depth.
 // For example, WithMaxDepth(2) will scan only root, its subdirs, and their subdirs.
 func WithMaxDepth(depth int) ScanOption {
@@ -74,11 +136,80 @@ func WithMaxResults(n int) ScanOption {
 	}
 }
 
+// WithConcurrency returns a ScanOption that inspects archives and reads
+// metadata using up to n parallel workers while the directory walk continues.
+// Results are yielded as soon as they are ready, so their order may differ
+// from the serial walk order unless WithSortedResults is used as well.
+// Values of n less than 2 keep the serial mode.
+func WithConcurrency(n int) ScanOption {
+	return func(f *scanFilter) {
+		f.workers = n
+	}
+}
+
+// WithContinueOnError returns a ScanOption that keeps walking when a path
+// can't be read. Each such error is yielded as a *ScanError and the walk
+// moves on to the next entry instead of aborting the whole scan.
+func WithContinueOnError() ScanOption {
+	return func(f *scanFilter) {
+		f.continueOnError = true
+	}
+}
+
+// WithSortedResults returns a ScanOption that yields results in the serial
+// walk order. In concurrent mode this waits until the whole tree has been
+// scanned before the first result is yielded.
+func WithSortedResults() ScanOption {
+	return func(f *scanFilter) {
+		f.sorted = true
+	}
+}
+
+// ScanError records an error that occurred while scanning a path.
+type ScanError struct {
+	Path string
+	Err  error
+}
+
+func (e *ScanError) Error() string {
+	return "crx3/scan: " + e.Path + ": " + e.Err.Error()
+}
+
+func (e *ScanError) Unwrap() error {
+	return e.Err
+}
+
 type scanFilter struct {
-	names     []string
-	maxDepth  int
-	maxCount  int
-	currCount int
+	names           []string
+	maxDepth        int
+	maxCount        int
+	metadata        bool
+	version         bool
+	workers         int
+	continueOnError bool
+	sorted          bool
+	followSymlinks  bool
+	hidden          bool
+
+	types            []string
+	ids              []string
+	minSize          int64
+	maxSize          int64
+	modifiedAfter    time.Time
+	modifiedBefore   time.Time
+	manifestVersions []int
+	permissions      []string
+	include          []string
+	exclude          []string
+}
+
+// scanCandidate is a path that looks like an extension and still has to be inspected.
+type scanCandidate struct {
+	info    *ExtensionInfo
+	rel     string
+	modTime time.Time
+	// zip archives without a naming match only count if they contain manifest.json
+	checkManifest bool
 }
 
 // Scan walks the directory tree starting at rootPath and collects information about
@@ -93,171 +224,336 @@ type scanFilter struct {
 // including name, path, type ("crx", "zip", or "dir"), size, and modification time.
 //
 // Directories without recognized files are skipped. Files larger than 300MB are ignored.
+// See WithConcurrency and WithContinueOnError for scanning large or unreliable trees.
 func Scan(rootPath string, opts ...ScanOption) iter.Seq2[*ExtensionInfo, error] {
 	filter := new(scanFilter)
 	for _, opt := range opts {
 		opt(filter)
 	}
+	if filter.workers > 1 {
+		return filter.scanConcurrent(rootPath)
+	}
 	return func(yield func(*ExtensionInfo, error) bool) {
-		err := filepath.WalkDir(rootPath,
-			func(path string, d fs.DirEntry, err error) error {
-				if err != nil {
-					return err
+		var count int
+		err := filter.walk(rootPath,
+			func(c scanCandidate) bool {
+				ei, ok := filter.inspect(c)
+				if !ok {
+					return true
 				}
-
-				if isHidden(path) {
-					if d.IsDir() {
-						return filepath.SkipDir
-					}
-					return nil
+				if !yield(ei, nil) {
+					return false
 				}
+				count++
+				return filter.maxCount <= 0 || count < filter.maxCount
+			},
+			func(err error) bool {
+				return yield(nil, err)
+			})
+		if err != nil {
+			if !yield(nil, err) {
+				return
+			}
+		}
+	}
+}
 
-				depth := strings.Count(path[len(rootPath):], string(filepath.Separator))
-				if depth < 0 {
-					depth = 0
-				}
+// walk traverses rootPath and calls emit for every extension candidate in walk order.
+// Path errors are passed to emitErr in continue-on-error mode and returned otherwise.
+// Walking stops as soon as emit or emitErr returns false.
+func (filter *scanFilter) walk(
+	rootPath string,
+	emit func(scanCandidate) bool,
+	emitErr func(error) bool,
+) error {
+	stop := false
+	pathError := func(path string, err error, isDir bool) error {
+		if !filter.continueOnError {
+			return err
+		}
+		if !emitErr(&ScanError{Path: path, Err: err}) {
+			stop = true
+			return filepath.SkipAll
+		}
+		if isDir {
+			return filepath.SkipDir
+		}
+		return nil
+	}
+	next := func(c scanCandidate) bool {
+		if !emit(c) {
+			stop = true
+		}
+		return !stop
+	}
+	// real paths of all directories walked while following symlinks, so a
+	// directory reached twice, through a link or a cycle, is only walked once
+	visited := make(map[string]bool)
+
+	var visit fs.WalkDirFunc
+	visit = func(path string, d fs.DirEntry, err error) error {
+		if stop {
+			return filepath.SkipAll
+		}
+		if err != nil {
+			return pathError(path, err, d != nil && d.IsDir())
+		}
 
-				info, err := d.Info()
+		rel := relativePath(rootPath, path)
+		if !filter.hidden && isHidden(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filter.isExcluded(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		var depth int
+		if rel != "." {
+			depth = strings.Count(rel, string(filepath.Separator)) + 1
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return pathError(path, err, d.IsDir())
+		}
+
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if !filter.followSymlinks {
+				return nil
+			}
+			if info, err = os.Stat(path); err != nil {
+				return pathError(path, err, false)
+			}
+			if info.IsDir() {
+				real, err := filepath.EvalSymlinks(path)
 				if err != nil {
-					return err
+					return pathError(path, err, true)
 				}
-				if info != nil && info.Size() > maxExtensionSize {
+				if visited[real] {
 					return nil
 				}
+				return filepath.WalkDir(real, func(p string, d fs.DirEntry, err error) error {
+					return visit(filepath.Join(path, relativePath(real, p)), d, err)
+				})
+			}
+		} else if info.IsDir() && filter.followSymlinks {
+			real, err := filepath.EvalSymlinks(path)
+			if err != nil {
+				return pathError(path, err, true)
+			}
+			if visited[real] {
+				return filepath.SkipDir
+			}
+			visited[real] = true
+		}
 
-				if strings.HasPrefix(info.Name(), ".") {
-					return nil
-				}
+		if info.Size() > maxExtensionSize {
+			return nil
+		}
 
-				if filter.maxDepth > 0 && depth > filter.maxDepth {
-					if info.IsDir() {
-						return filepath.SkipDir
-					}
-					return nil
-				}
+		if !filter.hidden && rel != "." && strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
 
-				lowerName := strings.ToLower(info.Name())
-				if len(filter.names) > 0 {
-					var match bool
-					for _, q := range filter.names {
-						if strings.Contains(lowerName, q) {
-							match = true
-							break
-						}
-					}
-					if !match {
-						return nil
-					}
-				}
+		if filter.maxDepth > 0 && depth > filter.maxDepth {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if len(filter.names) > 0 && !filter.matchName(info.Name()) {
+			return nil
+		}
 
-				if filter.maxCount > 0 && filter.currCount >= filter.maxCount {
+		candidate := func(ei *ExtensionInfo, checkManifest bool) scanCandidate {
+			return scanCandidate{info: ei, rel: rel, modTime: info.ModTime(), checkManifest: checkManifest}
+		}
+
+		// format: some_name_kpkcennohgffjdgaelocingbmkjnpjgc.crx|zip
+		matches := extensionNameRe.FindStringSubmatch(info.Name())
+		if len(matches) == 4 && IsValidExtensionID(matches[2]) {
+			ei := &ExtensionInfo{
+				Name:     formatName(matches[1], matches[2]),
+				Type:     cmp.Or(matches[3], tdir),
+				Size:     info.Size(),
+				Path:     path,
+				Modified: info.ModTime().Format(defaultLayout),
+			}
+			if !next(candidate(ei, false)) {
+				return filepath.SkipAll
+			}
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// format: directory without crx|zip extension and without specifying a special name
+		if info.IsDir() {
+			// crx3.id or manifest.json
+			if fileExists(filepath.Join(path, extensionID)) || fileExists(manifestFile(path)) {
+				ei := &ExtensionInfo{
+					Name:     formatName(info.Name(), unknownName),
+					Type:     tdir,
+					Size:     info.Size(),
+					Path:     path,
+					Modified: info.ModTime().Format(defaultLayout),
+				}
+				if !next(candidate(ei, false)) {
 					return filepath.SkipAll
 				}
+				return filepath.SkipDir
+			}
+			return nil
+		}
 
-				// format: some_name_kpkcennohgffjdgaelocingbmkjnpjgc.crx|zip
-				matches := extensionNameRe.FindStringSubmatch(info.Name())
-				if len(matches) == 4 && IsValidExtensionID(matches[2]) {
-					ei := &ExtensionInfo{
-						Name:     formatName(matches[1], matches[2]),
-						Type:     cmp.Or(matches[3], tdir),
-						Size:     info.Size(),
-						Path:     path,
-						Modified: info.ModTime().Format(defaultLayout),
-					}
-					if !yield(ei, nil) {
-						return filepath.SkipAll
-					}
-					filter.currCount++
-					if info.IsDir() {
-						return filepath.SkipDir
-					}
-					return nil
-				}
+		// crx extension
+		if strings.HasSuffix(strings.ToLower(d.Name()), crxExt) {
+			ei := &ExtensionInfo{
+				Name:     formatName(info.Name(), unknownName),
+				Path:     path,
+				Type:     tcrx,
+				Size:     info.Size(),
+				Modified: info.ModTime().Format(defaultLayout),
+			}
+			if !next(candidate(ei, false)) {
+				return filepath.SkipAll
+			}
+			return nil
+		}
 
-				// format: directory without crx|zip extension and without specifying a special name
-				if info.IsDir() {
-					crx3Filename := filepath.Join(path, extensionID)
-					if fileExists(crx3Filename) {
-						ei := &ExtensionInfo{
-							Name:     formatName(info.Name(), unknownName),
-							Type:     tdir,
-							Size:     info.Size(),
-							Path:     path,
-							Modified: info.ModTime().Format(defaultLayout),
-						}
-						filter.currCount++
-						if !yield(ei, nil) {
-							return filepath.SkipAll
-						}
-						return filepath.SkipDir
-					}
-
-					// manifest.json
-					manifestFilename := filepath.Join(path, "manifest.json")
-					if fileExists(manifestFilename) {
-						ei := &ExtensionInfo{
-							Name:     formatName(info.Name(), unknownName),
-							Type:     tdir,
-							Size:     info.Size(),
-							Path:     path,
-							Modified: info.ModTime().Format(defaultLayout),
-						}
-						filter.currCount++
-						if !yield(ei, nil) {
-							return filepath.SkipAll
-						}
-						return filepath.SkipDir
-					}
-					return nil
-				}
+		// zip extension
+		if strings.HasSuffix(strings.ToLower(d.Name()), zipExt) {
+			ei := &ExtensionInfo{
+				Name:     formatName(info.Name(), unknownName),
+				Path:     path,
+				Type:     tzip,
+				Size:     info.Size(),
+				Modified: info.ModTime().Format(defaultLayout),
+			}
+			if !next(candidate(ei, true)) {
+				return filepath.SkipAll
+			}
+			return nil
+		}
+		return nil
+	}
+	return filepath.WalkDir(rootPath, visit)
+}
 
-				// crx extension
-				if strings.HasSuffix(strings.ToLower(d.Name()), crxExt) {
-					ei := &ExtensionInfo{
-						Name:     formatName(info.Name(), unknownName),
-						Path:     path,
-						Type:     tcrx,
-						Size:     info.Size(),
-						Modified: info.ModTime().Format(defaultLayout),
-					}
-					filter.currCount++
-					if !yield(ei, nil) {
-						return filepath.SkipAll
-					}
-					return nil
-				}
+// inspect opens the candidate when needed, fills in the manifest data and
+// applies the filters. It reports false if the candidate turns out not to be
+// an extension or does not match.
+func (filter *scanFilter) inspect(c scanCandidate) (*ExtensionInfo, bool) {
+	if !filter.matchCandidate(c) {
+		return nil, false
+	}
+	if c.checkManifest && !manifestExists(c.info.Path) {
+		return nil, false
+	}
+	if filter.metadata || filter.needsMetadata() {
+		// enrich reads the version from the manifest it parses anyway
+		c.info.enrich()
+	} else {
+		if filter.version {
+			c.info.Version = manifestVersion(c.info.Path)
+		}
+		if c.info.Type == tdir && filter.hasSizeRange() {
+			c.info.Size = dirSize(c.info.Path)
+		}
+	}
+	if !filter.matchInfo(c.info) {
+		return nil, false
+	}
+	return c.info, true
+}
 
-				// zip extension
-				if strings.HasSuffix(strings.ToLower(d.Name()), zipExt) {
-					if manifestExists(path) {
-						ei := &ExtensionInfo{
-							Name:     formatName(info.Name(), unknownName),
-							Path:     path,
-							Type:     tzip,
-							Size:     info.Size(),
-							Modified: info.ModTime().Format(defaultLayout),
-						}
-						filter.currCount++
-						if !yield(ei, nil) {
-							return filepath.SkipAll
-						}
-					}
-					return nil
-				}
-				return nil
-			})
-		if err != nil {
-			if !yield(nil, err) {
+// Sources of ExtensionInfo.ID.
+const (
+	IDSourceHeader   = "header"
+	IDSourceManifest = "manifest"
+	IDSourceMarker   = "marker"
+	IDSourceFilename = "filename"
+)
+
+func (e *ExtensionInfo) enrich() {
+	if stat, err := os.Stat(e.Path); err == nil {
+		e.ModTime = stat.ModTime()
+	}
+	if e.Type == tdir {
+		e.Size = dirSize(e.Path)
+	}
+
+	manifest, err := ReadManifest(e.Path)
+	if err == nil {
+		e.ManifestName = manifest.Name
+		e.Version = manifest.Version
+		e.ManifestVersion = manifest.ManifestVersion
+		e.RequestedPermissions = manifest.AllPermissions()
+		e.Permissions = len(e.RequestedPermissions)
+	}
+
+	switch {
+	case e.Type == tcrx:
+		if id, err := ID(e.Path); err == nil {
+			e.ID, e.IDSource = id, IDSourceHeader
+			return
+		}
+	case manifest != nil && len(manifest.Key) > 0:
+		if id, err := IDFromPubKey([]byte(manifest.Key)); err == nil {
+			e.ID, e.IDSource = id, IDSourceManifest
+			return
+		}
+	}
+	if e.Type == tdir {
+		if data, err := os.ReadFile(filepath.Join(e.Path, extensionID)); err == nil {
+			if id := strings.TrimSpace(string(data)); IsValidExtensionID(id) {
+				e.ID, e.IDSource = id, IDSourceMarker
 				return
 			}
 		}
 	}
+	if matches := extensionNameRe.FindStringSubmatch(filepath.Base(e.Path)); len(matches) == 4 {
+		e.ID, e.IDSource = matches[2], IDSourceFilename
+	}
+}
+
+func dirSize(path string) int64 {
+	var size int64
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// relativePath returns path relative to root, or path itself if that fails.
+func relativePath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+	return rel
 }
 
 func isHidden(path string) bool {
 	parts := strings.Split(path, string(filepath.Separator))
 	for _, part := range parts {
-		if len(part) > 0 && part[0] == '.' {
+		if len(part) > 0 && part[0] == '.' && part != "." && part != ".." {
 			return true
 		}
 	}
@@ -276,6 +572,14 @@ func formatName(name string, extensionID string) string {
 	return name
 }
 
+func manifestVersion(path string) string {
+	manifest, err := ReadManifest(path)
+	if err != nil {
+		return ""
+	}
+	return manifest.Version
+}
+
 func manifestExists(path string) bool {
 	r, err := zip.OpenReader(path)
 	if err != nil {
@@ -0,0 +1,131 @@
+package crx3
+
+import (
+	"cmp"
+	"context"
+	"iter"
+	"slices"
+	"sync"
+)
+
+type scanJob struct {
+	seq       int
+	candidate scanCandidate
+}
+
+type scanResult struct {
+	seq  int
+	info *ExtensionInfo
+	err  error
+}
+
+// scanConcurrent walks the tree in a single goroutine and inspects the found
+// candidates in a pool of filter.workers goroutines. Every candidate and
+// error gets a sequence number in walk order, which is used by sorted mode
+// to reproduce the serial order exactly.
+func (filter *scanFilter) scanConcurrent(rootPath string) iter.Seq2[*ExtensionInfo, error] {
+	return func(yield func(*ExtensionInfo, error) bool) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		jobs := make(chan scanJob)
+		results := make(chan scanResult)
+		send := func(r scanResult) bool {
+			select {
+			case results <- r:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(jobs)
+			var seq int
+			err := filter.walk(rootPath,
+				func(c scanCandidate) bool {
+					select {
+					case jobs <- scanJob{seq: seq, candidate: c}:
+						seq++
+						return true
+					case <-ctx.Done():
+						return false
+					}
+				},
+				func(err error) bool {
+					seq++
+					return send(scanResult{seq: seq - 1, err: err})
+				})
+			if err != nil {
+				send(scanResult{seq: seq, err: err})
+			}
+		}()
+
+		for range filter.workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for job := range jobs {
+					if ctx.Err() != nil {
+						continue
+					}
+					if info, ok := filter.inspect(job.candidate); ok {
+						send(scanResult{seq: job.seq, info: info})
+					}
+				}
+			}()
+		}
+
+		go func() {
+			wg.Wait()
+			close(results)
+		}()
+		// stop the pipeline and wait for all goroutines before returning
+		defer func() {
+			cancel()
+			for range results {
+			}
+		}()
+
+		if filter.sorted {
+			var collected []scanResult
+			for r := range results {
+				collected = append(collected, r)
+			}
+			slices.SortFunc(collected, func(a, b scanResult) int {
+				return cmp.Compare(a.seq, b.seq)
+			})
+			filter.yieldResults(slices.Values(collected), yield)
+			return
+		}
+
+		filter.yieldResults(func(yieldResult func(scanResult) bool) {
+			for r := range results {
+				if !yieldResult(r) {
+					return
+				}
+			}
+		}, yield)
+	}
+}
+
+// yieldResults passes results to yield until it returns false
+// or the maximum number of extensions has been reached.
+func (filter *scanFilter) yieldResults(results iter.Seq[scanResult], yield func(*ExtensionInfo, error) bool) {
+	var count int
+	for r := range results {
+		if !yield(r.info, r.err) {
+			return
+		}
+		if r.info == nil {
+			continue
+		}
+		count++
+		if filter.maxCount > 0 && count >= filter.maxCount {
+			return
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package crx3
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// The filters below narrow down the results of Scan. Values passed to the
+// same filter are joined with logical OR, different filters with logical AND.
+// Filters that depend on the manifest or the extension ID (WithIDFilter,
+// WithManifestVersionFilter and WithPermissionFilter) read the metadata of
+// every candidate, as if WithMetadata was given.
+
+// WithTypeFilter returns a ScanOption that keeps only extensions of the
+// given types: "crx", "zip" or "dir".
+func WithTypeFilter(types ...string) ScanOption {
+	return func(f *scanFilter) {
+		for _, t := range types {
+			f.types = append(f.types, strings.ToLower(t))
+		}
+	}
+}
+
+// WithIDFilter returns a ScanOption that keeps only extensions with one of the given IDs.
+func WithIDFilter(ids ...string) ScanOption {
+	return func(f *scanFilter) {
+		f.ids = append(f.ids, ids...)
+	}
+}
+
+// WithSizeRange returns a ScanOption that keeps only extensions whose size in
+// bytes lies within [minSize, maxSize]. A bound less than or equal to 0 is not
+// checked. The size of an unpacked directory is the total size of its files.
+func WithSizeRange(minSize, maxSize int64) ScanOption {
+	return func(f *scanFilter) {
+		f.minSize = minSize
+		f.maxSize = maxSize
+	}
+}
+
+// ParseModifiedTime parses the argument of WithModifiedAfter and
+// WithModifiedBefore given as text: an RFC3339 timestamp, or a YYYY-MM-DD
+// date, which stands for midnight at the start of that day in the local
+// time zone, the zone file modification times are usually shown in.
+func ParseModifiedTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("crx3/scan: %q is neither an RFC3339 timestamp nor a YYYY-MM-DD date", s)
+	}
+	return t, nil
+}
+
+// WithModifiedAfter returns a ScanOption that keeps only extensions
+// modified at or after t.
+func WithModifiedAfter(t time.Time) ScanOption {
+	return func(f *scanFilter) {
+		f.modifiedAfter = t
+	}
+}
+
+// WithModifiedBefore returns a ScanOption that keeps only extensions
+// modified before t.
+func WithModifiedBefore(t time.Time) ScanOption {
+	return func(f *scanFilter) {
+		f.modifiedBefore = t
+	}
+}
+
+// WithManifestVersionFilter returns a ScanOption that keeps only extensions
+// whose manifest_version is one of versions.
+func WithManifestVersionFilter(versions ...int) ScanOption {
+	return func(f *scanFilter) {
+		f.manifestVersions = append(f.manifestVersions, versions...)
+	}
+}
+
+// WithPermissionFilter returns a ScanOption that keeps only extensions
+// requesting at least one of the given permissions, optional permissions
+// or host permissions.
+func WithPermissionFilter(permissions ...string) ScanOption {
+	return func(f *scanFilter) {
+		f.permissions = append(f.permissions, permissions...)
+	}
+}
+
+// WithIncludeGlob returns a ScanOption that keeps only extensions whose path
+// relative to the scan root, or whose base name, matches one of the patterns.
+// Patterns use the filepath.Match syntax with forward slashes as separators.
+func WithIncludeGlob(patterns ...string) ScanOption {
+	return func(f *scanFilter) {
+		f.include = append(f.include, patterns...)
+	}
+}
+
+// WithExcludeGlob returns a ScanOption that skips every file or directory whose
+// path relative to the scan root, or whose base name, matches one of the patterns.
+// Excluded directories are not descended into.
+func WithExcludeGlob(patterns ...string) ScanOption {
+	return func(f *scanFilter) {
+		f.exclude = append(f.exclude, patterns...)
+	}
+}
+
+// WithFollowSymlinks returns a ScanOption that follows symbolic links to files
+// and directories. Results keep the path through the link. Every directory is
+// visited once, under the path it is reached by first, so symlink cycles are
+// not followed and a link to a directory that was already walked is skipped.
+func WithFollowSymlinks() ScanOption {
+	return func(f *scanFilter) {
+		f.followSymlinks = true
+	}
+}
+
+// WithHidden returns a ScanOption that also scans hidden files and
+// directories whose names start with a dot.
+func WithHidden() ScanOption {
+	return func(f *scanFilter) {
+		f.hidden = true
+	}
+}
+
+// needsMetadata reports whether a filter depends on data read by enrich.
+func (filter *scanFilter) needsMetadata() bool {
+	return len(filter.ids) > 0 || len(filter.manifestVersions) > 0 || len(filter.permissions) > 0
+}
+
+func (filter *scanFilter) hasSizeRange() bool {
+	return filter.minSize > 0 || filter.maxSize > 0
+}
+
+// matchName reports whether name contains one of the name filters, ignoring case.
+func (filter *scanFilter) matchName(name string) bool {
+	name = strings.ToLower(name)
+	for _, q := range filter.names {
+		if strings.Contains(name, q) {
+			return true
+		}
+	}
+	return false
+}
+
+func (filter *scanFilter) isExcluded(rel string) bool {
+	return rel != "." && matchGlobs(filter.exclude, rel)
+}
+
+// matchCandidate applies the filters that don't require opening the candidate.
+func (filter *scanFilter) matchCandidate(c scanCandidate) bool {
+	if len(filter.types) > 0 && !slices.Contains(filter.types, c.info.Type) {
+		return false
+	}
+	if len(filter.include) > 0 && !matchGlobs(filter.include, c.rel) {
+		return false
+	}
+	if !filter.modifiedAfter.IsZero() && c.modTime.Before(filter.modifiedAfter) {
+		return false
+	}
+	if !filter.modifiedBefore.IsZero() && !c.modTime.Before(filter.modifiedBefore) {
+		return false
+	}
+	// the size of a directory is only known after inspection
+	if c.info.Type != tdir && !filter.matchSize(c.info.Size) {
+		return false
+	}
+	return true
+}
+
+// matchInfo applies the filters that depend on the inspected extension.
+func (filter *scanFilter) matchInfo(info *ExtensionInfo) bool {
+	if info.Type == tdir && !filter.matchSize(info.Size) {
+		return false
+	}
+	if len(filter.ids) > 0 && !slices.Contains(filter.ids, info.ID) {
+		return false
+	}
+	if len(filter.manifestVersions) > 0 && !slices.Contains(filter.manifestVersions, info.ManifestVersion) {
+		return false
+	}
+	if len(filter.permissions) > 0 && !slices.ContainsFunc(filter.permissions, func(p string) bool {
+		return slices.Contains(info.RequestedPermissions, p)
+	}) {
+		return false
+	}
+	return true
+}
+
+func (filter *scanFilter) matchSize(size int64) bool {
+	if filter.minSize > 0 && size < filter.minSize {
+		return false
+	}
+	if filter.maxSize > 0 && size > filter.maxSize {
+		return false
+	}
+	return true
+}
+
+// matchGlobs reports whether the slash-separated rel path or its base name
+// matches one of the patterns. Malformed patterns never match.
+func matchGlobs(patterns []string, rel string) bool {
+	rel = filepath.ToSlash(rel)
+	base := rel[strings.LastIndex(rel, "/")+1:]
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+package crx3
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scanNames(t *testing.T, root string, opts ...ScanOption) []string {
+	t.Helper()
+	var names []string
+	for info, err := range Scan(root, opts...) {
+		require.NoError(t, err)
+		names = append(names, filepath.Base(info.Path))
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestScan_Filters(t *testing.T) {
+	const root = "testdata/scanroot"
+	all := []string{
+		"kpkcennohgffjdgaelocingbmkjnpjgc",
+		"kpkcennohgffjdgaelocingbmkjnpjgc.crx",
+		"some_extension",
+		"some_extension.zip",
+		"some_valid_kpkcennohgffjdgaelocingbmkjnpjgc.crx",
+		"valid.crx",
+		"with_manifest",
+	}
+	require.Equal(t, all, scanNames(t, root))
+
+	tests := []struct {
+		name string
+		opts []ScanOption
+		want []string
+	}{
+		{
+			name: "type",
+			opts: []ScanOption{WithTypeFilter("zip", "DIR")},
+			want: []string{"kpkcennohgffjdgaelocingbmkjnpjgc", "some_extension", "some_extension.zip", "with_manifest"},
+		},
+		{
+			name: "id",
+			opts: []ScanOption{WithTypeFilter("dir"), WithIDFilter("kpkcennohgffjdgaelocingbmkjnpjgc")},
+			want: []string{"kpkcennohgffjdgaelocingbmkjnpjgc", "some_extension"},
+		},
+		{
+			name: "include glob",
+			opts: []ScanOption{WithIncludeGlob("*.crx")},
+			want: []string{"kpkcennohgffjdgaelocingbmkjnpjgc.crx", "some_valid_kpkcennohgffjdgaelocingbmkjnpjgc.crx", "valid.crx"},
+		},
+		{
+			name: "exclude glob",
+			opts: []ScanOption{WithExcludeGlob("*.crx", "some_*")},
+			want: []string{"kpkcennohgffjdgaelocingbmkjnpjgc", "with_manifest"},
+		},
+		{
+			name: "manifest version",
+			opts: []ScanOption{WithManifestVersionFilter(3)},
+			want: nil,
+		},
+		{
+			name: "permission",
+			opts: []ScanOption{WithTypeFilter("crx"), WithPermissionFilter("unknown", "tabCapture")},
+			want: []string{"kpkcennohgffjdgaelocingbmkjnpjgc.crx", "some_valid_kpkcennohgffjdgaelocingbmkjnpjgc.crx", "valid.crx"},
+		},
+		{
+			name: "size",
+			opts: []ScanOption{WithSizeRange(4*1024*1024, 0)},
+			want: []string{"kpkcennohgffjdgaelocingbmkjnpjgc", "some_extension", "with_manifest"},
+		},
+		{
+			name: "max size",
+			opts: []ScanOption{WithSizeRange(0, 1351596)},
+			want: []string{"kpkcennohgffjdgaelocingbmkjnpjgc.crx", "some_valid_kpkcennohgffjdgaelocingbmkjnpjgc.crx", "valid.crx"},
+		},
+		{
+			name: "modified before",
+			opts: []ScanOption{WithModifiedBefore(time.Unix(0, 0))},
+			want: nil,
+		},
+		{
+			name: "modified after",
+			opts: []ScanOption{WithModifiedAfter(time.Unix(0, 0))},
+			want: all,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, scanNames(t, root, tt.opts...))
+			// concurrent mode applies the same filters
+			opts := append(slices.Clone(tt.opts), WithConcurrency(4))
+			assert.Equal(t, tt.want, scanNames(t, root, opts...))
+		})
+	}
+}
+
+func TestScan_Hidden(t *testing.T) {
+	root := t.TempDir()
+	makeExtensionDir(t, filepath.Join(root, ".hidden", "ext"))
+	makeExtensionDir(t, filepath.Join(root, "visible"))
+
+	assert.Equal(t, []string{"visible"}, scanNames(t, root))
+	assert.Equal(t, []string{"ext", "visible"}, scanNames(t, root, WithHidden()))
+
+	// a relative root starting with a dot is not hidden itself
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(root))
+	defer os.Chdir(wd)
+	assert.Equal(t, []string{"visible"}, scanNames(t, "./"))
+}
+
+func TestScan_FollowSymlinks(t *testing.T) {
+	root := t.TempDir()
+	target := t.TempDir()
+	makeExtensionDir(t, filepath.Join(target, "linked"))
+	require.NoError(t, os.Symlink(target, filepath.Join(root, "link")))
+	// a cycle back to the root must not be followed forever
+	require.NoError(t, os.Symlink(root, filepath.Join(target, "loop")))
+
+	assert.Empty(t, scanNames(t, root))
+
+	var paths []string
+	for info, err := range Scan(root, WithFollowSymlinks()) {
+		require.NoError(t, err)
+		paths = append(paths, info.Path)
+	}
+	assert.Equal(t, []string{filepath.Join(root, "link", "linked")}, paths)
+}
+
+func TestScan_FollowSymlinks_SiblingLink(t *testing.T) {
+	root := t.TempDir()
+	makeExtensionDir(t, filepath.Join(root, "a", "ext"))
+	require.NoError(t, os.Symlink(filepath.Join(root, "a"), filepath.Join(root, "link")))
+	// a link into the tree to a directory walked later
+	require.NoError(t, os.Symlink(filepath.Join(root, "z"), filepath.Join(root, "b")))
+	makeExtensionDir(t, filepath.Join(root, "z", "other"))
+
+	var paths []string
+	for info, err := range Scan(root, WithFollowSymlinks(), WithSortedResults()) {
+		require.NoError(t, err)
+		paths = append(paths, info.Path)
+	}
+	assert.Equal(t, []string{
+		filepath.Join(root, "a", "ext"),
+		filepath.Join(root, "b", "other"),
+	}, paths)
+}
+
+func makeExtensionDir(t *testing.T, dir string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, manifestFilename),
+		[]byte(`{"name":"test","version":"1.0","manifest_version":3}`), 0644))
+}
+
+func TestParseModifiedTime(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{input: "2026-01-01", want: time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)},
+		{input: "2026-01-01T10:00:00Z", want: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{input: "2026-01-01T10:00:00+02:00", want: time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)},
+		{input: "yesterday", wantErr: true},
+		{input: "2026-13-01", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseModifiedTime(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), got)
+		})
+	}
+}
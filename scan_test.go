@@ -0,0 +1,150 @@
+package crx3
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScan_WithMetadata(t *testing.T) {
+	const id = "kpkcennohgffjdgaelocingbmkjnpjgc"
+	results := make(map[string]*ExtensionInfo)
+	for info, err := range Scan("testdata/scanroot", WithMetadata()) {
+		require.NoError(t, err)
+		results[filepath.Base(info.Path)] = info
+	}
+
+	tests := []struct {
+		name     string
+		idSource string
+	}{
+		{name: id, idSource: IDSourceMarker},
+		{name: id + ".crx", idSource: IDSourceHeader},
+		{name: "valid.crx", idSource: IDSourceHeader},
+		{name: "some_extension", idSource: IDSourceMarker},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, ok := results[tt.name]
+			require.True(t, ok)
+			assert.Equal(t, id, info.ID)
+			assert.Equal(t, tt.idSource, info.IDSource)
+			assert.Equal(t, "2.1.0", info.Version)
+			assert.Equal(t, 2, info.ManifestVersion)
+			assert.Equal(t, 2, info.Permissions)
+			assert.NotEmpty(t, info.ManifestName)
+			assert.False(t, info.ModTime.IsZero())
+		})
+	}
+
+	dir := results["with_manifest"]
+	require.NotNil(t, dir)
+	assert.Empty(t, dir.ID)
+	assert.Greater(t, dir.Size, int64(1024*1024))
+}
+
+func TestScan_WithoutMetadata(t *testing.T) {
+	for info, err := range Scan("testdata/scanroot") {
+		require.NoError(t, err)
+		assert.Empty(t, info.ID)
+		assert.Empty(t, info.Version)
+		assert.True(t, info.ModTime.IsZero())
+	}
+}
+
+func TestScan_WithVersion(t *testing.T) {
+	var found bool
+	for info, err := range Scan("testdata/scanroot", WithVersion()) {
+		require.NoError(t, err)
+		assert.Empty(t, info.ID)
+		if filepath.Base(info.Path) == "valid.crx" {
+			found = true
+			assert.Equal(t, "2.1.0", info.Version)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestScan_Concurrent(t *testing.T) {
+	collect := func(opts ...ScanOption) []string {
+		var paths []string
+		for info, err := range Scan("testdata/scanroot", opts...) {
+			require.NoError(t, err)
+			paths = append(paths, info.Path)
+		}
+		return paths
+	}
+
+	serial := collect(WithMetadata())
+	require.NotEmpty(t, serial)
+
+	assert.ElementsMatch(t, serial, collect(WithMetadata(), WithConcurrency(4)))
+	assert.Equal(t, serial, collect(WithMetadata(), WithConcurrency(4), WithSortedResults()))
+	assert.Equal(t, serial[:3], collect(WithConcurrency(4), WithSortedResults(), WithMaxResults(3)))
+	assert.Len(t, collect(WithConcurrency(4), WithMaxResults(2)), 2)
+}
+
+func TestScan_Concurrent_Break(t *testing.T) {
+	var n int
+	for _, err := range Scan("testdata/scanroot", WithConcurrency(2)) {
+		require.NoError(t, err)
+		n++
+		break
+	}
+	assert.Equal(t, 1, n)
+}
+
+func TestScan_ContinueOnError(t *testing.T) {
+	for _, opts := range [][]ScanOption{
+		{WithContinueOnError()},
+		{WithContinueOnError(), WithConcurrency(2)},
+	} {
+		var errs []error
+		for info, err := range Scan("testdata/notfound", opts...) {
+			assert.Nil(t, info)
+			errs = append(errs, err)
+		}
+		require.Len(t, errs, 1)
+		var scanErr *ScanError
+		require.ErrorAs(t, errs[0], &scanErr)
+		assert.Equal(t, "testdata/notfound", scanErr.Path)
+		assert.ErrorIs(t, errs[0], os.ErrNotExist)
+	}
+}
+
+func TestScan_ContinueOnError_UnreadableDir(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+	root := t.TempDir()
+	locked := filepath.Join(root, "a_locked")
+	require.NoError(t, os.MkdirAll(locked, 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "b_extension"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "b_extension", "manifest.json"), []byte(`{}`), 0644))
+	require.NoError(t, os.Chmod(locked, 0))
+	defer os.Chmod(locked, 0755)
+
+	var found, failed int
+	for info, err := range Scan(root, WithContinueOnError()) {
+		if err != nil {
+			failed++
+			continue
+		}
+		assert.Equal(t, filepath.Join(root, "b_extension"), info.Path)
+		found++
+	}
+	assert.Equal(t, 1, found)
+	assert.Equal(t, 1, failed)
+
+	// without the option the walk aborts on the first error
+	var results int
+	for info := range Scan(root) {
+		if info != nil {
+			results++
+		}
+	}
+	assert.Zero(t, results)
+}
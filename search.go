@@ -234,9 +234,11 @@ func IsValidChromeWebStoreURL(rawURL string) bool {
 	return IsValidExtensionID(extensionID)
 }
 
+// IsValidExtensionID reports whether s is an extension ID: 32 letters a-p,
+// the hex digits of a public key hash mapped to a-p.
 func IsValidExtensionID(s string) bool {
 	return len(s) == 32 && strings.IndexFunc(s, func(r rune) bool {
-		return !((r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'))
+		return r < 'a' || r > 'p'
 	}) == -1
 }
 
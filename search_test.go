@@ -0,0 +1,28 @@
+package crx3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidExtensionID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{id: "kpkcennohgffjdgaelocingbmkjnpjgc", want: true},
+		{id: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", want: true},
+		{id: "pppppppppppppppppppppppppppppppp", want: true},
+		{id: "kpkcennohgffjdgaelocingbmkjnpjg", want: false},
+		{id: "kpkcennohgffjdgaelocingbmkjnpjgcc", want: false},
+		{id: "kpkcennohgffjdgaelocingbmkjnpjgz", want: false},
+		{id: "kpkcennohgffjdgaelocingbmkjnpjg0", want: false},
+		{id: "KPKCENNOHGFFJDGAELOCINGBMKJNPJGC", want: false},
+		{id: "../secret", want: false},
+		{id: "", want: false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, IsValidExtensionID(tt.id), tt.id)
+	}
+}
@@ -0,0 +1,166 @@
+package crx3
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// testKeyVersion is part of the DRBG personalization string; it changes
+// whenever the derivation changes so old seeds never silently give other keys.
+const testKeyVersion = "crx3 test key v1"
+
+// InsecureTestKey derives a private key of keyType (KeyTypeRSA with the
+// default key size, or KeyTypeECDSA) deterministically from seed, so test
+// fixtures keep the same extension ID across runs.
+//
+// INSECURE: anyone who knows the seed can recreate the key. Use it only for
+// tests and fixtures, never to sign extensions that are published.
+//
+// The key material is read from an HMAC_DRBG (NIST SP 800-90A) with SHA-256,
+// instantiated with seed as entropy input, no nonce and the personalization
+// string "crx3 test key v1 <type> <bits>", e.g. "crx3 test key v1 RSA 2048".
+// An ECDSA P-256 key is the first 32-byte output that is a valid scalar.
+// An RSA key uses e = 65537 and two primes; each is the first output of
+// bits/2 bits, with the two top bits and the low bit set, that passes
+// big.Int.ProbablyPrime(20) and for which p-1 is coprime to e.
+// The derivation does not use crypto/rand or the Go key generators, so it
+// does not change between Go versions.
+func InsecureTestKey(seed []byte, keyType string) (crypto.Signer, error) {
+	if len(seed) == 0 {
+		return nil, errors.New("crx3/testkey: empty seed")
+	}
+	switch keyType = strings.ToUpper(keyType); keyType {
+	case KeyTypeECDSA:
+		drbg := newHMACDRBG(seed, fmt.Appendf(nil, "%s %s %d", testKeyVersion, keyType, 256))
+		return deriveECDSAKey(drbg)
+	case KeyTypeRSA:
+		bits := defaultKeySize
+		drbg := newHMACDRBG(seed, fmt.Appendf(nil, "%s %s %d", testKeyVersion, keyType, bits))
+		return deriveRSAKey(drbg, bits)
+	}
+	return nil, fmt.Errorf("%w: key type %q", ErrUnsupportedKeyType, keyType)
+}
+
+func deriveECDSAKey(r io.Reader) (*ecdsa.PrivateKey, error) {
+	curve := elliptic.P256()
+	order := curve.Params().N
+	scalar := make([]byte, (order.BitLen()+7)/8)
+	for {
+		if _, err := io.ReadFull(r, scalar); err != nil {
+			return nil, err
+		}
+		if d := new(big.Int).SetBytes(scalar); d.Sign() > 0 && d.Cmp(order) < 0 {
+			return ecdsa.ParseRawPrivateKey(curve, scalar)
+		}
+	}
+}
+
+func deriveRSAKey(r io.Reader, bits int) (*rsa.PrivateKey, error) {
+	const e = 65537
+	p, err := derivePrime(r, bits/2, e)
+	if err != nil {
+		return nil, err
+	}
+	q := p
+	for q.Cmp(p) == 0 {
+		if q, err = derivePrime(r, bits-bits/2, e); err != nil {
+			return nil, err
+		}
+	}
+	one := big.NewInt(1)
+	phi := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+	key := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{N: new(big.Int).Mul(p, q), E: e},
+		D:         new(big.Int).ModInverse(big.NewInt(e), phi),
+		Primes:    []*big.Int{p, q},
+	}
+	key.Precompute()
+	if err := key.Validate(); err != nil {
+		return nil, fmt.Errorf("crx3/testkey: %w", err)
+	}
+	return key, nil
+}
+
+// derivePrime returns the first prime of exactly bits bits read from r for
+// which p-1 is coprime to e. The two top bits are set so that the product of
+// two such primes has exactly twice as many bits.
+func derivePrime(r io.Reader, bits int, e int64) (*big.Int, error) {
+	buf := make([]byte, (bits+7)/8)
+	one, exp, gcd := big.NewInt(1), big.NewInt(e), new(big.Int)
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		p := new(big.Int).SetBytes(buf)
+		for i := bits; i < len(buf)*8; i++ {
+			p.SetBit(p, i, 0)
+		}
+		p.SetBit(p, bits-1, 1)
+		p.SetBit(p, bits-2, 1)
+		p.SetBit(p, 0, 1)
+		if p.ProbablyPrime(20) && gcd.GCD(nil, nil, exp, new(big.Int).Sub(p, one)).Cmp(one) == 0 {
+			return p, nil
+		}
+	}
+}
+
+// hmacDRBG is the HMAC_DRBG of NIST SP 800-90A Rev. 1, section 10.1.2, with
+// SHA-256 and without reseeding or additional input. Every Read is one
+// generate call.
+type hmacDRBG struct {
+	k, v []byte
+}
+
+func newHMACDRBG(entropy, personalization []byte) *hmacDRBG {
+	d := &hmacDRBG{
+		k: make([]byte, sha256.Size),
+		v: make([]byte, sha256.Size),
+	}
+	for i := range d.v {
+		d.v[i] = 0x01
+	}
+	d.update(entropy, personalization)
+	return d
+}
+
+func (d *hmacDRBG) mac(data ...[]byte) []byte {
+	h := hmac.New(sha256.New, d.k)
+	for _, b := range data {
+		h.Write(b)
+	}
+	return h.Sum(nil)
+}
+
+// update is HMAC_DRBG_Update with the concatenation of data as provided data.
+func (d *hmacDRBG) update(data ...[]byte) {
+	d.k = d.mac(append([][]byte{d.v, {0x00}}, data...)...)
+	d.v = d.mac(d.v)
+	provided := 0
+	for _, b := range data {
+		provided += len(b)
+	}
+	if provided == 0 {
+		return
+	}
+	d.k = d.mac(append([][]byte{d.v, {0x01}}, data...)...)
+	d.v = d.mac(d.v)
+}
+
+// Read fills p with DRBG output.
+func (d *hmacDRBG) Read(p []byte) (int, error) {
+	for n := 0; n < len(p); {
+		d.v = d.mac(d.v)
+		n += copy(p[n:], d.v)
+	}
+	d.update()
+	return len(p), nil
+}
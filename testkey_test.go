@@ -0,0 +1,72 @@
+package crx3
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHMACDRBG(t *testing.T) {
+	// NIST CAVP HMAC_DRBG SHA-256, no reseed, no personalization, COUNT = 0:
+	// the second 1024-bit generate call returns the expected bits.
+	seed, err := hex.DecodeString("ca851911349384bffe89de1cbdc46e6831e44d34a4fb935ee285dd14b71a7488" +
+		"659ba96c601dc69fc902940805ec0ca8")
+	require.NoError(t, err)
+	drbg := newHMACDRBG(seed, nil)
+	out := make([]byte, 128)
+	_, _ = drbg.Read(out)
+	_, _ = drbg.Read(out)
+	assert.Equal(t, "e528e9abf2dece54d47c7e75e5fe302149f817ea9fb4bee6f4199697d04d5b89"+
+		"d54fbb978a15b5c443c9ec21036d2460b6f73ebad0dc2aba6e624abf07745bc1"+
+		"07694bb7547bb0995f70de25d6b29e2d3011bb19d27676c07162c8b5ccde0668"+
+		"961df86803482cb37ed6d5c0bb8d50cf1f50d476aa0458bdaba806f48be9dcb8", hex.EncodeToString(out))
+}
+
+func TestInsecureTestKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		seed     string
+		keyType  string
+		expected string
+	}{
+		{name: "ecdsa", seed: "fixture", keyType: KeyTypeECDSA, expected: "mnphcnffceeaaggknmmikniolinfnaha"},
+		{name: "rsa", seed: "fixture", keyType: "rsa", expected: "kpoenolmifjbgpdhnpancjhhbkkmbfdk"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := InsecureTestKey([]byte(tt.seed), tt.keyType)
+			require.NoError(t, err)
+			id, err := KeyID(key)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, id)
+
+			again, err := InsecureTestKey([]byte(tt.seed), tt.keyType)
+			require.NoError(t, err)
+			assert.True(t, key.(interface{ Equal(crypto.PrivateKey) bool }).Equal(again))
+			other, err := InsecureTestKey([]byte(tt.seed+"!"), tt.keyType)
+			require.NoError(t, err)
+			otherID, err := KeyID(other)
+			require.NoError(t, err)
+			assert.NotEqual(t, id, otherID)
+		})
+	}
+
+	key, err := InsecureTestKey([]byte("fixture"), KeyTypeRSA)
+	require.NoError(t, err)
+	require.IsType(t, &rsa.PrivateKey{}, key)
+	assert.Equal(t, 2048, key.(*rsa.PrivateKey).N.BitLen())
+	require.NoError(t, key.(*rsa.PrivateKey).Validate())
+	key, err = InsecureTestKey([]byte("fixture"), KeyTypeECDSA)
+	require.NoError(t, err)
+	assert.IsType(t, &ecdsa.PrivateKey{}, key)
+
+	_, err = InsecureTestKey(nil, KeyTypeRSA)
+	assert.EqualError(t, err, "crx3/testkey: empty seed")
+	_, err = InsecureTestKey([]byte("fixture"), "dsa")
+	assert.ErrorIs(t, err, ErrUnsupportedKeyType)
+}
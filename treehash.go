@@ -0,0 +1,294 @@
+package crx3
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Locations of the content verification metadata inside an extension.
+const (
+	ComputedHashesFilename   = "_metadata/computed_hashes.json"
+	VerifiedContentsFilename = "_metadata/verified_contents.json"
+
+	// ContentHashBlockSize is the block size Chrome uses for content hashes.
+	ContentHashBlockSize = 4096
+
+	metadataDir          = "_metadata"
+	computedHashesFormat = 2
+)
+
+// ErrVerifiedContentsNotFound is returned when an extension has no verified_contents.json.
+var ErrVerifiedContentsNotFound = errors.New("crx3: verified contents not found")
+
+// BlockHashes reads r to the end and returns the SHA-256 hash of every
+// blockSize block. Empty input yields the hash of the empty string.
+func BlockHashes(r io.Reader, blockSize int) ([][]byte, error) {
+	var hashes [][]byte
+	buf := make([]byte, blockSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 || len(hashes) == 0 && err == io.EOF {
+			sum := sha256.Sum256(buf[:n])
+			hashes = append(hashes, sum[:])
+		}
+		switch err {
+		case nil:
+			continue
+		case io.EOF, io.ErrUnexpectedEOF:
+			return hashes, nil
+		default:
+			return nil, err
+		}
+	}
+}
+
+// TreeHash computes the root of the hash tree Chrome builds over block hashes.
+// Each level hashes the concatenation of up to hashBlockSize/32 child hashes
+// until a single hash is left. A single leaf is its own root.
+func TreeHash(leaves [][]byte, hashBlockSize int) []byte {
+	if len(leaves) == 0 {
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	}
+	branchFactor := max(hashBlockSize/sha256.Size, 2)
+	level := leaves
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+branchFactor-1)/branchFactor)
+		for chunk := range slices.Chunk(level, branchFactor) {
+			h := sha256.New()
+			for _, leaf := range chunk {
+				h.Write(leaf)
+			}
+			next = append(next, h.Sum(nil))
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// ComputedHashes is the content of _metadata/computed_hashes.json,
+// which Chrome writes when it installs an extension.
+type ComputedHashes struct {
+	FileHashes []ComputedFileHashes `json:"file_hashes"`
+	Version    int                  `json:"version"`
+}
+
+// ComputedFileHashes holds the base64-encoded block hashes of one file.
+type ComputedFileHashes struct {
+	BlockHashes []string `json:"block_hashes"`
+	BlockSize   int      `json:"block_size"`
+	Path        string   `json:"path"`
+}
+
+// ComputeHashes returns the block hashes of every file of an extension in an
+// unpacked directory, a ZIP archive or a CRX3 file, sorted by path.
+// Files in the _metadata directory are skipped.
+func ComputeHashes(path string) (*ComputedHashes, error) {
+	hashes := &ComputedHashes{Version: computedHashesFormat, FileHashes: []ComputedFileHashes{}}
+	err := walkContentFiles(path, func(name string, r io.Reader) error {
+		blocks, err := BlockHashes(r, ContentHashBlockSize)
+		if err != nil {
+			return err
+		}
+		encoded := make([]string, len(blocks))
+		for i, b := range blocks {
+			encoded[i] = base64.StdEncoding.EncodeToString(b)
+		}
+		hashes.FileHashes = append(hashes.FileHashes, ComputedFileHashes{
+			BlockHashes: encoded,
+			BlockSize:   ContentHashBlockSize,
+			Path:        name,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(hashes.FileHashes, func(a, b ComputedFileHashes) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return hashes, nil
+}
+
+// WriteComputedHashes computes the block hashes of the unpacked extension
+// in dir and writes them to dir/_metadata/computed_hashes.json.
+func WriteComputedHashes(dir string) error {
+	if !isDir(dir) {
+		return fmt.Errorf("%w: %s", ErrPathNotFound, dir)
+	}
+	hashes, err := ComputeHashes(dir)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(hashes)
+	if err != nil {
+		return err
+	}
+	filename := filepath.Join(dir, filepath.FromSlash(ComputedHashesFilename))
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// walkContentFiles is walkExtensionFiles without the _metadata directory.
+func walkContentFiles(path string, fn func(name string, r io.Reader) error) error {
+	return walkExtensionFiles(path, func(name string, r io.Reader) error {
+		if strings.HasPrefix(name, metadataDir+"/") {
+			return nil
+		}
+		return fn(name, r)
+	})
+}
+
+// VerifiedContents is the parsed payload of _metadata/verified_contents.json,
+// which the Chrome Web Store adds to the CRX files it serves.
+type VerifiedContents struct {
+	ItemID        string `json:"item_id"`
+	ItemVersion   string `json:"item_version"`
+	BlockSize     int    `json:"block_size"`
+	HashBlockSize int    `json:"hash_block_size"`
+	// RootHashes maps lower-cased file paths to their raw tree hash roots.
+	RootHashes map[string][]byte `json:"-"`
+	// Signatures lists the key IDs ("publisher", "webstore") that signed the payload.
+	Signatures []string `json:"signatures"`
+}
+
+type verifiedContentsPayload struct {
+	ContentHashes []struct {
+		BlockSize     int    `json:"block_size"`
+		HashBlockSize int    `json:"hash_block_size"`
+		Format        string `json:"format"`
+		Digest        string `json:"digest"`
+		Files         []struct {
+			Path     string `json:"path"`
+			RootHash string `json:"root_hash"`
+		} `json:"files"`
+	} `json:"content_hashes"`
+	ItemID      string `json:"item_id"`
+	ItemVersion string `json:"item_version"`
+}
+
+// ParseVerifiedContents decodes the treehash payload of a verified_contents.json
+// file. The signatures are listed but not verified.
+func ParseVerifiedContents(data []byte) (*VerifiedContents, error) {
+	var entries []struct {
+		Description   string `json:"description"`
+		SignedContent struct {
+			Payload    string `json:"payload"`
+			Signatures []struct {
+				Header struct {
+					KeyID string `json:"kid"`
+				} `json:"header"`
+			} `json:"signatures"`
+		} `json:"signed_content"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("crx3: failed to parse verified contents: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.Description != "treehash per file" {
+			continue
+		}
+		raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(entry.SignedContent.Payload, "="))
+		if err != nil {
+			return nil, fmt.Errorf("crx3: failed to decode verified contents payload: %w", err)
+		}
+		var payload verifiedContentsPayload
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return nil, fmt.Errorf("crx3: failed to parse verified contents payload: %w", err)
+		}
+		vc := &VerifiedContents{
+			ItemID:      payload.ItemID,
+			ItemVersion: payload.ItemVersion,
+			RootHashes:  make(map[string][]byte),
+		}
+		for _, sig := range entry.SignedContent.Signatures {
+			vc.Signatures = append(vc.Signatures, sig.Header.KeyID)
+		}
+		for _, hashes := range payload.ContentHashes {
+			if hashes.Format != "treehash" || hashes.Digest != "sha256" {
+				continue
+			}
+			vc.BlockSize, vc.HashBlockSize = hashes.BlockSize, hashes.HashBlockSize
+			for _, file := range hashes.Files {
+				root, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(file.RootHash, "="))
+				if err != nil {
+					return nil, fmt.Errorf("crx3: invalid root hash of %s: %w", file.Path, err)
+				}
+				vc.RootHashes[strings.ToLower(file.Path)] = root
+			}
+		}
+		if vc.BlockSize <= 0 || vc.HashBlockSize <= 0 {
+			return nil, fmt.Errorf("crx3: verified contents payload has no sha256 treehash")
+		}
+		return vc, nil
+	}
+	return nil, fmt.Errorf("crx3: verified contents have no treehash entry")
+}
+
+// ReadVerifiedContents reads and parses _metadata/verified_contents.json from
+// an unpacked directory, a ZIP archive or a CRX3 file.
+func ReadVerifiedContents(path string) (*VerifiedContents, error) {
+	var data []byte
+	err := walkExtensionFiles(path, func(name string, r io.Reader) (err error) {
+		if name == VerifiedContentsFilename {
+			data, err = io.ReadAll(r)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("%w: %s", ErrVerifiedContentsNotFound, path)
+	}
+	return ParseVerifiedContents(data)
+}
+
+// Verify computes the tree hash of every file of the extension at path (an
+// unpacked directory, a ZIP archive or a CRX3 file) and compares it with
+// the payload. Paths are compared case-insensitively, like Chrome does.
+// Files in the _metadata directory are skipped.
+func (vc *VerifiedContents) Verify(path string) (*IntegrityReport, error) {
+	report := &IntegrityReport{Added: []string{}, Removed: []string{}, Modified: []string{}}
+	seen := make(map[string]bool)
+	err := walkContentFiles(path, func(name string, r io.Reader) error {
+		key := strings.ToLower(name)
+		expected, ok := vc.RootHashes[key]
+		if !ok {
+			report.Added = append(report.Added, name)
+			return nil
+		}
+		seen[key] = true
+		blocks, err := BlockHashes(r, vc.BlockSize)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(TreeHash(blocks, vc.HashBlockSize), expected) {
+			report.Modified = append(report.Modified, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range slices.Sorted(maps.Keys(vc.RootHashes)) {
+		if !seen[name] {
+			report.Removed = append(report.Removed, name)
+		}
+	}
+	slices.Sort(report.Added)
+	slices.Sort(report.Modified)
+	return report, nil
+}
@@ -0,0 +1,156 @@
+package crx3
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockHashes(t *testing.T) {
+	empty := sha256.Sum256(nil)
+	tests := []struct {
+		name   string
+		size   int
+		blocks int
+	}{
+		{name: "empty", size: 0, blocks: 1},
+		{name: "partial block", size: 100, blocks: 1},
+		{name: "one block", size: ContentHashBlockSize, blocks: 1},
+		{name: "two blocks", size: ContentHashBlockSize + 1, blocks: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := bytes.Repeat([]byte{'x'}, tt.size)
+			hashes, err := BlockHashes(bytes.NewReader(data), ContentHashBlockSize)
+			require.NoError(t, err)
+			assert.Len(t, hashes, tt.blocks)
+			if tt.size == 0 {
+				assert.Equal(t, empty[:], hashes[0])
+			}
+		})
+	}
+}
+
+func TestTreeHash(t *testing.T) {
+	leaf := func(b byte) []byte {
+		sum := sha256.Sum256([]byte{b})
+		return sum[:]
+	}
+	concat := func(hashes ...[]byte) []byte {
+		sum := sha256.Sum256(bytes.Join(hashes, nil))
+		return sum[:]
+	}
+
+	// a single leaf is the root
+	assert.Equal(t, leaf(1), TreeHash([][]byte{leaf(1)}, ContentHashBlockSize))
+
+	// with a branch factor of 2, four leaves form two levels
+	leaves := [][]byte{leaf(1), leaf(2), leaf(3), leaf(4)}
+	want := concat(concat(leaf(1), leaf(2)), concat(leaf(3), leaf(4)))
+	assert.Equal(t, want, TreeHash(leaves, 2*sha256.Size))
+
+	// with the default branch factor of 128, they are hashed at once
+	assert.Equal(t, concat(leaves...), TreeHash(leaves, ContentHashBlockSize))
+}
+
+func TestVerifiedContents_Verify(t *testing.T) {
+	for _, path := range []string{
+		"testdata/scanroot/some_extension",
+		"testdata/scanroot/some_extension.zip",
+		"testdata/scanroot/valid.crx",
+	} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			vc, err := ReadVerifiedContents(path)
+			require.NoError(t, err)
+			assert.Equal(t, "kpkcennohgffjdgaelocingbmkjnpjgc", vc.ItemID)
+			assert.Equal(t, "2.1.0", vc.ItemVersion)
+			assert.Equal(t, ContentHashBlockSize, vc.BlockSize)
+			assert.Equal(t, []string{"publisher", "webstore"}, vc.Signatures)
+
+			report, err := vc.Verify(path)
+			require.NoError(t, err)
+			assert.True(t, report.OK(), "%+v", report)
+		})
+	}
+}
+
+func TestVerifiedContents_VerifyTampered(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.CopyFS(dir, os.DirFS("testdata/scanroot/some_extension")))
+	vc, err := ReadVerifiedContents(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, manifestFilename), []byte(`{}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "extra.js"), []byte(`alert(1)`), 0644))
+	require.NoError(t, os.Remove(filepath.Join(dir, "popup.html")))
+
+	report, err := vc.Verify(dir)
+	require.NoError(t, err)
+	assert.Equal(t, &IntegrityReport{
+		Added:    []string{"extra.js"},
+		Removed:  []string{"popup.html"},
+		Modified: []string{manifestFilename},
+	}, report)
+}
+
+func TestParseVerifiedContents_Errors(t *testing.T) {
+	payload := func(s string) string {
+		return `[{"description":"treehash per file","signed_content":{"payload":"` +
+			base64.RawURLEncoding.EncodeToString([]byte(s)) + `"}}]`
+	}
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "not json", data: `{`},
+		{name: "no treehash entry", data: `[{"description":"other"}]`},
+		{name: "invalid payload encoding", data: `[{"description":"treehash per file","signed_content":{"payload":"!!"}}]`},
+		{name: "invalid payload", data: payload(`{`)},
+		{name: "no sha256 treehash", data: payload(`{"content_hashes":[{"format":"treehash","digest":"md5","block_size":4096,"hash_block_size":4096}]}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseVerifiedContents([]byte(tt.data))
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := ReadVerifiedContents(t.TempDir())
+	assert.ErrorIs(t, err, ErrVerifiedContentsNotFound)
+}
+
+func TestWriteComputedHashes(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.CopyFS(dir, os.DirFS("testdata/scanroot/some_extension")))
+	require.NoError(t, WriteComputedHashes(dir))
+
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(ComputedHashesFilename)))
+	require.NoError(t, err)
+	var hashes ComputedHashes
+	require.NoError(t, json.Unmarshal(data, &hashes))
+	assert.Equal(t, 2, hashes.Version)
+
+	// the computed hashes must be consistent with verified_contents.json
+	vc, err := ReadVerifiedContents(dir)
+	require.NoError(t, err)
+	require.Len(t, hashes.FileHashes, len(vc.RootHashes))
+	for _, file := range hashes.FileHashes {
+		assert.False(t, strings.HasPrefix(file.Path, metadataDir+"/"))
+		leaves := make([][]byte, len(file.BlockHashes))
+		for i, h := range file.BlockHashes {
+			leaves[i], err = base64.StdEncoding.DecodeString(h)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, vc.RootHashes[strings.ToLower(file.Path)], TreeHash(leaves, vc.HashBlockSize), file.Path)
+	}
+
+	assert.ErrorIs(t, WriteComputedHashes(filepath.Join(dir, "missing")), ErrPathNotFound)
+}
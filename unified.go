@@ -0,0 +1,209 @@
+package crx3
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	devNull         = "/dev/null"
+	noNewlineMarker = `\ No newline at end of file`
+)
+
+// filePatch holds the hunks of one file of a unified diff.
+type filePatch struct {
+	oldName string
+	newName string
+	hunks   []hunk
+}
+
+type hunk struct {
+	oldStart int
+	oldLines int
+	lines    []string // prefixed with ' ', '-' or '+'
+	// newNoEOL is set if the new side of the hunk ends without a newline.
+	newNoEOL bool
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// parseUnifiedDiff parses a unified diff as produced by "diff -u" or "git diff".
+// File names lose the "a/" and "b/" prefixes.
+func parseUnifiedDiff(data []byte) ([]filePatch, error) {
+	var (
+		patches []filePatch
+		current *filePatch
+		h       *hunk
+		oldLeft int
+		newLeft int
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if strings.HasPrefix(line, `\`) {
+			// "\ No newline at end of file" refers to the previous line
+			if h != nil && len(h.lines) > 0 && h.lines[len(h.lines)-1][0] != '-' {
+				h.newNoEOL = true
+			}
+			continue
+		}
+		if h != nil && (oldLeft > 0 || newLeft > 0) {
+			if len(line) == 0 {
+				line = " "
+			}
+			switch line[0] {
+			case ' ':
+				oldLeft--
+				newLeft--
+			case '-':
+				oldLeft--
+			case '+':
+				newLeft--
+			default:
+				return nil, fmt.Errorf("line %d: unexpected line in hunk: %q", n, line)
+			}
+			if oldLeft < 0 || newLeft < 0 {
+				return nil, fmt.Errorf("line %d: hunk is longer than its header", n)
+			}
+			h.lines = append(h.lines, line)
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			patches = append(patches, filePatch{oldName: diffFileName(line[4:])})
+			current, h = &patches[len(patches)-1], nil
+		case strings.HasPrefix(line, "+++ "):
+			if current == nil {
+				return nil, fmt.Errorf("line %d: +++ without ---", n)
+			}
+			current.newName = diffFileName(line[4:])
+		case strings.HasPrefix(line, "@@"):
+			if current == nil || len(current.newName) == 0 {
+				return nil, fmt.Errorf("line %d: hunk without file header", n)
+			}
+			m := hunkHeader.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("line %d: invalid hunk header %q", n, line)
+			}
+			current.hunks = append(current.hunks, hunk{
+				oldStart: atoiDefault(m[1], 0),
+				oldLines: atoiDefault(m[2], 1),
+			})
+			h = &current.hunks[len(current.hunks)-1]
+			oldLeft, newLeft = h.oldLines, atoiDefault(m[4], 1)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if h != nil && (oldLeft > 0 || newLeft > 0) {
+		return nil, fmt.Errorf("unexpected end of diff in hunk")
+	}
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("no file headers found")
+	}
+	return patches, nil
+}
+
+func diffFileName(s string) string {
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSpace(s)
+	if s == devNull {
+		return s
+	}
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		s = s[2:]
+	}
+	return entryName(s)
+}
+
+func atoiDefault(s string, def int) int {
+	if len(s) == 0 {
+		return def
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// applyHunks applies the hunks to content. Hunks must match exactly, but may
+// be found at an offset from the line numbers in their headers.
+func applyHunks(content []byte, hunks []hunk) ([]byte, error) {
+	lines := strings.SplitAfter(string(content), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	trailingNewline := len(content) == 0 || content[len(content)-1] == '\n'
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\n")
+	}
+
+	var out []string
+	cursor, offset := 0, 0
+	for i, h := range hunks {
+		var oldSide, newSide []string
+		for _, l := range h.lines {
+			if l[0] != '+' {
+				oldSide = append(oldSide, l[1:])
+			}
+			if l[0] != '-' {
+				newSide = append(newSide, l[1:])
+			}
+		}
+		base := h.oldStart - 1
+		if len(oldSide) == 0 {
+			// a pure insertion comes after line oldStart
+			base = h.oldStart
+		}
+		want := base + offset
+		pos := findLines(lines, oldSide, want, cursor)
+		if pos < 0 {
+			return nil, fmt.Errorf("hunk %d (line %d) does not apply", i+1, h.oldStart)
+		}
+		out = append(out, lines[cursor:pos]...)
+		out = append(out, newSide...)
+		cursor = pos + len(oldSide)
+		offset = pos - base
+		if cursor == len(lines) {
+			trailingNewline = !h.newNoEOL
+		}
+	}
+	out = append(out, lines[cursor:]...)
+
+	result := strings.Join(out, "\n")
+	if len(out) > 0 && trailingNewline {
+		result += "\n"
+	}
+	return []byte(result), nil
+}
+
+// findLines returns the index of needle in lines closest to want, not before from.
+func findLines(lines, needle []string, want, from int) int {
+	match := func(pos int) bool {
+		if pos < from || pos+len(needle) > len(lines) {
+			return false
+		}
+		for i, l := range needle {
+			if lines[pos+i] != l {
+				return false
+			}
+		}
+		return true
+	}
+	want = max(want, from)
+	for delta := 0; want-delta >= from || want+delta <= len(lines); delta++ {
+		if match(want - delta) {
+			return want - delta
+		}
+		if match(want + delta) {
+			return want + delta
+		}
+	}
+	return -1
+}
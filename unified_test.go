@@ -0,0 +1,104 @@
+package crx3
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyHunks_RoundTrip(t *testing.T) {
+	lines := func(n int, edit func(i int) string) string {
+		var b strings.Builder
+		for i := 1; i <= n; i++ {
+			b.WriteString(edit(i) + "\n")
+		}
+		return b.String()
+	}
+	orig := lines(40, func(i int) string { return "line " + string(rune('a'+i%26)) })
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{name: "change", a: orig, b: strings.Replace(orig, "line c\n", "line C\n", 1)},
+		{name: "several hunks", a: orig, b: strings.NewReplacer("line b\n", "", "line y\n", "line Y\nline Z\n").Replace(orig)},
+		{name: "append", a: orig, b: orig + "tail\n"},
+		{name: "prepend", a: orig, b: "head\n" + orig},
+		{name: "remove newline at eof", a: "a\nb\n", b: "a\nb"},
+		{name: "add newline at eof", a: "a\nb", b: "a\nb\n"},
+		{name: "from empty", a: "", b: "a\nb\n"},
+		{name: "to empty", a: "a\nb\n", b: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := unifiedDiff("f.txt", []byte(tt.a), []byte(tt.b), 3)
+			require.NoError(t, err)
+			patches, err := parseUnifiedDiff([]byte(diff))
+			require.NoError(t, err)
+			require.Len(t, patches, 1)
+			assert.Equal(t, "f.txt", patches[0].oldName)
+			got, err := applyHunks([]byte(tt.a), patches[0].hunks)
+			require.NoError(t, err)
+			assert.Equal(t, tt.b, string(got))
+		})
+	}
+}
+
+func TestApplyHunks_Offset(t *testing.T) {
+	diff := `--- a/f.js
++++ b/f.js
+@@ -2,3 +2,3 @@
+ two
+-three
++THREE
+ four
+`
+	patches, err := parseUnifiedDiff([]byte(diff))
+	require.NoError(t, err)
+
+	// the hunk applies although two lines were added above it
+	got, err := applyHunks([]byte("new\nnew\none\ntwo\nthree\nfour\n"), patches[0].hunks)
+	require.NoError(t, err)
+	assert.Equal(t, "new\nnew\none\ntwo\nTHREE\nfour\n", string(got))
+
+	_, err = applyHunks([]byte("one\ntwo\n3\nfour\n"), patches[0].hunks)
+	assert.Error(t, err)
+}
+
+func TestParseUnifiedDiff(t *testing.T) {
+	diff := `diff --git a/old.js b/old.js
+deleted file mode 100644
+index 1111111..0000000
+--- a/old.js
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+diff --git a/new.js b/new.js
+new file mode 100644
+--- /dev/null
++++ b/new.js	2024-01-01 00:00:00
+@@ -0,0 +1,2 @@
++a
++b
+`
+	patches, err := parseUnifiedDiff([]byte(diff))
+	require.NoError(t, err)
+	require.Len(t, patches, 2)
+	assert.Equal(t, "old.js", patches[0].oldName)
+	assert.Equal(t, devNull, patches[0].newName)
+	assert.Equal(t, devNull, patches[1].oldName)
+	assert.Equal(t, "new.js", patches[1].newName)
+	assert.Equal(t, []string{"+a", "+b"}, patches[1].hunks[0].lines)
+
+	for _, invalid := range []string{
+		"",
+		"@@ -1 +1 @@\n-a\n+b\n",
+		"--- a\n+++ b\n@@ -1,2 +1,2 @@\n-a\n+b\n",
+		"--- a\n+++ b\n@@ -1 +1 @@\n*a\n",
+		"--- a\n+++ b\n@@ invalid @@\n",
+	} {
+		_, err := parseUnifiedDiff([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
@@ -1,6 +1,7 @@
 package crx3
 
 import (
+	"archive/zip"
 	"bytes"
 	"encoding/binary"
 	"fmt"
@@ -114,6 +115,19 @@ func unpack(filename string, dirname string, conf *unpackOptions) (err error) {
 		return err
 	}
 
+	// record the digests of the original files for integrity checks
+	zr, err := zip.NewReader(reader, size)
+	if err != nil {
+		return err
+	}
+	digests, err := digestZip(zr)
+	if err != nil {
+		return err
+	}
+	if err := WriteSumFile(unpacked, digests); err != nil {
+		return err
+	}
+
 	// write extension id
 	extensionFilename := filepath.Join(unpacked, extensionID)
 	return os.WriteFile(extensionFilename, []byte(makeExtensionID(signedData.CrxId)), 0755)
@@ -0,0 +1,245 @@
+package crx3
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"math"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// VanityOption configures GenerateVanityKey.
+type VanityOption func(*vanityOptions)
+
+// vanityOptions holds configuration for the vanity key search.
+type vanityOptions struct {
+	keyType  string
+	workers  int
+	progress func(VanityStats)
+	interval time.Duration
+}
+
+// VanityKeyType returns a VanityOption that selects the type of the searched
+// keys: KeyTypeRSA (the default, using the default key size) or KeyTypeECDSA.
+// ECDSA keys are generated orders of magnitude faster than RSA keys.
+func VanityKeyType(keyType string) VanityOption {
+	return func(o *vanityOptions) {
+		o.keyType = keyType
+	}
+}
+
+// VanityWorkers returns a VanityOption that sets the number of goroutines
+// generating keys. The default is runtime.NumCPU().
+func VanityWorkers(n int) VanityOption {
+	return func(o *vanityOptions) {
+		o.workers = n
+	}
+}
+
+// VanityProgress returns a VanityOption that calls fn with the search
+// statistics every interval while the search runs.
+func VanityProgress(fn func(VanityStats), interval time.Duration) VanityOption {
+	return func(o *vanityOptions) {
+		o.progress = fn
+		o.interval = interval
+	}
+}
+
+// VanityStats reports the progress of a vanity key search.
+type VanityStats struct {
+	Attempts uint64
+	Elapsed  time.Duration
+	// Expected is the expected number of attempts, 16^len(prefix).
+	Expected float64
+}
+
+// Rate returns the number of keys generated per second.
+func (s VanityStats) Rate() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Attempts) / s.Elapsed.Seconds()
+}
+
+// Probability returns the probability that a key with the prefix would have
+// been found after Attempts attempts.
+func (s VanityStats) Probability() float64 {
+	return -math.Expm1(float64(s.Attempts) * math.Log1p(-1/s.Expected))
+}
+
+// Remaining returns the expected time to find a key at the current rate.
+// As every attempt is independent, it does not decrease with the attempts made.
+func (s VanityStats) Remaining() time.Duration {
+	rate := s.Rate()
+	if rate == 0 {
+		return 0
+	}
+	return time.Duration(s.Expected / rate * float64(time.Second))
+}
+
+// VanityKey is a private key whose extension ID starts with a vanity prefix.
+type VanityKey struct {
+	ID    string
+	Key   crypto.Signer
+	Stats VanityStats
+}
+
+// VanityPrefix translates prefix into the a-p alphabet of extension IDs.
+// Letters a-p are kept and lowercased, digits 0-9 become the letters a-j of
+// the hex digits they stand for in makeExtensionID. Other characters cannot
+// appear in an extension ID, so a prefix like "corp" is impossible and makes
+// VanityPrefix fail.
+func VanityPrefix(prefix string) (string, error) {
+	if len(prefix) == 0 {
+		return "", fmt.Errorf("crx3/vanity: empty prefix")
+	}
+	if len(prefix) > 32 {
+		return "", fmt.Errorf("crx3/vanity: prefix %q is longer than an extension ID", prefix)
+	}
+	var b strings.Builder
+	for _, c := range strings.ToLower(prefix) {
+		switch {
+		case c >= 'a' && c <= 'p':
+			b.WriteRune(c)
+		case c >= '0' && c <= '9':
+			b.WriteRune('a' + c - '0')
+		default:
+			return "", fmt.Errorf("crx3/vanity: prefix %q is impossible: %q is not in a-p or 0-9, the only characters of extension IDs", prefix, c)
+		}
+	}
+	return b.String(), nil
+}
+
+// ExpectedVanityAttempts returns the expected number of keys to generate
+// until the ID of one starts with prefix: 16 per letter.
+func ExpectedVanityAttempts(prefix string) float64 {
+	return math.Pow(16, float64(len(prefix)))
+}
+
+// GenerateVanityKey generates keys on all CPUs until the extension ID of one
+// starts with prefix, which is translated by VanityPrefix first. It returns
+// ctx.Err() if ctx is done before a key is found.
+func GenerateVanityKey(ctx context.Context, prefix string, opts ...VanityOption) (*VanityKey, error) {
+	prefix, err := VanityPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	conf := &vanityOptions{keyType: KeyTypeRSA, workers: runtime.NumCPU()}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	if conf.workers < 1 {
+		conf.workers = 1
+	}
+	switch strings.ToUpper(conf.keyType) {
+	case KeyTypeRSA, KeyTypeECDSA:
+	default:
+		return nil, fmt.Errorf("%w: key type %q", ErrUnsupportedKeyType, conf.keyType)
+	}
+	// the prefix letters are the leading nibbles of the CRX ID
+	nibbles := make([]byte, len(prefix))
+	for i := range prefix {
+		nibbles[i] = prefix[i] - 'a'
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		start    = time.Now()
+		attempts atomic.Uint64
+		once     sync.Once
+		found    *VanityKey
+		errOnce  sync.Once
+		keyErr   error
+		wg       sync.WaitGroup
+	)
+	stats := func() VanityStats {
+		return VanityStats{Attempts: attempts.Load(), Elapsed: time.Since(start), Expected: ExpectedVanityAttempts(prefix)}
+	}
+	for range conf.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				key, sum, err := newVanityCandidate(conf.keyType)
+				if err != nil {
+					errOnce.Do(func() {
+						keyErr = fmt.Errorf("crx3/vanity: %w", err)
+						cancel()
+					})
+					return
+				}
+				attempts.Add(1)
+				if hasNibblePrefix(sum[:], nibbles) {
+					once.Do(func() {
+						found = &VanityKey{ID: string(makeExtensionID(sum[:16])), Key: key}
+						cancel()
+					})
+					return
+				}
+			}
+		}()
+	}
+
+	if conf.progress != nil && conf.interval > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			ticker := time.NewTicker(conf.interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					if ctx.Err() == nil {
+						conf.progress(stats())
+					}
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	if found != nil {
+		found.Stats = stats()
+		return found, nil
+	}
+	if keyErr != nil {
+		return nil, keyErr
+	}
+	return nil, context.Cause(ctx)
+}
+
+// newVanityCandidate returns a new key of keyType and the SHA-256 hash of its public key.
+func newVanityCandidate(keyType string) (crypto.Signer, [sha256.Size]byte, error) {
+	key, err := NewKey(keyType)
+	if err != nil {
+		return nil, [sha256.Size]byte{}, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, [sha256.Size]byte{}, err
+	}
+	return key, sha256.Sum256(der), nil
+}
+
+// hasNibblePrefix reports whether the hex digits of sum start with nibbles.
+func hasNibblePrefix(sum []byte, nibbles []byte) bool {
+	for i, n := range nibbles {
+		b := sum[i/2]
+		if i%2 == 0 {
+			b >>= 4
+		}
+		if b&0x0f != n {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package crx3
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVanityPrefix(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		expected string
+		err      string
+	}{
+		{prefix: "hello", expected: "hello"},
+		{prefix: "HeLLo", expected: "hello"},
+		{prefix: "42", expected: "ec"},
+		{prefix: "p0", expected: "pa"},
+		{prefix: "corp", err: `crx3/vanity: prefix "corp" is impossible: 'r' is not in a-p or 0-9, the only characters of extension IDs`},
+		{prefix: "a-b", err: `crx3/vanity: prefix "a-b" is impossible: '-' is not in a-p or 0-9, the only characters of extension IDs`},
+		{prefix: "", err: "crx3/vanity: empty prefix"},
+		{prefix: strings.Repeat("a", 33), err: `crx3/vanity: prefix "` + strings.Repeat("a", 33) + `" is longer than an extension ID`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			prefix, err := VanityPrefix(tt.prefix)
+			if len(tt.err) > 0 {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, prefix)
+		})
+	}
+}
+
+func TestHasNibblePrefix(t *testing.T) {
+	sum := []byte{0x42, 0xf0}
+	assert.True(t, hasNibblePrefix(sum, nil))
+	assert.True(t, hasNibblePrefix(sum, []byte{4}))
+	assert.True(t, hasNibblePrefix(sum, []byte{4, 2, 15}))
+	assert.False(t, hasNibblePrefix(sum, []byte{2}))
+	assert.False(t, hasNibblePrefix(sum, []byte{4, 2, 0}))
+}
+
+func TestVanityStats(t *testing.T) {
+	s := VanityStats{Attempts: 256, Elapsed: 2 * time.Second, Expected: ExpectedVanityAttempts("ab")}
+	assert.Equal(t, float64(256), s.Expected)
+	assert.Equal(t, float64(128), s.Rate())
+	assert.Equal(t, 2*time.Second, s.Remaining())
+	assert.InDelta(t, 0.632, s.Probability(), 0.001)
+	assert.Zero(t, VanityStats{Expected: 16}.Rate())
+}
+
+func TestGenerateVanityKey(t *testing.T) {
+	key, err := GenerateVanityKey(context.Background(), "0b", VanityKeyType("ecdsa"), VanityWorkers(2),
+		VanityProgress(func(VanityStats) {}, time.Millisecond))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key.ID, "ab"), key.ID)
+	assert.IsType(t, &ecdsa.PrivateKey{}, key.Key)
+	assert.Positive(t, key.Stats.Attempts)
+	id, err := KeyID(key.Key)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, id)
+
+	dst := filepath.Join(t.TempDir(), "vanity.crx")
+	require.NoError(t, Pack("testdata/scanroot/some_extension", dst, nil, PackSigner(key.Key)))
+	info, err := Inspect(dst)
+	require.NoError(t, err)
+	require.Len(t, info.Proofs, 1)
+	assert.Equal(t, AlgorithmECDSA, info.Proofs[0].Algorithm)
+	assert.True(t, info.Proofs[0].SignatureValid)
+	assert.Equal(t, key.ID, info.Proofs[0].ID)
+}
+
+func TestGenerateVanityKey_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := GenerateVanityKey(ctx, "abcdefghijklmnop", VanityKeyType(KeyTypeECDSA))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = GenerateVanityKey(ctx, "abcdefghijklmnop", VanityKeyType(KeyTypeECDSA))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = GenerateVanityKey(context.Background(), "ab", VanityKeyType("dsa"))
+	assert.ErrorIs(t, err, ErrUnsupportedKeyType)
+	_, err = GenerateVanityKey(context.Background(), "xyz")
+	assert.Error(t, err)
+}
+
+func TestNewKey(t *testing.T) {
+	key, err := NewKey(KeyTypeECDSA)
+	require.NoError(t, err)
+	assert.IsType(t, &ecdsa.PrivateKey{}, key)
+	_, err = NewKey("ed25519")
+	assert.ErrorIs(t, err, ErrUnsupportedKeyType)
+}
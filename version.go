@@ -0,0 +1,119 @@
+package crx3
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxVersionComponents = 4
+	maxVersionComponent  = 65535
+)
+
+// Version represents the "version" field of an extension manifest.
+// Chrome accepts one to four dot-separated integers, each between 0 and 65535.
+// Like Chrome, only the first component can't have leading zeros, so "1.01"
+// is valid and equals "1.1", while "01.1" is invalid. The free-form "version_name"
+// field is a display string only and is never parsed into a Version.
+type Version struct {
+	components []uint16
+}
+
+// ParseVersion parses a Chrome extension version string such as "1.2.3.4".
+// It returns ErrInvalidVersion if the string does not follow Chrome's rules.
+func ParseVersion(s string) (Version, error) {
+	if len(s) == 0 {
+		return Version{}, fmt.Errorf("%w: empty string", ErrInvalidVersion)
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > maxVersionComponents {
+		return Version{}, fmt.Errorf("%w: %q has more than %d components",
+			ErrInvalidVersion, s, maxVersionComponents)
+	}
+	components := make([]uint16, 0, len(parts))
+	for i, part := range parts {
+		if len(part) == 0 {
+			return Version{}, fmt.Errorf("%w: %q has an empty component", ErrInvalidVersion, s)
+		}
+		if i == 0 && len(part) > 1 && part[0] == '0' {
+			return Version{}, fmt.Errorf("%w: %q has a leading zero in %q", ErrInvalidVersion, s, part)
+		}
+		if strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' }) != -1 {
+			return Version{}, fmt.Errorf("%w: %q has a non-numeric component %q", ErrInvalidVersion, s, part)
+		}
+		n, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || n > maxVersionComponent {
+			return Version{}, fmt.Errorf("%w: %q component %q is out of range 0-%d",
+				ErrInvalidVersion, s, part, maxVersionComponent)
+		}
+		components = append(components, uint16(n))
+	}
+	return Version{components: components}, nil
+}
+
+// IsValidVersion reports whether s is a valid Chrome extension version.
+func IsValidVersion(s string) bool {
+	_, err := ParseVersion(s)
+	return err == nil
+}
+
+// CompareVersions parses and compares two version strings.
+// See Version.Compare for the meaning of the result.
+func CompareVersions(a, b string) (int, error) {
+	va, err := ParseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := ParseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
+
+// Compare returns -1 if v is older than other, +1 if it is newer and 0 if both are equal.
+// Missing trailing components are treated as zeros, so "1.0" equals "1.0.0.0".
+func (v Version) Compare(other Version) int {
+	n := max(len(v.components), len(other.components))
+	for i := 0; i < n; i++ {
+		a, b := v.component(i), other.component(i)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	}
+	return 0
+}
+
+// IsZero reports whether v is the zero value, i.e. it was not produced by ParseVersion.
+func (v Version) IsZero() bool {
+	return len(v.components) == 0
+}
+
+// Components returns the numeric components of the version.
+func (v Version) Components() []int {
+	out := make([]int, len(v.components))
+	for i, c := range v.components {
+		out[i] = int(c)
+	}
+	return out
+}
+
+// String returns the dotted representation of the version.
+func (v Version) String() string {
+	parts := make([]string, len(v.components))
+	for i, c := range v.components {
+		parts[i] = strconv.Itoa(int(c))
+	}
+	return strings.Join(parts, ".")
+}
+
+func (v Version) component(i int) uint16 {
+	if i < len(v.components) {
+		return v.components[i]
+	}
+	return 0
+}
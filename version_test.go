@@ -0,0 +1,82 @@
+package crx3
+
+import (
+	"cmp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []int
+		str     string
+		wantErr bool
+	}{
+		{name: "should parse single component", input: "1", want: []int{1}},
+		{name: "should parse four components", input: "1.2.3.4", want: []int{1, 2, 3, 4}},
+		{name: "should parse max component", input: "65535.0", want: []int{65535, 0}},
+		{name: "should return error when empty", input: "", wantErr: true},
+		{name: "should return error when more than four components", input: "1.2.3.4.5", wantErr: true},
+		{name: "should return error when component is empty", input: "1..2", wantErr: true},
+		{name: "should parse leading zero after first component", input: "1.01", want: []int{1, 1}, str: "1.1"},
+		{name: "should parse zero components", input: "0.0.0", want: []int{0, 0, 0}},
+		{name: "should return error when first component has leading zero", input: "01.2", wantErr: true},
+		{name: "should return error when component is out of range", input: "65536", wantErr: true},
+		{name: "should return error when component is not numeric", input: "1.2b", wantErr: true},
+		{name: "should return error when component is negative", input: "-1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseVersion(tt.input)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidVersion)
+				assert.True(t, got.IsZero())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Components())
+			assert.Equal(t, cmp.Or(tt.str, tt.input), got.String())
+		})
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.0", b: "1.0.0.0", want: 0},
+		{a: "1.2", b: "1.10", want: -1},
+		{a: "2", b: "1.99.99", want: 1},
+		{a: "1.0.0.1", b: "1.0.0", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			got, err := CompareVersions(tt.a, tt.b)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := CompareVersions("1.0", "x")
+	assert.ErrorIs(t, err, ErrInvalidVersion)
+}
+
+func TestSortByVersion(t *testing.T) {
+	extensions := []*ExtensionInfo{
+		{Path: "c", Version: "1.10"},
+		{Path: "a", Version: "1.2"},
+		{Path: "x", Version: ""},
+		{Path: "b", Version: "1.9.1"},
+	}
+	SortByVersion(extensions)
+	var paths []string
+	for _, ext := range extensions {
+		paths = append(paths, ext.Path)
+	}
+	assert.Equal(t, []string{"x", "a", "b", "c"}, paths)
+}